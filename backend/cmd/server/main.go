@@ -13,6 +13,7 @@ import (
 	"pass-chain/backend/internal/api"
 	"pass-chain/backend/internal/config"
 	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/worker"
 	"pass-chain/backend/pkg/logger"
 )
 
@@ -44,8 +45,27 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Connect backends shared by the API and the workers
+	svc := api.NewServices(cfg, log)
+
 	// Initialize router
-	router := api.NewRouter(cfg, db, log)
+	router := api.NewRouter(cfg, db, svc, log)
+
+	// Start background workers; they stop when the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	reaper := worker.NewReaper(db, svc.Vault, svc.Fabric, time.Duration(cfg.Worker.ExpiryWarning)*time.Second, log)
+	go worker.Every(workerCtx, time.Duration(cfg.Worker.ReaperInterval)*time.Second, "expiry reaper", log, reaper.Run)
+
+	purger := worker.NewPurger(db, svc.Vault, svc.Fabric, log)
+	go worker.Every(workerCtx, time.Duration(cfg.Worker.PurgeInterval)*time.Second, "trash purger", log, purger.Run)
+
+	granter := worker.NewEmergencyGranter(db, svc.Fabric, log)
+	go worker.Every(workerCtx, time.Duration(cfg.Worker.EmergencyInterval)*time.Second, "emergency granter", log, granter.Run)
+
+	refresher := worker.NewShareRefresher(db, svc.Vault, svc.Fabric, time.Duration(cfg.Worker.ShareMaxAge)*time.Second, log)
+	go worker.Every(workerCtx, time.Duration(cfg.Worker.RefreshInterval)*time.Second, "share refresher", log, refresher.Run)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -70,6 +90,7 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down server...")
+	stopWorkers()
 
 	// Give outstanding requests 5 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,579 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+type AccountHandler struct {
+	db       *database.Database
+	vault    *services.VaultService
+	fabric   *services.FabricClient
+	nonces   services.NonceStore
+	nonceTTL time.Duration
+	audit    *auditTrail
+	logger   *logger.Logger
+}
+
+func NewAccountHandler(db *database.Database, vault *services.VaultService, fabric *services.FabricClient, nonces services.NonceStore, nonceTTL time.Duration, log *logger.Logger) *AccountHandler {
+	return &AccountHandler{
+		db:       db,
+		vault:    vault,
+		fabric:   fabric,
+		nonces:   nonces,
+		nonceTTL: nonceTTL,
+		audit:    newAuditTrail(db, fabric, log),
+		logger:   log,
+	}
+}
+
+// linkedWallets returns every wallet linked to the same account as wallet,
+// or just wallet itself when it has no account
+func linkedWallets(db *database.Database, wallet string) []string {
+	var wallets []string
+	db.Model(&models.AccountWallet{}).
+		Where("account_id = (?)", db.Model(&models.AccountWallet{}).Select("account_id").Where("wallet_address = ?", wallet)).
+		Pluck("wallet_address", &wallets)
+
+	if len(wallets) == 0 {
+		return []string{wallet}
+	}
+	return wallets
+}
+
+// linkNonceKey namespaces wallet-link nonces in the nonce store
+func linkNonceKey(nonce string) string {
+	return "link:" + nonce
+}
+
+// unlinkNonceKey namespaces wallet-unlink nonces in the nonce store
+func unlinkNonceKey(nonce string) string {
+	return "unlink:" + nonce
+}
+
+// GetAccount handles GET /api/v1/accounts/me
+func (h *AccountHandler) GetAccount(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var link models.AccountWallet
+	if err := h.db.Where("wallet_address = ?", walletAddress).First(&link).Error; err != nil {
+		// Not linked yet: the wallet is its own single-wallet account
+		c.JSON(http.StatusOK, gin.H{
+			"id":      nil,
+			"wallets": []models.AccountWallet{{WalletAddress: walletAddress}},
+		})
+		return
+	}
+
+	var account models.Account
+	if err := h.db.Preload("Wallets").Where("id = ?", link.AccountID).First(&account).Error; err != nil {
+		h.logger.Error("Failed to fetch account", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// CreateLinkChallenge handles POST /api/v1/accounts/link-challenge
+func (h *AccountHandler) CreateLinkChallenge(c *gin.Context) {
+	var req struct {
+		NewWallet string `json:"newWallet" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	newWallet, err := auth.ChecksumAddress(req.NewWallet)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	if auth.SameAddress(newWallet, walletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot link a wallet to itself"})
+		return
+	}
+
+	nonce, err := auth.NewNonce()
+	if err != nil {
+		h.logger.Error("Failed to generate nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link challenge"})
+		return
+	}
+
+	expiresAt := time.Now().Add(h.nonceTTL).Truncate(time.Second)
+	binding := strings.Join([]string{walletAddress, newWallet, strconv.FormatInt(expiresAt.Unix(), 10)}, "|")
+	if err := h.nonces.Put(c.Request.Context(), linkNonceKey(nonce), binding, h.nonceTTL); err != nil {
+		h.logger.Error("Failed to store link nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nonce":     nonce,
+		"message":   auth.LinkWalletMessage(walletAddress, newWallet, nonce, expiresAt),
+		"expiresAt": expiresAt,
+	})
+}
+
+// LinkWallet handles POST /api/v1/accounts/wallets
+func (h *AccountHandler) LinkWallet(c *gin.Context) {
+	var req models.LinkWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+
+	binding, ok, err := h.nonces.Take(c.Request.Context(), linkNonceKey(req.Nonce))
+	if err != nil {
+		h.logger.Error("Failed to read link nonce", "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to verify link challenge"})
+		return
+	}
+
+	parts := strings.Split(binding, "|")
+	if !ok || len(parts) != 3 || parts[0] != walletAddress || !auth.SameAddress(parts[1], req.NewWallet) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unknown or expired link challenge"})
+		return
+	}
+	newWallet := parts[1]
+
+	expiresUnix, _ := strconv.ParseInt(parts[2], 10, 64)
+	expiresAt := time.Unix(expiresUnix, 0)
+	if time.Now().After(expiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unknown or expired link challenge"})
+		return
+	}
+
+	// Both wallets must have signed the very same challenge
+	message := []byte(auth.LinkWalletMessage(walletAddress, newWallet, req.Nonce, expiresAt))
+	if _, err := auth.VerifySignature(message, req.ExistingSignature, walletAddress); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature from account wallet"})
+		return
+	}
+	if _, err := auth.VerifySignature(message, req.NewSignature, newWallet); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature from new wallet"})
+		return
+	}
+
+	var account models.Account
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.AccountWallet
+		if err := tx.Where("wallet_address = ?", newWallet).First(&existing).Error; err == nil {
+			return errWalletAlreadyLinked
+		}
+
+		// First link: create the account around the caller's wallet
+		var link models.AccountWallet
+		if err := tx.Where("wallet_address = ?", walletAddress).First(&link).Error; err != nil {
+			if err := tx.Create(&account).Error; err != nil {
+				return err
+			}
+			link = models.AccountWallet{WalletAddress: walletAddress, AccountID: account.ID, LinkedAt: time.Now()}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&models.AccountWallet{WalletAddress: newWallet, AccountID: link.AccountID, LinkedAt: time.Now()}).Error; err != nil {
+			return err
+		}
+
+		return tx.Preload("Wallets").Where("id = ?", link.AccountID).First(&account).Error
+	})
+	if errors.Is(err, errWalletAlreadyLinked) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Wallet is already linked to an account"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to link wallet", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link wallet"})
+		return
+	}
+
+	h.logger.Info("Wallet linked", "account", account.ID, "wallet", walletAddress, "newWallet", newWallet)
+
+	c.JSON(http.StatusCreated, account)
+}
+
+var errWalletAlreadyLinked = errors.New("wallet already linked")
+
+// CreateUnlinkChallenge handles POST /api/v1/accounts/unlink-challenge
+//
+// Removing a wallet needs a signature over auth.UnlinkWalletMessage from that
+// wallet or from one linked to the account before it, so a newly linked (or
+// stolen) wallet cannot push out the wallets that vouched for it.
+func (h *AccountHandler) CreateUnlinkChallenge(c *gin.Context) {
+	var req struct {
+		Wallet string `json:"wallet" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	target, ok := findWallet(linkedWallets(h.db, walletAddress), req.Wallet)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found on this account"})
+		return
+	}
+
+	nonce, err := auth.NewNonce()
+	if err != nil {
+		h.logger.Error("Failed to generate nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create unlink challenge"})
+		return
+	}
+
+	expiresAt := time.Now().Add(h.nonceTTL).Truncate(time.Second)
+	binding := strings.Join([]string{walletAddress, target, strconv.FormatInt(expiresAt.Unix(), 10)}, "|")
+	if err := h.nonces.Put(c.Request.Context(), unlinkNonceKey(nonce), binding, h.nonceTTL); err != nil {
+		h.logger.Error("Failed to store unlink nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create unlink challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nonce":     nonce,
+		"message":   auth.UnlinkWalletMessage(target, nonce, expiresAt),
+		"expiresAt": expiresAt,
+	})
+}
+
+// UnlinkWallet handles DELETE /api/v1/accounts/wallets/:address
+//
+// X-Unlink-Nonce and X-Unlink-Signature carry the unlink challenge, signed by
+// the wallet being removed or by a wallet linked before it.
+func (h *AccountHandler) UnlinkWallet(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var link models.AccountWallet
+	if err := h.db.Where("wallet_address = ?", walletAddress).First(&link).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet is not linked to an account"})
+		return
+	}
+
+	var target models.AccountWallet
+	if err := h.db.Where("account_id = ? AND LOWER(wallet_address) = LOWER(?)", link.AccountID, c.Param("address")).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found on this account"})
+		return
+	}
+
+	nonce := c.GetHeader("X-Unlink-Nonce")
+	signature := c.GetHeader("X-Unlink-Signature")
+	if nonce == "" || signature == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unlinking requires a signed unlink challenge"})
+		return
+	}
+
+	// Single use: the nonce is gone whether or not the signature checks out
+	binding, ok, err := h.nonces.Take(c.Request.Context(), unlinkNonceKey(nonce))
+	if err != nil {
+		h.logger.Error("Failed to read unlink nonce", "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to verify unlink challenge"})
+		return
+	}
+
+	parts := strings.Split(binding, "|")
+	if !ok || len(parts) != 3 || parts[0] != walletAddress || parts[1] != target.WalletAddress {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unknown or expired unlink challenge"})
+		return
+	}
+
+	expiresUnix, _ := strconv.ParseInt(parts[2], 10, 64)
+	expiresAt := time.Unix(expiresUnix, 0)
+	if time.Now().After(expiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unknown or expired unlink challenge"})
+		return
+	}
+
+	signer, err := auth.RecoverAddress([]byte(auth.UnlinkWalletMessage(target.WalletAddress, nonce, expiresAt)), signature)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid unlink signature"})
+		return
+	}
+	if !auth.SameAddress(signer, target.WalletAddress) {
+		var senior int64
+		h.db.Model(&models.AccountWallet{}).
+			Where("account_id = ? AND LOWER(wallet_address) = LOWER(?) AND linked_at < ?", link.AccountID, signer, target.LinkedAt).
+			Count(&senior)
+		if senior == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Unlink must be signed by the wallet itself or one linked before it"})
+			return
+		}
+	}
+
+	if err := h.db.Where("account_id = ? AND wallet_address = ?", link.AccountID, target.WalletAddress).Delete(&models.AccountWallet{}).Error; err != nil {
+		h.logger.Error("Failed to unlink wallet", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink wallet"})
+		return
+	}
+
+	h.logger.Info("Wallet unlinked", "account", link.AccountID, "wallet", target.WalletAddress, "signer", signer)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Wallet unlinked successfully"})
+}
+
+// MigrateWallet handles POST /api/v1/accounts/migrate
+//
+// Moves what the account keeps under one linked wallet to another. Credentials
+// (trashed ones included) move one at a time with their whole version history
+// (DB rows, every Vault version of share1, share2 on Fabric), their audit
+// entries, shares and links; a failed credential is reported and left on the
+// old wallet. API keys, folders, tags, emergency contacts, notifications and
+// the rest of the audit trail only move once every credential has, so the
+// call can simply be repeated until it succeeds.
+func (h *AccountHandler) MigrateWallet(c *gin.Context) {
+	var req models.MigrateWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	wallets := linkedWallets(h.db, walletAddress)
+
+	fromWallet, fromOK := findWallet(wallets, req.FromWallet)
+	toWallet, toOK := findWallet(wallets, req.ToWallet)
+	if !fromOK || !toOK || fromWallet == toWallet {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Both wallets must be distinct and linked to your account"})
+		return
+	}
+
+	var credentials []models.Credential
+	// Organization credentials belong to the organization, not their creator
+	if err := h.db.Unscoped().Where("wallet_address = ? AND org_id IS NULL", fromWallet).Find(&credentials).Error; err != nil {
+		h.logger.Error("Failed to fetch credentials", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credentials"})
+		return
+	}
+
+	migrated := []string{}
+	failed := []gin.H{}
+	for i := range credentials {
+		credential := &credentials[i]
+		if err := h.migrateCredential(credential, fromWallet, toWallet); err != nil {
+			h.logger.Error("Failed to migrate credential", "id", credential.ID, "error", err)
+			failed = append(failed, gin.H{"id": credential.ID, "error": err.Error()})
+			continue
+		}
+		h.audit.record(c, toWallet, credential.ID, "migrate")
+		migrated = append(migrated, credential.ID)
+	}
+
+	if len(failed) == 0 {
+		if err := h.migrateOwnership(fromWallet, toWallet); err != nil {
+			h.logger.Error("Failed to migrate wallet ownership", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Credentials were migrated but the rest was not; repeat the call", "migrated": migrated})
+			return
+		}
+	}
+
+	h.logger.Info("Wallet migrated", "from", fromWallet, "to", toWallet, "migrated", len(migrated), "failed", len(failed))
+
+	status := http.StatusOK
+	if len(failed) > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{
+		"fromWallet": fromWallet,
+		"toWallet":   toWallet,
+		"migrated":   migrated,
+		"failed":     failed,
+	})
+}
+
+// migrateCredential moves credential to toWallet. Every share1 version in its
+// history is copied, oldest first, to the new Vault path and the history rows
+// are repointed at the copies, so rollback keeps working; versions Vault no
+// longer has stay unrestorable. share2 of every version is stored on Fabric
+// under the new wallet. The old path is purged once the DB points at the new.
+func (h *AccountHandler) migrateCredential(credential *models.Credential, fromWallet, toWallet string) error {
+	if err := ensureVersion(h.db.DB, credential); err != nil {
+		return err
+	}
+	var history []models.CredentialVersion
+	if err := h.db.Where("credential_id = ?", credential.ID).Order("version").Find(&history).Error; err != nil {
+		return err
+	}
+
+	// Clear anything an earlier attempt left at the new path
+	newPath := credentialVaultPath(toWallet, credential.ID)
+	if err := h.vault.PurgeSecret(newPath); err != nil {
+		return err
+	}
+
+	vaultVersions := make(map[int]int, len(history)) // Credential version → copy's Vault version
+	for _, entry := range history {
+		var data map[string]interface{}
+		var err error
+		switch {
+		case entry.VaultVersion != 0:
+			data, err = h.vault.ReadSecretVersion(credential.VaultPath, entry.VaultVersion)
+		case entry.Version == credential.Version:
+			data, err = h.vault.ReadSecret(credential.VaultPath) // From before version history
+		default:
+			continue
+		}
+		if err != nil {
+			if entry.Version == credential.Version {
+				h.purgeSecret(newPath)
+				return err
+			}
+			h.logger.Warn("Share1 of an old version is gone from Vault; not migrating it", "id", credential.ID, "version", entry.Version, "error", err)
+			continue
+		}
+
+		written, err := h.vault.WriteSecretVersion(newPath, data)
+		if err != nil {
+			h.purgeSecret(newPath)
+			return err
+		}
+		vaultVersions[entry.Version] = written
+	}
+
+	txID := credential.BlockchainTxID
+	if h.fabric != nil {
+		for _, entry := range history {
+			// The first version's key is the last segment of the new path
+			key := credential.ID
+			if entry.Version > 1 {
+				key = services.ShardKey(credential.ID, entry.Version)
+			}
+			id, err := h.fabric.StoreShard(toWallet, key, entry.Share2)
+			if err != nil {
+				h.logger.Error("Failed to store in Fabric", "id", credential.ID, "version", entry.Version, "error", err)
+				continue
+			}
+			if entry.Version == credential.Version {
+				txID = id
+			}
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Guard against an update or share refresh since the shares were read
+		result := tx.Unscoped().Model(&models.Credential{}).
+			Where("id = ? AND wallet_address = ? AND version = ? AND share2 = ?", credential.ID, fromWallet, credential.Version, credential.Share2).
+			Updates(map[string]interface{}{
+				"wallet_address":   toWallet,
+				"vault_path":       newPath,
+				"blockchain_tx_id": txID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+
+		for _, entry := range history {
+			if err := tx.Model(&models.CredentialVersion{}).Where("id = ?", entry.ID).
+				Update("vault_version", vaultVersions[entry.Version]).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.AuditLog{}).Where("credential_id = ? AND wallet_address = ?", credential.ID, fromWallet).
+			Update("wallet_address", toWallet).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CredentialShare{}).Where("credential_id = ?", credential.ID).
+			Update("owner_wallet", toWallet).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ShareLink{}).Where("credential_id = ?", credential.ID).
+			Update("owner_wallet", toWallet).Error
+	})
+	if err != nil {
+		h.purgeSecret(newPath)
+		return err
+	}
+
+	if err := h.vault.PurgeSecret(credential.VaultPath); err != nil {
+		h.logger.Error("Failed to purge old Vault path", "path", credential.VaultPath, "error", err)
+	}
+
+	return nil
+}
+
+// migrateOwnership moves what fromWallet owns outside its credentials to
+// toWallet. Tags merge into toWallet's tags of the same name. Shares and
+// emergency access given to fromWallet stay with it: they are wrapped to its
+// encryption key.
+func (h *AccountHandler) migrateOwnership(fromWallet, toWallet string) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []interface{}{&models.APIKey{}, &models.AuditLog{}, &models.Folder{}, &models.Notification{}} {
+			if err := tx.Model(table).Where("wallet_address = ?", fromWallet).Update("wallet_address", toWallet).Error; err != nil {
+				return err
+			}
+		}
+		for _, table := range []interface{}{&models.EmergencyContact{}, &models.EmergencyRequest{}, &models.CredentialShare{}, &models.ShareLink{}} {
+			if err := tx.Model(table).Where("owner_wallet = ?", fromWallet).Update("owner_wallet", toWallet).Error; err != nil {
+				return err
+			}
+		}
+
+		var tags []models.Tag
+		if err := tx.Where("wallet_address = ?", fromWallet).Find(&tags).Error; err != nil {
+			return err
+		}
+		for i := range tags {
+			tag := &tags[i]
+			var existing models.Tag
+			err := tx.Where("wallet_address = ? AND name = ?", toWallet, tag.Name).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Model(tag).Update("wallet_address", toWallet).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("INSERT INTO credential_tags (credential_id, tag_id) SELECT credential_id, ? FROM credential_tags WHERE tag_id = ? ON CONFLICT DO NOTHING", existing.ID, tag.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM credential_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(tag).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (h *AccountHandler) purgeSecret(vaultPath string) {
+	if err := h.vault.PurgeSecret(vaultPath); err != nil {
+		h.logger.Error("Failed to purge Vault path", "path", vaultPath, "error", err)
+	}
+}
+
+// findWallet returns the entry of wallets matching address, ignoring checksum casing
+func findWallet(wallets []string, address string) (string, bool) {
+	for _, wallet := range wallets {
+		if auth.SameAddress(wallet, address) {
+			return wallet, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/models"
+)
+
+// linkWallets puts wallets on one new account
+func (e *testEnv) linkWallets(wallets ...testWallet) {
+	e.t.Helper()
+	account := &models.Account{}
+	if err := e.db.Create(account).Error; err != nil {
+		e.t.Fatal(err)
+	}
+	for _, wallet := range wallets {
+		if err := e.db.Create(&models.AccountWallet{WalletAddress: wallet.address, AccountID: account.ID, LinkedAt: time.Now()}).Error; err != nil {
+			e.t.Fatal(err)
+		}
+	}
+}
+
+func TestRollbackAfterMigration(t *testing.T) {
+	env := newTestEnv(t)
+	from, to := newTestWallet(t), newTestWallet(t)
+	env.linkWallets(from, to)
+
+	id := env.createCredential(from, "djE=")
+	oldPath := env.credential(id).VaultPath
+	if status, body := env.updateSecret(from, id, "djI=", 1); status != http.StatusOK {
+		t.Fatalf("update: status %d, body %v", status, body)
+	}
+
+	var migration struct {
+		Migrated []string `json:"migrated"`
+		Failed   []gin.H  `json:"failed"`
+	}
+	status := env.do(from, http.MethodPost, "/accounts/migrate", gin.H{"fromWallet": from.address, "toWallet": to.address}, nil, &migration)
+	if status != http.StatusOK || len(migration.Migrated) != 1 || len(migration.Failed) != 0 {
+		t.Fatalf("migrate: status %d, %+v", status, migration)
+	}
+
+	credential := env.credential(id)
+	if credential.WalletAddress != to.address || credential.VaultPath != credentialVaultPath(to.address, id) {
+		t.Fatalf("credential = %s at %s", credential.WalletAddress, credential.VaultPath)
+	}
+	if total, _ := env.vault.versions(oldPath); total != 0 {
+		t.Errorf("old Vault path still holds %d versions", total)
+	}
+	if total, live := env.vault.versions(credential.VaultPath); total != 2 || live != 2 {
+		t.Errorf("new Vault path holds %d versions (%d live), want both", total, live)
+	}
+
+	// Version 1 lived only at the old path before the migration
+	var rollback map[string]interface{}
+	status = env.do(to, http.MethodPost, "/credentials/"+id+"/rollback", gin.H{"version": 1}, nil, &rollback)
+	if status != http.StatusOK || rollback["version"] != float64(3) || rollback["restoredFrom"] != float64(1) {
+		t.Fatalf("rollback: status %d, body %v", status, rollback)
+	}
+
+	status, revealed := env.reveal(to, id)
+	if status != http.StatusOK || revealed["share1"] != "djE=" || revealed["encryptedData"] != "ZW5j" {
+		t.Errorf("reveal: status %d, share1 %v, encryptedData %v", status, revealed["share1"], revealed["encryptedData"])
+	}
+}
+
+func TestMigrateWalletNotLinked(t *testing.T) {
+	env := newTestEnv(t)
+	from, stranger := newTestWallet(t), newTestWallet(t)
+	env.linkWallets(from, newTestWallet(t))
+	id := env.createCredential(from, "djE=")
+
+	status := env.do(from, http.MethodPost, "/accounts/migrate", gin.H{"fromWallet": from.address, "toWallet": stranger.address}, nil, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("migrate to an unlinked wallet: status %d, want %d", status, http.StatusBadRequest)
+	}
+	if credential := env.credential(id); credential.WalletAddress != from.address {
+		t.Errorf("credential moved to %s", credential.WalletAddress)
+	}
+}
+
+// unlink signs a fresh unlink challenge for target as signer and sends it as caller
+func (e *testEnv) unlink(caller, signer testWallet, target string) int {
+	e.t.Helper()
+	var challenge struct {
+		Nonce   string `json:"nonce"`
+		Message string `json:"message"`
+	}
+	if status := e.do(caller, http.MethodPost, "/accounts/unlink-challenge", gin.H{"wallet": target}, nil, &challenge); status != http.StatusOK {
+		return status
+	}
+
+	header := http.Header{}
+	header.Set("X-Unlink-Nonce", challenge.Nonce)
+	header.Set("X-Unlink-Signature", signer.sign(challenge.Message))
+	return e.do(caller, http.MethodDelete, "/accounts/wallets/"+target, nil, header, nil)
+}
+
+func TestUnlinkWallet(t *testing.T) {
+	env := newTestEnv(t)
+	first, second, third, stranger := newTestWallet(t), newTestWallet(t), newTestWallet(t), newTestWallet(t)
+	env.linkWallets(first, second, third)
+
+	tests := []struct {
+		name   string
+		caller testWallet
+		signer testWallet
+		target testWallet
+		want   int
+	}{
+		{"newer wallet signs for an older one", third, third, first, http.StatusForbidden},
+		{"caller signs for a newer wallet it does not hold", second, stranger, third, http.StatusForbidden},
+		{"wallet off the account", first, stranger, stranger, http.StatusNotFound},
+		{"older wallet signs", third, first, second, http.StatusOK},
+		{"wallet signs for itself", first, third, third, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := env.unlink(tt.caller, tt.signer, tt.target.address); status != tt.want {
+				t.Errorf("status %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	if wallets := linkedWallets(env.db, first.address); len(wallets) != 1 || wallets[0] != first.address {
+		t.Errorf("linked wallets = %v, want only %s", wallets, first.address)
+	}
+
+	if status := env.do(first, http.MethodDelete, "/accounts/wallets/"+first.address, nil, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("unlink without a challenge: status %d, want %d", status, http.StatusUnauthorized)
+	}
+}
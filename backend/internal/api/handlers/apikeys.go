@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/pkg/logger"
+)
+
+// maxAPIKeyLifetime bounds how far in the future a key may expire
+const maxAPIKeyLifetime = 365 * 24 * time.Hour
+
+type APIKeyHandler struct {
+	db     *database.Database
+	logger *logger.Logger
+}
+
+func NewAPIKeyHandler(db *database.Database, log *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		db:     db,
+		logger: log,
+	}
+}
+
+// CreateAPIKey handles POST /api/v1/api-keys
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	if !req.ExpiresAt.After(now) || req.ExpiresAt.Sub(now) > maxAPIKeyLifetime {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future and within one year"})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+
+	// Every scoped credential must belong to the caller's account
+	if len(req.CredentialIDs) > 0 {
+		var owned int64
+		h.db.Model(&models.Credential{}).
+			Where("id IN ? AND wallet_address IN ?", req.CredentialIDs, linkedWallets(h.db, walletAddress)).
+			Count(&owned)
+		if int(owned) != len(req.CredentialIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown credential in credentialIds"})
+			return
+		}
+	}
+
+	rawKey, lookupID, keyHash, err := auth.NewAPIKey()
+	if err != nil {
+		h.logger.Error("Failed to generate API key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	key := &models.APIKey{
+		WalletAddress: walletAddress,
+		Name:          req.Name,
+		LookupID:      lookupID,
+		KeyHash:       keyHash,
+		Scope:         req.Scope,
+		CredentialIDs: req.CredentialIDs,
+		Tags:          req.Tags,
+		ExpiresAt:     req.ExpiresAt,
+	}
+
+	if err := h.db.Create(key).Error; err != nil {
+		h.logger.Error("Failed to save API key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	h.logger.Info("API key created", "id", key.ID, "wallet", walletAddress, "scope", key.Scope)
+
+	// The raw key is returned only once; only its hash is stored
+	c.JSON(http.StatusCreated, gin.H{
+		"apiKey":  key,
+		"key":     rawKey,
+		"message": "Store this key now, it cannot be shown again",
+	})
+}
+
+// GetAPIKeys handles GET /api/v1/api-keys
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var keys []models.APIKey
+	if err := h.db.Where("wallet_address = ?", walletAddress).Order("created_at DESC").Find(&keys).Error; err != nil {
+		h.logger.Error("Failed to fetch API keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey handles DELETE /api/v1/api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	result := h.db.Model(&models.APIKey{}).
+		Where("id = ? AND wallet_address = ? AND revoked_at IS NULL", id, walletAddress).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		h.logger.Error("Failed to revoke API key", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	h.logger.Info("API key revoked", "id", id, "wallet", walletAddress)
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
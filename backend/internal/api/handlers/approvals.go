@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// maxApprovers bounds N of an M-of-N approval policy
+const maxApprovers = 20
+
+// ApprovalHandler lets designated approvers see and sign reveal requests for
+// credentials flagged for M-of-N approval
+type ApprovalHandler struct {
+	db     *database.Database
+	audit  *auditTrail
+	logger *logger.Logger
+}
+
+func NewApprovalHandler(db *database.Database, fabric *services.FabricClient, log *logger.Logger) *ApprovalHandler {
+	return &ApprovalHandler{
+		db:     db,
+		audit:  newAuditTrail(db, fabric, log),
+		logger: log,
+	}
+}
+
+// approverOf matches approval requests that list any of the wallets bound
+// to it as an approver
+const approverOf = "EXISTS (SELECT 1 FROM jsonb_array_elements_text(approvers) AS approver WHERE approver IN ?)"
+
+// notify writes a notification, about credentialID unless it is empty.
+// Failures are logged but never fail the request.
+func notify(db *database.Database, log *logger.Logger, wallet, kind, credentialID, message string) {
+	notification := &models.Notification{
+		WalletAddress: wallet,
+		Kind:          kind,
+		Message:       message,
+	}
+	if credentialID != "" {
+		notification.CredentialID = &credentialID
+	}
+	if err := db.Create(notification).Error; err != nil {
+		log.Error("Failed to create notification", "wallet", wallet, "error", err)
+	}
+}
+
+// SetApprovalPolicy handles PUT /api/v1/credentials/:id/approval-policy
+//
+// Open requests are closed, so they cannot be approved under the old policy.
+// On organization credentials only owners may change the policy. A change
+// that weakens the policy in force (see weakensPolicy) must itself be
+// approved under it: without an approved request in X-Approval-Request for
+// exactly this change, it answers 202 with the caller's open request.
+func (h *CredentialHandler) SetApprovalPolicy(c *gin.Context) {
+	var req models.SetApprovalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot change approval policies"})
+		return
+	}
+
+	credential, status, errMsg := h.findAuthorized(c, id, models.OrgRoleOwner)
+	if errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	var approvers []string
+	if req.Threshold > 0 {
+		if len(req.Approvers) > maxApprovers {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many approvers", "max": maxApprovers})
+			return
+		}
+		if req.Threshold > len(req.Approvers) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold cannot exceed the number of approvers"})
+			return
+		}
+
+		// The owner approving their own reveals would defeat the policy
+		owners := []string{}
+		if credential.OrgID == nil {
+			owners = linkedWallets(h.db, credential.WalletAddress)
+		}
+		for _, address := range req.Approvers {
+			approver, err := auth.ChecksumAddress(address)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid approver wallet", "wallet": address})
+				return
+			}
+			if _, dup := findWallet(approvers, approver); dup {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Duplicate approver wallet", "wallet": address})
+				return
+			}
+			if _, own := findWallet(owners, approver); own {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Approvers must not be linked to the owner's account", "wallet": address})
+				return
+			}
+			approvers = append(approvers, approver)
+		}
+	}
+
+	requestID := ""
+	if weakensPolicy(credential, approvers, req.Threshold) {
+		var ok bool
+		if requestID, ok = h.checkPolicyApproval(c, credential, approvers, req.Threshold); !ok {
+			return
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Claim the approval first, so two changes cannot both use it
+		if requestID != "" {
+			claim := tx.Model(&models.ApprovalRequest{}).
+				Where(approvedFor, requestID, credential.ID, models.ApprovalActionPolicy, linkedWallets(h.db, walletAddress), time.Now()).
+				Update("released_at", time.Now())
+			if claim.Error != nil {
+				return claim.Error
+			}
+			if claim.RowsAffected == 0 {
+				return errApprovalUsed
+			}
+		}
+		if err := tx.Model(credential).Select("approvers", "approval_threshold").
+			Updates(&models.Credential{Approvers: approvers, ApprovalThreshold: req.Threshold}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ApprovalRequest{}).
+			Where("credential_id = ? AND released_at IS NULL AND expires_at > ?", credential.ID, time.Now()).
+			Update("expires_at", time.Now()).Error
+	})
+	if errors.Is(err, errApprovalUsed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Approval was already used or has expired"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to update approval policy", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update approval policy"})
+		return
+	}
+
+	h.audit.recordCredential(c, walletAddress, credential, "approval_policy")
+
+	h.logger.Info("Approval policy changed", "id", id, "wallet", walletAddress, "threshold", req.Threshold, "approvers", len(approvers))
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                credential.ID,
+		"approvers":         approvers,
+		"approvalThreshold": req.Threshold,
+	})
+}
+
+// errApprovalUsed aborts a policy change whose approval was claimed first
+var errApprovalUsed = errors.New("approval already used")
+
+// weakensPolicy reports whether replacing credential's approval policy with
+// threshold of approvers needs approval under the current one. Only raising
+// the threshold or dropping approvers passes without: a lower threshold,
+// removing the policy or adding approvers the current ones never vetted
+// would let fewer of them, or none, stand in the way of a reveal.
+func weakensPolicy(credential *models.Credential, approvers []string, threshold int) bool {
+	if !credential.RequiresApproval() {
+		return false
+	}
+	if threshold < credential.ApprovalThreshold {
+		return true
+	}
+	for _, approver := range approvers {
+		if _, ok := findWallet(credential.Approvers, approver); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// proposes reports whether request proposes exactly threshold of approvers
+func proposes(request *models.ApprovalRequest, approvers []string, threshold int) bool {
+	if request.ProposedThreshold != threshold || len(request.ProposedApprovers) != len(approvers) {
+		return false
+	}
+	for _, approver := range approvers {
+		if _, ok := findWallet(request.ProposedApprovers, approver); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPolicyApproval gates a policy change that weakens the policy in force.
+// It returns the ID of the approved request in X-Approval-Request when that
+// request proposed this exact change. Otherwise it answers 202 with the
+// caller's open request for the change, making one (and notifying the
+// approvers) if there is none.
+func (h *CredentialHandler) checkPolicyApproval(c *gin.Context, credential *models.Credential, approvers []string, threshold int) (string, bool) {
+	walletAddress := middleware.WalletAddress(c)
+	wallets := linkedWallets(h.db, walletAddress)
+
+	if requestID := c.GetHeader("X-Approval-Request"); requestID != "" {
+		var request models.ApprovalRequest
+		err := h.db.Where(approvedFor, requestID, credential.ID, models.ApprovalActionPolicy, wallets, time.Now()).
+			First(&request).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			h.logger.Error("Failed to check approval", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check approval"})
+			return "", false
+		}
+		if err == nil && proposes(&request, approvers, threshold) {
+			return request.ID, true
+		}
+	}
+
+	var open []models.ApprovalRequest
+	err := h.db.Preload("Approvals").
+		Where("credential_id = ? AND action = ? AND requester_wallet IN ? AND released_at IS NULL AND expires_at > ?",
+			credential.ID, models.ApprovalActionPolicy, wallets, time.Now()).
+		Order("created_at DESC").
+		Find(&open).Error
+	if err != nil {
+		h.logger.Error("Failed to fetch approval requests", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request approval"})
+		return "", false
+	}
+	for i := range open {
+		if proposes(&open[i], approvers, threshold) {
+			c.JSON(http.StatusAccepted, gin.H{
+				"approvalRequired": true,
+				"request":          open[i],
+			})
+			return "", false
+		}
+	}
+
+	request := models.ApprovalRequest{
+		CredentialID:      credential.ID,
+		RequesterWallet:   walletAddress,
+		Action:            models.ApprovalActionPolicy,
+		ProposedApprovers: approvers,
+		ProposedThreshold: threshold,
+		Approvers:         credential.Approvers,
+		Threshold:         credential.ApprovalThreshold,
+		ExpiresAt:         time.Now().Add(h.approvalTTL).Truncate(time.Second),
+		Approvals:         []models.Approval{},
+	}
+	if err := h.db.Omit("Approvals").Create(&request).Error; err != nil {
+		h.logger.Error("Failed to open approval request", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request approval"})
+		return "", false
+	}
+
+	h.audit.recordCredential(c, walletAddress, credential, "approval_request")
+	for _, approver := range request.Approvers {
+		notify(h.db, h.logger, approver, models.NotificationApprovalRequest, credential.ID,
+			walletAddress+" asks to change the approval policy of "+credential.CredentialName)
+	}
+	h.logger.Info("Policy change approval requested", "id", request.ID, "credential", credential.ID, "wallet", walletAddress)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"approvalRequired": true,
+		"request":          request,
+	})
+	return "", false
+}
+
+// checkApproval gates revealing a credential flagged for approval. It returns
+// true when the request in X-Approval-Request is approved, along with its ID
+// for releaseApproval once the reveal is ready to answer. Otherwise it
+// answers 202 with the caller's open request, making one (and notifying the
+// approvers) if there is none. Unflagged credentials pass with no ID.
+func (h *CredentialHandler) checkApproval(c *gin.Context, credential *models.Credential) (string, bool) {
+	if !credential.RequiresApproval() {
+		return "", true
+	}
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Credential requires approval; API keys cannot access it"})
+		return "", false
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	wallets := linkedWallets(h.db, walletAddress)
+
+	if requestID := c.GetHeader("X-Approval-Request"); requestID != "" {
+		var count int64
+		err := h.db.Model(&models.ApprovalRequest{}).
+			Where(approvedFor, requestID, credential.ID, models.ApprovalActionReveal, wallets, time.Now()).
+			Count(&count).Error
+		if err != nil {
+			h.logger.Error("Failed to check approval", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check approval"})
+			return "", false
+		}
+		if count == 1 {
+			return requestID, true
+		}
+	}
+
+	var request models.ApprovalRequest
+	err := h.db.Preload("Approvals").
+		Where("credential_id = ? AND action = ? AND requester_wallet IN ? AND released_at IS NULL AND expires_at > ?", credential.ID, models.ApprovalActionReveal, wallets, time.Now()).
+		Order("created_at DESC").
+		First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		request = models.ApprovalRequest{
+			CredentialID:    credential.ID,
+			RequesterWallet: walletAddress,
+			Action:          models.ApprovalActionReveal,
+			Approvers:       credential.Approvers,
+			Threshold:       credential.ApprovalThreshold,
+			ExpiresAt:       time.Now().Add(h.approvalTTL).Truncate(time.Second),
+			Approvals:       []models.Approval{},
+		}
+		if err = h.db.Omit("Approvals").Create(&request).Error; err == nil {
+			h.audit.recordCredential(c, walletAddress, credential, "approval_request")
+			for _, approver := range request.Approvers {
+				notify(h.db, h.logger, approver, models.NotificationApprovalRequest, credential.ID,
+					walletAddress+" asks to reveal "+credential.CredentialName)
+			}
+			h.logger.Info("Approval requested", "id", request.ID, "credential", credential.ID, "wallet", walletAddress)
+		}
+	}
+	if err != nil {
+		h.logger.Error("Failed to open approval request", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request approval"})
+		return "", false
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"approvalRequired": true,
+		"request":          request,
+	})
+	return "", false
+}
+
+// approvedFor matches an approved request of the credential that has not
+// been released yet: request ID, credential ID, action, requester wallets, now
+const approvedFor = "id = ? AND credential_id = ? AND action = ? AND requester_wallet IN ? AND approved_at IS NOT NULL AND released_at IS NULL AND expires_at > ?"
+
+// releaseApproval claims the approved request checkApproval returned, right
+// before the shares go out, so a reveal that fails on the way does not use
+// it up. Only one reveal wins the claim; the others get 409.
+func (h *CredentialHandler) releaseApproval(c *gin.Context, credential *models.Credential, requestID string) bool {
+	if requestID == "" {
+		return true
+	}
+
+	claim := h.db.Model(&models.ApprovalRequest{}).
+		Where(approvedFor, requestID, credential.ID, models.ApprovalActionReveal, linkedWallets(h.db, middleware.WalletAddress(c)), time.Now()).
+		Update("released_at", time.Now())
+	if claim.Error != nil {
+		h.logger.Error("Failed to claim approval", "error", claim.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check approval"})
+		return false
+	}
+	if claim.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Approval was already used or has expired"})
+		return false
+	}
+	return true
+}
+
+// GetApprovals handles GET /api/v1/approvals
+// Lists the open requests the caller is a designated approver of.
+func (h *ApprovalHandler) GetApprovals(c *gin.Context) {
+	wallets := linkedWallets(h.db, middleware.WalletAddress(c))
+
+	var requests []models.ApprovalRequest
+	if err := h.db.Preload("Approvals").
+		Where(approverOf, wallets).
+		Where("released_at IS NULL AND expires_at > ?", time.Now()).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&requests).Error; err != nil {
+		h.logger.Error("Failed to fetch approval requests", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approval requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// findRequest loads an approval request the caller made or may approve
+func (h *ApprovalHandler) findRequest(c *gin.Context) (*models.ApprovalRequest, []string, bool) {
+	wallets := linkedWallets(h.db, middleware.WalletAddress(c))
+
+	var request models.ApprovalRequest
+	if err := h.db.Preload("Approvals").
+		Where("id = ?", c.Param("id")).
+		Where("(requester_wallet IN ? OR "+approverOf+")", wallets, wallets).
+		First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Approval request not found"})
+		return nil, nil, false
+	}
+	return &request, wallets, true
+}
+
+// approvalMessage is the text approvers sign to approve request
+func approvalMessage(request *models.ApprovalRequest) string {
+	if request.Action == models.ApprovalActionPolicy {
+		return auth.PolicyChangeMessage(request.ID, request.CredentialID, request.RequesterWallet,
+			request.ProposedApprovers, request.ProposedThreshold, request.ExpiresAt)
+	}
+	return auth.ApprovalMessage(request.ID, request.CredentialID, request.RequesterWallet, request.ExpiresAt)
+}
+
+// GetApproval handles GET /api/v1/approvals/:id
+// The message is what approvers sign to approve the request.
+func (h *ApprovalHandler) GetApproval(c *gin.Context) {
+	request, _, ok := h.findRequest(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"request": request,
+		"message": approvalMessage(request),
+	})
+}
+
+// Approve handles POST /api/v1/approvals/:id/approve
+//
+// The body carries the designated approver wallet's signature over
+// approvalMessage. Each approval is recorded on Fabric; the approval
+// that reaches the threshold approves the request and tells the requester.
+func (h *ApprovalHandler) Approve(c *gin.Context) {
+	var req models.ApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	request, wallets, ok := h.findRequest(c)
+	if !ok {
+		return
+	}
+	if request.ReleasedAt != nil || !request.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "Approval request is closed"})
+		return
+	}
+
+	var approver string
+	for _, wallet := range wallets {
+		if designated, ok := findWallet(request.Approvers, wallet); ok {
+			approver = designated
+			break
+		}
+	}
+	if approver == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not an approver of this request"})
+		return
+	}
+	if _, self := findWallet(linkedWallets(h.db, request.RequesterWallet), approver); self {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Requesters cannot approve their own request"})
+		return
+	}
+
+	message := approvalMessage(request)
+	if _, err := auth.VerifySignature([]byte(message), req.Signature, approver); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Signature does not match the approval request", "details": err.Error()})
+		return
+	}
+
+	var credential models.Credential
+	if err := h.db.Where("id = ?", request.CredentialID).First(&credential).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	approval := &models.Approval{RequestID: request.ID, ApproverWallet: approver, Signature: req.Signature}
+	result := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(approval)
+	if result.Error != nil {
+		h.logger.Error("Failed to save approval", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already approved this request"})
+		return
+	}
+
+	approval.TxHash = h.audit.recordCredentialActor(c, approver, &credential, "approve")
+	if approval.TxHash != "" {
+		h.db.Model(approval).Update("tx_hash", approval.TxHash)
+	}
+
+	var count int64
+	h.db.Model(&models.Approval{}).Where("request_id = ?", request.ID).Count(&count)
+
+	// Only the approval that crosses the threshold sets approved_at
+	if int(count) >= request.Threshold {
+		claim := h.db.Model(&models.ApprovalRequest{}).
+			Where("id = ? AND approved_at IS NULL", request.ID).
+			Update("approved_at", time.Now())
+		if claim.Error != nil {
+			h.logger.Error("Failed to approve request", "error", claim.Error)
+		} else if claim.RowsAffected == 1 {
+			what := "reveal "
+			if request.Action == models.ApprovalActionPolicy {
+				what = "change the approval policy of "
+			}
+			notify(h.db, h.logger, request.RequesterWallet, models.NotificationApprovalGranted, credential.ID,
+				"Your request to "+what+credential.CredentialName+" was approved")
+			h.logger.Info("Approval request approved", "id", request.ID, "credential", credential.ID)
+		}
+	}
+
+	h.logger.Info("Reveal approved", "request", request.ID, "approver", approver, "approvals", count, "threshold", request.Threshold)
+
+	c.JSON(http.StatusOK, gin.H{
+		"requestId": request.ID,
+		"approvals": count,
+		"threshold": request.Threshold,
+		"approved":  int(count) >= request.Threshold,
+		"txHash":    approval.TxHash,
+	})
+}
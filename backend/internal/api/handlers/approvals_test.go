@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/models"
+)
+
+// setPolicy changes the approval policy of credential id as wallet, with the
+// approval request approvalID if it is not empty, and returns the status and
+// the approval request of a 202
+func (e *testEnv) setPolicy(wallet testWallet, id string, approvers []testWallet, threshold int, approvalID string) (int, models.ApprovalRequest) {
+	e.t.Helper()
+	addresses := []string{}
+	for _, approver := range approvers {
+		addresses = append(addresses, approver.address)
+	}
+	header := http.Header{}
+	if approvalID != "" {
+		header.Set("X-Approval-Request", approvalID)
+	}
+	var body struct {
+		Request models.ApprovalRequest `json:"request"`
+	}
+	status := e.do(wallet, http.MethodPut, "/credentials/"+id+"/approval-policy", gin.H{"approvers": addresses, "threshold": threshold}, header, &body)
+	return status, body.Request
+}
+
+// approve signs approval request id as approver
+func (e *testEnv) approve(approver testWallet, id string) {
+	e.t.Helper()
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if status := e.do(approver, http.MethodGet, "/approvals/"+id, nil, nil, &detail); status != http.StatusOK {
+		e.t.Fatalf("approval request: status %d", status)
+	}
+	if status := e.do(approver, http.MethodPost, "/approvals/"+id+"/approve", gin.H{"signature": approver.sign(detail.Message)}, nil, nil); status != http.StatusOK {
+		e.t.Fatalf("approve: status %d", status)
+	}
+}
+
+func TestSetApprovalPolicyWeakening(t *testing.T) {
+	env := newTestEnv(t)
+	owner, first, second, outsider := newTestWallet(t), newTestWallet(t), newTestWallet(t), newTestWallet(t)
+	id := env.createCredential(owner, "djE=")
+	both := []testWallet{first, second}
+
+	if status, _ := env.setPolicy(owner, id, both, 2, ""); status != http.StatusOK {
+		t.Fatalf("set policy: status %d", status)
+	}
+
+	// Weakening changes wait for an approved request under the current policy
+	weakening := []struct {
+		name      string
+		approvers []testWallet
+		threshold int
+	}{
+		{"lower threshold", both, 1},
+		{"remove", nil, 0},
+		{"add an approver", []testWallet{first, second, outsider}, 2},
+	}
+	for _, tt := range weakening {
+		t.Run(tt.name, func(t *testing.T) {
+			status, request := env.setPolicy(owner, id, tt.approvers, tt.threshold, "")
+			if status != http.StatusAccepted || request.Action != models.ApprovalActionPolicy || request.Threshold != 2 {
+				t.Errorf("status %d, request %+v", status, request)
+			}
+		})
+	}
+	if credential := env.credential(id); credential.ApprovalThreshold != 2 {
+		t.Fatalf("threshold = %d before approval, want 2", credential.ApprovalThreshold)
+	}
+
+	status, request := env.setPolicy(owner, id, both, 1, "")
+	if status != http.StatusAccepted {
+		t.Fatalf("lower threshold: status %d", status)
+	}
+	env.approve(first, request.ID)
+	if status, pending := env.setPolicy(owner, id, both, 1, request.ID); status != http.StatusAccepted || pending.ID != request.ID {
+		t.Fatalf("with one of two approvals: status %d, request %s, want %s", status, pending.ID, request.ID)
+	}
+	env.approve(second, request.ID)
+
+	// The approval covers only the change it proposed
+	if status, other := env.setPolicy(owner, id, nil, 0, request.ID); status != http.StatusAccepted || other.ID == request.ID {
+		t.Fatalf("different change with the approval: status %d, request %s", status, other.ID)
+	}
+	if status, _ := env.setPolicy(owner, id, both, 1, request.ID); status != http.StatusOK {
+		t.Fatalf("approved change: status %d", status)
+	}
+	if credential := env.credential(id); credential.ApprovalThreshold != 1 {
+		t.Fatalf("threshold = %d, want 1", credential.ApprovalThreshold)
+	}
+	if status, _ := env.setPolicy(owner, id, nil, 0, request.ID); status != http.StatusAccepted {
+		t.Errorf("reused approval: status %d, want %d", status, http.StatusAccepted)
+	}
+
+	// Tightening needs no approval
+	if status, _ := env.setPolicy(owner, id, []testWallet{first}, 1, ""); status != http.StatusOK {
+		t.Errorf("drop an approver: status %d", status)
+	}
+	if status, _ := env.setPolicy(owner, id, []testWallet{first}, 1, ""); status != http.StatusOK {
+		t.Errorf("same policy: status %d", status)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
 	"pass-chain/backend/internal/models"
 	"pass-chain/backend/pkg/logger"
 )
@@ -24,20 +26,12 @@ func NewAuditHandler(db *database.Database, log *logger.Logger) *AuditHandler {
 
 // GetAuditLogs handles GET /api/v1/audit-logs
 func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
-	walletAddress := c.GetHeader("X-Wallet-Address")
-	if walletAddress == "" {
-		walletAddress = c.Query("wallet")
-	}
-
-	if walletAddress == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Wallet address required"})
-		return
-	}
+	walletAddress := middleware.WalletAddress(c)
 
 	var auditLogs []models.AuditLog
-	
-	// Query audit logs for this wallet, ordered by most recent first
-	if err := h.db.Where("wallet_address = ?", walletAddress).
+
+	// Query audit logs for every wallet on this account, most recent first
+	if err := h.db.Where("wallet_address IN ?", linkedWallets(h.db, walletAddress)).
 		Order("timestamp DESC").
 		Limit(100).
 		Find(&auditLogs).Error; err != nil {
@@ -51,10 +45,12 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 		ID             string    `json:"id"`
 		CredentialID   string    `json:"credentialId"`
 		CredentialName string    `json:"credentialName"`
+		WalletAddress  string    `json:"walletAddress"`
 		Action         string    `json:"action"`
 		Timestamp      time.Time `json:"timestamp"`
 		TxHash         string    `json:"txHash,omitempty"`
 		IPHash         string    `json:"ipHash,omitempty"`
+		APIKeyID       string    `json:"apiKeyId,omitempty"`
 	}
 
 	var response []AuditLogResponse
@@ -70,10 +66,12 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 			ID:             log.ID,
 			CredentialID:   log.CredentialID,
 			CredentialName: credName,
+			WalletAddress:  log.WalletAddress,
 			Action:         log.Action,
 			Timestamp:      log.Timestamp,
 			TxHash:         log.TxHash,
 			IPHash:         log.IPAddress,
+			APIKeyID:       log.APIKeyID,
 		})
 	}
 
@@ -87,28 +85,32 @@ func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
 
 // GetStats handles GET /api/v1/stats
 func (h *AuditHandler) GetStats(c *gin.Context) {
-	walletAddress := c.GetHeader("X-Wallet-Address")
-	if walletAddress == "" {
-		walletAddress = c.Query("wallet")
-	}
+	walletAddress := middleware.WalletAddress(c)
+	wallets := linkedWallets(h.db, walletAddress)
 
-	if walletAddress == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Wallet address required"})
+	// Optionally scoped by ?folder= and ?tag=
+	credentials, err := scopeCredentials(h.db, h.db.Model(&models.Credential{}).Where("wallet_address IN ? AND org_id IS NULL", wallets), c, wallets)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder not found"})
 		return
 	}
+	logs := h.db.Model(&models.AuditLog{}).Where("wallet_address IN ?", wallets)
+	if c.Query("folder") != "" || c.Query("tag") != "" {
+		logs = logs.Where("credential_id IN (?)", credentials.Session(&gorm.Session{}).Select("id"))
+	}
 
 	// Count total credentials
 	var totalCredentials int64
-	h.db.Model(&models.Credential{}).Where("wallet_address = ?", walletAddress).Count(&totalCredentials)
+	credentials.Session(&gorm.Session{}).Count(&totalCredentials)
 
 	// Count total accesses
 	var totalAccesses int64
-	h.db.Model(&models.AuditLog{}).Where("wallet_address = ? AND action = ?", walletAddress, "read").Count(&totalAccesses)
+	logs.Session(&gorm.Session{}).Where("action = ?", "read").Count(&totalAccesses)
 
 	// Get last activity timestamp
 	var lastLog models.AuditLog
 	lastActivity := ""
-	if err := h.db.Where("wallet_address = ?", walletAddress).
+	if err := logs.Session(&gorm.Session{}).
 		Order("timestamp DESC").
 		First(&lastLog).Error; err == nil {
 		lastActivity = lastLog.Timestamp.Format(time.RFC3339)
@@ -130,4 +132,3 @@ func (h *AuditHandler) GetStats(c *gin.Context) {
 		"blockchainShards": blockchainShards,
 	})
 }
-
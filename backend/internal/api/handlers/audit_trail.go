@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// auditTrail records credential actions in the audit_logs table and on Fabric
+type auditTrail struct {
+	db     *database.Database
+	fabric *services.FabricClient
+	logger *logger.Logger
+}
+
+func newAuditTrail(db *database.Database, fabric *services.FabricClient, log *logger.Logger) *auditTrail {
+	return &auditTrail{
+		db:     db,
+		fabric: fabric,
+		logger: log,
+	}
+}
+
+// record logs action on credentialID by walletAddress. Failures are logged
+// but never fail the request. Returns the Fabric transaction ID, if any.
+func (a *auditTrail) record(c *gin.Context, walletAddress, credentialID, action string) string {
+	return a.recordActor(c, walletAddress, "", credentialID, action)
+}
+
+// recordActor is record for an action by actorWallet on a credential owned
+// by walletAddress; the entry belongs to the owner's trail
+func (a *auditTrail) recordActor(c *gin.Context, walletAddress, actorWallet, credentialID, action string) string {
+	return a.write(c, &models.AuditLog{
+		CredentialID:  credentialID,
+		WalletAddress: walletAddress,
+		Action:        action,
+		ActorWallet:   actorWallet,
+	})
+}
+
+// recordCredential is record for an action by walletAddress on credential.
+// Entries on organization credentials carry the organization, so they show
+// up in its audit view as well as the acting member's own.
+func (a *auditTrail) recordCredential(c *gin.Context, walletAddress string, credential *models.Credential, action string) string {
+	return a.write(c, &models.AuditLog{
+		CredentialID:  credential.ID,
+		WalletAddress: walletAddress,
+		Action:        action,
+		OrgID:         credential.OrgID,
+	})
+}
+
+// recordCredentialActor is recordActor for an action by actorWallet on
+// credential, carrying its organization like recordCredential
+func (a *auditTrail) recordCredentialActor(c *gin.Context, actorWallet string, credential *models.Credential, action string) string {
+	return a.write(c, &models.AuditLog{
+		CredentialID:  credential.ID,
+		WalletAddress: credential.WalletAddress,
+		Action:        action,
+		ActorWallet:   actorWallet,
+		OrgID:         credential.OrgID,
+	})
+}
+
+// recordOnChain logs an account-level action (not on a credential) to Fabric
+// only, with subjectID in place of the credential ID. Returns the Fabric
+// transaction ID, if any.
+func (a *auditTrail) recordOnChain(c *gin.Context, walletAddress, subjectID, action string) string {
+	if a.fabric == nil {
+		return ""
+	}
+	txID, err := a.fabric.LogAccess(walletAddress, subjectID, action, hashIP(c.ClientIP()))
+	if err != nil {
+		a.logger.Error("Failed to log access to Fabric", "error", err)
+		return ""
+	}
+	a.logger.Info("Access logged to Fabric", "auditTxID", txID, "action", action)
+	return txID
+}
+
+func (a *auditTrail) write(c *gin.Context, entry *models.AuditLog) string {
+	entry.IPAddress = hashIP(c.ClientIP())
+	entry.Timestamp = time.Now()
+
+	if a.fabric != nil {
+		txID, err := a.fabric.LogAccess(entry.WalletAddress, entry.CredentialID, entry.Action, entry.IPAddress)
+		if err != nil {
+			a.logger.Error("Failed to log access to Fabric", "error", err)
+		} else {
+			entry.TxHash = txID
+			a.logger.Info("Access logged to Fabric", "auditTxID", txID, "action", entry.Action)
+		}
+	}
+
+	if key := middleware.APIKey(c); key != nil {
+		entry.APIKeyID = key.ID
+	}
+
+	if err := a.db.Create(entry).Error; err != nil {
+		a.logger.Error("Failed to write audit log", "error", err)
+	}
+
+	return entry.TxHash
+}
+
+// hashIP keeps audit entries linkable per client without storing raw IPs
+func hashIP(ip string) string {
+	sum := sha256.Sum256([]byte(ip))
+	return hex.EncodeToString(sum[:8])
+}
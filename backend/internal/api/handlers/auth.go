@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/config"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+type AuthHandler struct {
+	cfg      config.AuthConfig
+	nonces   services.NonceStore
+	sessions services.SessionStore
+	logger   *logger.Logger
+}
+
+func NewAuthHandler(cfg config.AuthConfig, nonces services.NonceStore, sessions services.SessionStore, log *logger.Logger) *AuthHandler {
+	return &AuthHandler{
+		cfg:      cfg,
+		nonces:   nonces,
+		sessions: sessions,
+		logger:   log,
+	}
+}
+
+// LoginRequest carries a signed EIP-4361 message
+type LoginRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// siweNonceKey namespaces login nonces in the nonce store
+func siweNonceKey(nonce string) string {
+	return "siwe:" + nonce
+}
+
+// GetNonce handles GET /api/v1/auth/nonce
+func (h *AuthHandler) GetNonce(c *gin.Context) {
+	nonce, err := auth.NewNonce()
+	if err != nil {
+		h.logger.Error("Failed to generate nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate nonce"})
+		return
+	}
+
+	ttl := time.Duration(h.cfg.NonceTTL) * time.Second
+	if err := h.nonces.Put(c.Request.Context(), siweNonceKey(nonce), "1", ttl); err != nil {
+		h.logger.Error("Failed to store nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate nonce"})
+		return
+	}
+
+	// Echo what the message must contain so the client can build it
+	c.JSON(http.StatusOK, gin.H{
+		"nonce":     nonce,
+		"domain":    h.cfg.SIWEDomain,
+		"uri":       h.cfg.SIWEURI,
+		"chainId":   h.cfg.ChainID,
+		"expiresAt": time.Now().Add(ttl),
+	})
+}
+
+// Login handles POST /api/v1/auth/login
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	msg, err := auth.ParseSIWEMessage(req.Message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SIWE message", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	if err := msg.Validate(auth.SIWEExpectations{
+		Domain:  h.cfg.SIWEDomain,
+		URI:     h.cfg.SIWEURI,
+		ChainID: h.cfg.ChainID,
+		Now:     now,
+	}); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The nonce is consumed before the signature is checked so a failed
+	// attempt cannot be retried with the same challenge
+	if _, ok, err := h.nonces.Take(c.Request.Context(), siweNonceKey(msg.Nonce)); err != nil {
+		h.logger.Error("Failed to read nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify nonce"})
+		return
+	} else if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unknown or expired nonce"})
+		return
+	}
+
+	signer, err := auth.VerifySignature([]byte(req.Message), req.Signature, msg.Address)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid wallet signature"})
+		return
+	}
+
+	token, tokenHash, err := auth.NewSessionToken()
+	if err != nil {
+		h.logger.Error("Failed to generate session token", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	expiresAt := now.Add(time.Duration(h.cfg.SessionTTL) * time.Second)
+	if msg.ExpirationTime != nil && msg.ExpirationTime.Before(expiresAt) {
+		expiresAt = *msg.ExpirationTime
+	}
+
+	userAgent := c.Request.UserAgent()
+	session := &services.Session{
+		ID:            tokenHash[:16],
+		WalletAddress: signer,
+		ChainID:       msg.ChainID,
+		DeviceID:      auth.DeviceID(userAgent),
+		UserAgent:     userAgent,
+		IPHash:        auth.CoarseIPHash(c.ClientIP()),
+		CreatedAt:     now,
+		LastSeenAt:    now,
+		ExpiresAt:     expiresAt,
+	}
+	if err := h.sessions.Create(c.Request.Context(), tokenHash, session); err != nil {
+		h.logger.Error("Failed to store session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	h.logger.Info("Wallet signed in", "wallet", signer, "session", session.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         token,
+		"walletAddress": signer,
+		"expiresAt":     expiresAt,
+	})
+}
+
+// Logout handles POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	tokenHash := middleware.SessionTokenHash(c)
+	if tokenHash == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request is not using a session"})
+		return
+	}
+
+	if err := h.sessions.Delete(c.Request.Context(), tokenHash); err != nil {
+		h.logger.Error("Failed to delete session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	h.logger.Info("Wallet signed out", "wallet", middleware.WalletAddress(c))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// sessionView is a session as shown to its owner
+type sessionView struct {
+	services.Session
+	Current bool `json:"current"`
+}
+
+// deviceView groups the sessions opened from one client
+type deviceView struct {
+	DeviceID   string    `json:"deviceId"`
+	UserAgent  string    `json:"userAgent"`
+	IPHashes   []string  `json:"ipHashes"`
+	Sessions   int       `json:"sessions"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
+
+// GetSessions handles GET /api/v1/auth/sessions
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	sessions, err := h.sessions.List(c.Request.Context(), walletAddress)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+
+	current := middleware.SessionID(c)
+	response := make([]sessionView, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, sessionView{Session: session, Current: session.ID == current})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": response,
+		"count":    len(response),
+	})
+}
+
+// GetDevices handles GET /api/v1/auth/devices
+func (h *AuthHandler) GetDevices(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	sessions, err := h.sessions.List(c.Request.Context(), walletAddress)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list devices"})
+		return
+	}
+
+	current := middleware.SessionID(c)
+	devices := []*deviceView{}
+	byID := make(map[string]*deviceView)
+
+	// Sessions are sorted by last seen, so each device keeps its latest activity
+	for _, session := range sessions {
+		device, ok := byID[session.DeviceID]
+		if !ok {
+			device = &deviceView{
+				DeviceID:   session.DeviceID,
+				UserAgent:  session.UserAgent,
+				IPHashes:   []string{},
+				LastSeenAt: session.LastSeenAt,
+			}
+			byID[session.DeviceID] = device
+			devices = append(devices, device)
+		}
+
+		device.Sessions++
+		device.Current = device.Current || session.ID == current
+		if !containsString(device.IPHashes, session.IPHash) {
+			device.IPHashes = append(device.IPHashes, session.IPHash)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"devices": devices,
+		"count":   len(devices),
+	})
+}
+
+// RevokeSession handles DELETE /api/v1/auth/sessions/:id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	err := h.sessions.Revoke(c.Request.Context(), walletAddress, id)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to revoke session", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	h.logger.Info("Session revoked", "wallet", walletAddress, "session", id)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeAllSessions handles DELETE /api/v1/auth/sessions
+// Pass ?keepCurrent=true to stay signed in on the calling session.
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	keep := ""
+	if c.Query("keepCurrent") == "true" {
+		keep = middleware.SessionID(c)
+	}
+
+	revoked, err := h.sessions.RevokeAll(c.Request.Context(), walletAddress, keep)
+	if err != nil {
+		h.logger.Error("Failed to revoke sessions", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	h.logger.Info("Sessions revoked", "wallet", walletAddress, "count", revoked)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sessions revoked successfully",
+		"revoked": revoked,
+	})
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// BackupHandler exports and restores signed archives of a wallet's
+// credentials (see services.BackupWriter for the format). An archive restores
+// on any deployment that trusts the exporting deployment's key, and only for
+// the wallet it was encrypted to.
+type BackupHandler struct {
+	credentials *CredentialHandler
+	signer      *services.BackupSigner
+	maxSize     int64
+	logger      *logger.Logger
+}
+
+func NewBackupHandler(credentials *CredentialHandler, signer *services.BackupSigner, maxSize int64, log *logger.Logger) *BackupHandler {
+	return &BackupHandler{
+		credentials: credentials,
+		signer:      signer,
+		maxSize:     maxSize,
+		logger:      log,
+	}
+}
+
+// GetPublicKey handles GET /api/v1/backups/public-key
+//
+// Other deployments add it to BACKUP_TRUSTED_KEYS to restore archives from here.
+func (h *BackupHandler) GetPublicKey(c *gin.Context) {
+	if !h.signer.CanSign() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Backup export is not configured"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm": "ed25519",
+		"keyId":     h.signer.KeyID(),
+		"publicKey": h.signer.PublicKey(),
+	})
+}
+
+// ExportBackup handles POST /api/v1/backups/export
+//
+// The archive carries both server-held shares of every credential, so it is
+// a signed request from the wallet itself; API keys cannot export.
+// Passkey-protected credentials and those that require approval are left out
+// and counted in the trailer. The archive is encrypted to the wallet's
+// published encryption key, so one must exist.
+// If reading a share fails mid-stream the archive ends without a trailer and
+// will not verify.
+func (h *BackupHandler) ExportBackup(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot export backups"})
+		return
+	}
+
+	if !h.signer.CanSign() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Backup export is not configured"})
+		return
+	}
+
+	ch := h.credentials
+	var key models.WalletKey
+	if err := ch.db.Where("wallet_address = ?", walletAddress).First(&key).Error; err != nil || key.Algorithm != services.WrapAlgorithm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Publish an encryption key first; backups are encrypted to it"})
+		return
+	}
+
+	filename := "passchain-backup-" + time.Now().UTC().Format("20060102-150405") + ".ndjson"
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	archive, err := h.signer.NewBackupWriter(c.Writer, walletAddress, key.PublicKey)
+	if err != nil {
+		h.logger.Error("Failed to start backup", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to encrypt the backup to the wallet key", "details": err.Error()})
+		return
+	}
+
+	var batch []models.Credential
+	err = ch.db.Scopes(notExpired).
+		Where("wallet_address IN ? AND org_id IS NULL", linkedWallets(ch.db, walletAddress)).
+		Order("created_at").
+		FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+			for _, credential := range batch {
+				if credential.RequirePasskey || credential.RequiresApproval() {
+					archive.Skip()
+					continue
+				}
+
+				share1, err := ch.readShare1(&credential)
+				if err != nil {
+					return fmt.Errorf("share1 of %s: %w", credential.ID, err)
+				}
+
+				var deltas []string
+				for _, delta := range pendingDeltas(ch.db, &credential) {
+					deltas = append(deltas, delta.WrappedDelta)
+				}
+
+				if err := archive.Write(services.BackupEntry{
+					ID:            credential.ID,
+					Name:          credential.CredentialName,
+					Kind:          credential.Kind,
+					Username:      credential.Username,
+					URL:           credential.URL,
+					EncryptedData: credential.EncryptedData,
+					Nonce:         credential.Nonce,
+					Metadata:      credential.Metadata,
+					Share1:        share1,
+					Share2:        credential.Share2,
+					Share3Hash:    credential.Share3Hash,
+					SplitScheme:   credential.SplitScheme,
+					RefreshDeltas: deltas,
+					ExpiresAt:     credential.ExpiresAt,
+				}); err != nil {
+					return err
+				}
+				ch.audit.record(c, walletAddress, credential.ID, "export")
+			}
+			c.Writer.Flush()
+			return nil
+		}).Error
+	if err != nil {
+		h.logger.Error("Backup export aborted", "wallet", walletAddress, "error", err)
+		return
+	}
+
+	if err := archive.Close(); err != nil {
+		h.logger.Error("Failed to finish backup", "error", err)
+		return
+	}
+
+	h.logger.Info("Backup exported", "wallet", walletAddress)
+}
+
+// RestoreBackup handles POST /api/v1/backups/restore
+//
+// The body is an archive from ExportBackup and X-Backup-Key the archive key,
+// base64, as unwrapped from the header by the wallet (eth_decrypt). The
+// archive is verified as a whole, then its credentials go through the same
+// all-or-nothing path as an import.
+// Expired entries are skipped, and so are entries whose name already exists
+// when ?skipExisting=true (otherwise they fail the restore).
+// The response maps each restored credential's archived ID to its new one
+// under "ids", so the client can re-key the share3 it keeps.
+func (h *BackupHandler) RestoreBackup(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot restore backups"})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Backup is too large", "max": h.maxSize})
+		return
+	}
+
+	archiveKey, err := base64.StdEncoding.DecodeString(c.GetHeader("X-Backup-Key"))
+	if err != nil || len(archiveKey) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X-Backup-Key must carry the unwrapped archive key"})
+		return
+	}
+
+	header, entries, err := h.signer.ReadBackup(data, archiveKey)
+	if errors.Is(err, services.ErrUntrustedBackup) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Backup was signed by a deployment this one does not trust", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Backup failed verification", "details": err.Error()})
+		return
+	}
+
+	ch := h.credentials
+	if _, ok := findWallet(linkedWallets(ch.db, walletAddress), header.Wallet); !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Backup belongs to a wallet not linked to this account"})
+		return
+	}
+
+	skipExisting := c.Query("skipExisting") == "true"
+	skipped := []string{}
+	items := make([]models.CreateCredentialRequest, 0, len(entries))
+	archivedIDs := make([]string, 0, len(entries)) // Parallel to items
+	for _, entry := range entries {
+		if entry.ExpiresAt != nil && !entry.ExpiresAt.After(time.Now()) {
+			skipped = append(skipped, entry.Name)
+			continue
+		}
+		if skipExisting && nameInUse(ch.db, walletAddress, entry.Name) {
+			skipped = append(skipped, entry.Name)
+			continue
+		}
+		items = append(items, models.CreateCredentialRequest{
+			Kind:          entry.Kind,
+			Name:          entry.Name,
+			Username:      entry.Username,
+			URL:           entry.URL,
+			EncryptedData: entry.EncryptedData,
+			Nonce:         entry.Nonce,
+			Metadata:      entry.Metadata,
+			Share1:        entry.Share1,
+			Share2:        entry.Share2,
+			Share3Hash:    entry.Share3Hash,
+			SplitScheme:   entry.SplitScheme,
+			WalletAddress: walletAddress,
+			ExpiresAt:     entry.ExpiresAt,
+			RefreshDeltas: entry.RefreshDeltas,
+		})
+		archivedIDs = append(archivedIDs, entry.ID)
+	}
+
+	ids := map[string]string{}
+	if len(items) == 0 {
+		c.JSON(http.StatusOK, gin.H{"imported": 0, "results": []importResult{}, "skipped": skipped, "ids": ids})
+		return
+	}
+
+	status, body := ch.createAll(c, walletAddress, items, "restore")
+	if results, ok := body["results"].([]importResult); ok {
+		for _, result := range results {
+			if result.ID != "" && archivedIDs[result.Index] != "" {
+				ids[archivedIDs[result.Index]] = result.ID
+			}
+		}
+	}
+	body["skipped"] = skipped
+	body["ids"] = ids
+	c.JSON(status, body)
+}
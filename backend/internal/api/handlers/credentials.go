@@ -1,28 +1,45 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/auth"
 	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
 	"pass-chain/backend/internal/models"
 	"pass-chain/backend/internal/services"
 	"pass-chain/backend/pkg/logger"
 )
 
 type CredentialHandler struct {
-	db      *database.Database
-	vault   *services.VaultService
-	fabric  *services.FabricClient
-	logger  *logger.Logger
+	db             *database.Database
+	vault          *services.VaultService
+	fabric         *services.FabricClient
+	nonces         services.NonceStore
+	revealTTL      time.Duration
+	trashRetention time.Duration
+	approvalTTL    time.Duration
+	audit          *auditTrail
+	logger         *logger.Logger
 }
 
-func NewCredentialHandler(db *database.Database, vault *services.VaultService, fabric *services.FabricClient, log *logger.Logger) *CredentialHandler {
+func NewCredentialHandler(db *database.Database, vault *services.VaultService, fabric *services.FabricClient, nonces services.NonceStore, revealTTL, trashRetention, approvalTTL time.Duration, log *logger.Logger) *CredentialHandler {
 	return &CredentialHandler{
-		db:     db,
-		vault:  vault,
-		fabric: fabric,
-		logger: log,
+		db:             db,
+		vault:          vault,
+		fabric:         fabric,
+		nonces:         nonces,
+		revealTTL:      revealTTL,
+		trashRetention: trashRetention,
+		approvalTTL:    approvalTTL,
+		audit:          newAuditTrail(db, fabric, log),
+		logger:         log,
 	}
 }
 
@@ -34,20 +51,35 @@ func (h *CredentialHandler) CreateCredential(c *gin.Context) {
 		return
 	}
 
-	// Verify wallet signature (from request body)
-	if req.WalletAddress == "" || req.Signature == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing wallet authentication"})
+	// The body must claim the same wallet WalletAuth verified
+	walletAddress := middleware.WalletAddress(c)
+	if !auth.SameAddress(req.WalletAddress, walletAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Wallet address does not match signature"})
 		return
 	}
+	req.WalletAddress = walletAddress
 
-	// TODO: Verify signature matches wallet address using EIP-191 or SIWE
-	// For now, just log it
-	h.logger.Info("Creating credential", "wallet", req.WalletAddress, "signature", req.Signature[:20]+"...")
+	// Keys scoped to specific credentials or tags cannot create new ones
+	if key := middleware.APIKey(c); key != nil && !key.Unrestricted() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is scoped to existing credentials"})
+		return
+	}
+	if middleware.APIKey(c) != nil && req.OrgID != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot write to organization vaults"})
+		return
+	}
+
+	if status, errMsg := h.checkNewCredential(&req); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	h.logger.Info("Creating credential", "wallet", req.WalletAddress)
 
 	// Store Share1 in Vault
-	// Vault KV v2 path format: secret/data/<path>
-	vaultPath := "secret/data/passchain/" + req.WalletAddress + "/" + req.Name
-	err := h.vault.WriteSecret(vaultPath, map[string]interface{}{
+	id := uuid.NewString()
+	vaultPath := newVaultPath(&req, id)
+	vaultVersion, err := h.vault.WriteSecretVersion(vaultPath, map[string]interface{}{
 		"share1":     req.Share1,
 		"created_at": "now",
 	})
@@ -60,7 +92,7 @@ func (h *CredentialHandler) CreateCredential(c *gin.Context) {
 	// Store Share2 in Fabric blockchain (if available)
 	var txID string
 	if h.fabric != nil {
-		txID, err = h.fabric.StoreShard(req.WalletAddress, req.Name, req.Share2)
+		txID, err = h.fabric.StoreShard(req.WalletAddress, id, req.Share2)
 		if err != nil {
 			h.logger.Error("Failed to store in Fabric", "error", err)
 			// Don't fail - fallback to DB storage
@@ -75,33 +107,40 @@ func (h *CredentialHandler) CreateCredential(c *gin.Context) {
 
 	// Store credential in database
 	credential := &models.Credential{
+		ID:             id,
 		CredentialName: req.Name,
+		Kind:           req.Kind,
 		Username:       req.Username,
+		ExpiresAt:      req.ExpiresAt,
 		URL:            req.URL,
 		EncryptedData:  req.EncryptedData,
 		Nonce:          req.Nonce,
+		Metadata:       req.Metadata,
 		WalletAddress:  req.WalletAddress,
 		VaultPath:      vaultPath,
 		BlockchainTxID: txID,
 		Share2:         req.Share2, // Fallback storage in DB
+		Share3Hash:     req.Share3Hash,
+		SplitScheme:    req.SplitScheme,
+		RequirePasskey: req.RequirePasskey,
+		OrgID:          req.OrgID,
+		Version:        1,
 	}
 
-	if err := h.db.Create(credential).Error; err != nil {
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(credential).Error; err != nil {
+			return err
+		}
+		return tx.Create(snapshotVersion(credential, vaultVersion, walletAddress)).Error
+	})
+	if err != nil {
 		h.logger.Error("Failed to save credential", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save credential"})
 		return
 	}
 
-	// Log to Fabric blockchain
-	if h.fabric != nil {
-		auditTxID, err := h.fabric.LogAccess(req.WalletAddress, credential.ID, "create", c.ClientIP())
-		if err != nil {
-			h.logger.Error("Failed to log to Fabric", "error", err)
-			// Don't fail - audit logging is optional
-		} else {
-			h.logger.Info("Audit logged to Fabric", "auditTxID", auditTxID)
-		}
-	}
+	// Record in the audit trail (DB + Fabric)
+	h.audit.recordCredential(c, req.WalletAddress, credential, "create")
 
 	h.logger.Info("Credential created", "id", credential.ID, "name", credential.CredentialName, "txID", txID)
 
@@ -114,120 +153,513 @@ func (h *CredentialHandler) CreateCredential(c *gin.Context) {
 }
 
 // GetCredentials handles GET /api/v1/credentials
+//
+// Query parameters:
+//
+//	limit   page size (default 50, max 200)
+//	cursor  nextCursor of the previous page
+//	sort    name, createdAt (default) or lastAccessed
+//	order   asc or desc (default desc, asc for name)
+//	kind    login, api_token, ssh_key, totp or note
+//	q       case-insensitive name prefix
+//	domain  URL host; also matches its subdomains
+//	folder  folder ID (subfolders included) or "none" for unfiled
+//	tag     tag name
+//	shared  true for only credentials shared with the caller, false for none
+//	org     organization ID: list its vault instead (any member role)
 func (h *CredentialHandler) GetCredentials(c *gin.Context) {
-	walletAddress := c.GetHeader("X-Wallet-Address")
-	if walletAddress == "" {
-		walletAddress = c.Query("wallet")
+	walletAddress := middleware.WalletAddress(c)
+
+	page, err := parsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Credentials of every wallet linked to the caller's account, and those
+	// other wallets shared with it (?shared=true for only those, false for none)
+	wallets := linkedWallets(h.db, walletAddress)
+	query := h.db.Preload("Tags").Scopes(notExpired)
+
+	key := middleware.APIKey(c)
+	switch shared, org := c.Query("shared"), c.Query("org"); {
+	case org != "":
+		if key != nil || orgRole(h.db, org, wallets) == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+			return
+		}
+		query = query.Where("org_id = ?", org)
+	case key != nil || shared == "false":
+		query = query.Where("wallet_address IN ? AND org_id IS NULL", wallets)
+	case shared == "true":
+		query = query.Where("wallet_address NOT IN ? AND id IN (?)", wallets, sharedWith(h.db, wallets))
+	case shared == "":
+		query = query.Where("((wallet_address IN ? AND org_id IS NULL) OR id IN (?))", wallets, sharedWith(h.db, wallets))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "shared must be true or false"})
+		return
+	}
+
+	// Service accounts only see the credentials their key is scoped to
+	if key != nil && !key.Unrestricted() {
+		query = query.Where("(id IN ? OR id IN (?))", nonEmpty(key.CredentialIDs), taggedCredentials(h.db, wallets, key.Tags))
 	}
 
-	if walletAddress == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Wallet address required"})
+	query, err = scopeCredentials(h.db, query, c, wallets)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Folder not found"})
 		return
 	}
 
+	if kind := c.Query("kind"); kind != "" {
+		if !models.ValidKind(kind) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown kind"})
+			return
+		}
+		query = query.Where("kind = ?", kind)
+	}
+	if prefix := c.Query("q"); prefix != "" {
+		query = query.Where("credential_name ILIKE ?", escapeLike(prefix)+"%")
+	}
+	if domain := strings.ToLower(strings.TrimSpace(c.Query("domain"))); domain != "" {
+		query = query.Where("("+urlHostExpr+" = ? OR "+urlHostExpr+" LIKE ?)", domain, "%."+escapeLike(domain))
+	}
+
 	var credentials []models.Credential
-	if err := h.db.Where("wallet_address = ?", walletAddress).Find(&credentials).Error; err != nil {
+	if err := page.apply(query).Find(&credentials).Error; err != nil {
 		h.logger.Error("Failed to fetch credentials", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credentials"})
 		return
 	}
 
+	hasMore := len(credentials) > page.Limit
+	var nextCursor string
+	if hasMore {
+		credentials = credentials[:page.Limit]
+		last := credentials[len(credentials)-1]
+		nextCursor = encodeCursor(pageCursor{Value: credentialSortValue(&last, page.Sort), ID: last.ID})
+	}
+
+	for i := range credentials {
+		if _, own := findWallet(wallets, credentials[i].WalletAddress); !own && credentials[i].OrgID == nil {
+			credentials[i].SharedWithMe = true
+		}
+	}
+
 	h.logger.Info("Fetched credentials", "wallet", walletAddress, "count", len(credentials))
 
-	c.JSON(http.StatusOK, credentials)
+	c.JSON(http.StatusOK, gin.H{
+		"items":      credentials,
+		"nextCursor": nextCursor,
+		"hasMore":    hasMore,
+	})
+}
+
+// credentialSortValue is the cursor value of credential for the given sort
+func credentialSortValue(credential *models.Credential, sort string) string {
+	switch sort {
+	case "name":
+		return credential.CredentialName
+	case "lastAccessed":
+		if credential.LastAccessed == nil {
+			return time.Unix(0, 0).UTC().Format(time.RFC3339Nano)
+		}
+		return credential.LastAccessed.UTC().Format(time.RFC3339Nano)
+	default:
+		return credential.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
 }
 
 // GetCredentialByID handles GET /api/v1/credentials/:id
 func (h *CredentialHandler) GetCredentialByID(c *gin.Context) {
 	id := c.Param("id")
-	walletAddress := c.GetHeader("X-Wallet-Address")
-
-	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet authentication required"})
+	walletAddress := middleware.WalletAddress(c)
+
+	credential, status, errMsg := h.findAuthorized(c, id, models.OrgRoleReadOnly)
+	if errMsg != "" {
+		if status == http.StatusNotFound && middleware.APIKey(c) == nil {
+			if shared, share, err := h.findShared(walletAddress, id); err == nil {
+				h.revealShared(c, shared, share)
+				return
+			}
+		}
+		c.JSON(status, gin.H{"error": errMsg})
 		return
 	}
 
-	var credential models.Credential
-	if err := h.db.Where("id = ? AND wallet_address = ?", id, walletAddress).First(&credential).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+	if !h.keyAllows(c, credential) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not cover this credential"})
 		return
 	}
 
-	// Retrieve Share1 from Vault
-	vaultData, err := h.vault.ReadSecret(credential.VaultPath)
-	if err != nil {
-		h.logger.Error("Failed to read from Vault", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve encryption key"})
+	// Shares are effectively the plaintext, so require step-up authorization
+	if status, errMsg := h.verifyReveal(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
 		return
 	}
 
-	share1, ok := vaultData["share1"].(string)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid vault data"})
+	if status, errMsg := h.verifyPasskey(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
 		return
 	}
 
-	// Update last accessed
-	h.db.Model(&credential).Update("last_accessed", "now()")
-
-	// Log access to Fabric
-	if h.fabric != nil {
-		auditTxID, err := h.fabric.LogAccess(walletAddress, id, "read", c.ClientIP())
-		if err != nil {
-			h.logger.Error("Failed to log access to Fabric", "error", err)
-		} else {
-			h.logger.Info("Access logged to Fabric", "auditTxID", auditTxID)
+	// Other organization members decrypt with a key wrapped to them
+	var memberKey *models.OrgMemberKey
+	if credential.OrgID != nil {
+		if memberKey, status, errMsg = h.orgMemberKey(c, credential); errMsg != "" {
+			c.JSON(status, gin.H{"error": errMsg})
+			return
 		}
 	}
 
-	h.logger.Info("Credential accessed", "id", id, "wallet", walletAddress)
+	// Flagged credentials answer with a pending approval request instead
+	approval, ok := h.checkApproval(c, credential)
+	if !ok {
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"id":            credential.ID,
 		"name":          credential.CredentialName,
+		"kind":          credential.Kind,
 		"username":      credential.Username,
 		"url":           credential.URL,
 		"encryptedData": credential.EncryptedData,
 		"nonce":         credential.Nonce,
+		"metadata":      credential.Metadata,
 		"walletAddress": credential.WalletAddress,
+		"orgId":         credential.OrgID,
 		"createdAt":     credential.CreatedAt,
 		"lastAccessed":  credential.LastAccessed,
-		"share1":        share1,
-		"share2":        credential.Share2, // From blockchain (future)
+	}
+
+	if memberKey != nil {
+		response["wrappedKey"] = memberKey.WrappedKey
+		response["wrappedFor"] = memberKey.MemberWallet
+	} else {
+		// Retrieve Share1 from Vault
+		share1, err := h.readShare1(credential)
+		if err != nil {
+			h.logger.Error("Failed to read from Vault", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve encryption key"})
+			return
+		}
+		response["share1"] = share1
+		response["share2"] = credential.Share2 // From blockchain (future)
+		response["refreshDeltas"] = pendingDeltas(h.db, credential)
+	}
+
+	if !h.releaseApproval(c, credential, approval) {
+		return
+	}
+
+	// Update last accessed
+	h.db.Model(credential).Update("last_accessed", "now()")
+
+	// Record in the audit trail (DB + Fabric)
+	h.audit.recordCredential(c, walletAddress, credential, "read")
+
+	h.logger.Info("Credential accessed", "id", id, "wallet", walletAddress, "wrappedKey", memberKey != nil)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateCredential handles PUT and PATCH /api/v1/credentials/:id
+//
+// A new secret is written as a new Vault KV v2 version of the same share1
+// path and a new Fabric key for share2; the outgoing version stays in the
+// credential_versions history.
+func (h *CredentialHandler) UpdateCredential(c *gin.Context) {
+	var req models.UpdateCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if c.Request.Method == http.MethodPut && !req.CompleteSecret() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "PUT requires encryptedData, nonce, share1 and share2"})
+		return
+	}
+	if req.HasSecret() && !req.CompleteSecret() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "encryptedData, nonce, share1 and share2 must be updated together"})
+		return
+	}
+	if req.Metadata != nil && !req.CompleteSecret() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metadata can only be replaced together with the secret"})
+		return
+	}
+	if req.Share3Hash != "" && !req.CompleteSecret() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "share3Hash can only be replaced together with the secret"})
+		return
+	}
+	if req.Share3Hash != "" && !validShareHash(req.Share3Hash) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "share3Hash must be a hex SHA-256 digest"})
+		return
+	}
+	if req.SplitScheme != "" && !req.CompleteSecret() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "splitScheme can only be replaced together with the secret"})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	credential, status, errMsg := h.findAuthorized(c, id, models.OrgRoleMember)
+	if errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if !h.keyAllows(c, credential) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not cover this credential"})
+		return
+	}
+
+	if status, errMsg := h.verifyPasskey(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if req.Version != 0 && req.Version != credential.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": "Credential was changed since it was read", "version": credential.Version})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["credential_name"] = *req.Name
+	}
+	if req.Username != nil {
+		updates["username"] = *req.Username
+	}
+	if req.URL != nil {
+		updates["url"] = *req.URL
+	}
+
+	// Moving the expiry re-arms the owner's warning
+	switch {
+	case req.NeverExpires:
+		updates["expires_at"] = nil
+		updates["expiry_notified_at"] = nil
+	case req.ExpiresAt != nil:
+		if !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+			return
+		}
+		updates["expires_at"] = *req.ExpiresAt
+		updates["expiry_notified_at"] = nil
+	}
+
+	// A new secret carries its own metadata (encrypted under the new key)
+	username, metadata := credential.Username, credential.Metadata
+	if req.Username != nil {
+		username = *req.Username
+	}
+	if req.CompleteSecret() {
+		metadata = req.Metadata
+	}
+	if err := models.ValidateKind(credential.Kind, username, metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The new split keeps the current scheme unless the client says otherwise,
+	// and a recovery split must come with its commitment rather than drop it
+	scheme := req.SplitScheme
+	if scheme == "" {
+		scheme = credential.SplitScheme
+	}
+	if req.CompleteSecret() {
+		if errMsg := checkSplit(scheme, req.Share3Hash); errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+	}
+
+	var next *models.CredentialVersion
+	var err error
+	if req.CompleteSecret() {
+		next, err = h.writeVersion(credential, walletAddress, req.EncryptedData, req.Nonce, req.Metadata, req.Share1, req.Share2)
+		if err != nil {
+			h.logger.Error("Failed to store in Vault", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store encryption key"})
+			return
+		}
+		next.Share3Hash = req.Share3Hash
+		next.SplitScheme = scheme
+	}
+
+	if next == nil && len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	err = h.saveVersion(credential, next, updates)
+	if err != nil && next != nil {
+		h.discardVersion(credential, next)
+	}
+	if errors.Is(err, errVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Credential was changed since it was read"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to update credential", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update credential"})
+		return
+	}
+
+	h.audit.recordCredential(c, walletAddress, credential, "update")
+
+	version := credential.Version
+	txID := credential.BlockchainTxID
+	if next != nil {
+		version = next.Version
+		txID = next.BlockchainTxID
+	}
+
+	h.logger.Info("Credential updated", "id", id, "wallet", walletAddress, "version", version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"version": version,
+		"txId":    txID,
+		"message": "Credential updated successfully",
 	})
 }
 
 // DeleteCredential handles DELETE /api/v1/credentials/:id
 func (h *CredentialHandler) DeleteCredential(c *gin.Context) {
 	id := c.Param("id")
-	walletAddress := c.GetHeader("X-Wallet-Address")
+	walletAddress := middleware.WalletAddress(c)
 
-	if walletAddress == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wallet authentication required"})
+	credential, status, errMsg := h.findAuthorized(c, id, models.OrgRoleAdmin)
+	if errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
 		return
 	}
 
-	var credential models.Credential
-	if err := h.db.Where("id = ? AND wallet_address = ?", id, walletAddress).First(&credential).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+	if !h.keyAllows(c, credential) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not cover this credential"})
 		return
 	}
 
-	// Delete from Vault
-	if err := h.vault.DeleteSecret(credential.VaultPath); err != nil {
-		h.logger.Error("Failed to delete from Vault", "error", err)
-		// Continue anyway to delete from DB
+	if status, errMsg := h.verifyPasskey(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
 	}
 
-	// Delete from database
-	if err := h.db.Delete(&credential).Error; err != nil {
+	// Move to the trash; the shares stay in Vault and Fabric until the purge
+	now := time.Now()
+	purgeAt := now.Add(h.trashRetention)
+	if err := h.db.Model(credential).Updates(map[string]interface{}{
+		"deleted_at": now,
+		"purge_at":   purgeAt,
+	}).Error; err != nil {
 		h.logger.Error("Failed to delete credential", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete credential"})
 		return
 	}
 
-	h.logger.Info("Credential deleted", "id", id, "wallet", walletAddress)
+	h.audit.recordCredential(c, walletAddress, credential, "delete")
+
+	h.logger.Info("Credential moved to trash", "id", id, "wallet", walletAddress, "purgeAt", purgeAt)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Credential moved to trash",
+		"purgeAt": purgeAt,
+	})
+}
+
+// checkNewCredential validates a create request beyond its binding tags and
+// defaults its kind. Returns an HTTP status and error message on failure.
+func (h *CredentialHandler) checkNewCredential(req *models.CreateCredentialRequest) (int, string) {
+	if req.OrgID != nil {
+		role := orgRole(h.db, *req.OrgID, linkedWallets(h.db, req.WalletAddress))
+		if role == "" {
+			return http.StatusNotFound, "Organization not found"
+		}
+		if !models.OrgRoleAtLeast(role, models.OrgRoleMember) {
+			return http.StatusForbidden, "Your role in the organization does not allow this"
+		}
+		// Other members could never satisfy the creator's passkey
+		if req.RequirePasskey {
+			return http.StatusBadRequest, "Organization credentials cannot require a passkey"
+		}
+	}
+
+	if req.RequirePasskey && !hasPasskey(h.db, req.WalletAddress) {
+		return http.StatusBadRequest, "Register a passkey first"
+	}
+
+	if req.Kind == "" {
+		req.Kind = models.KindLogin
+	}
+	if err := models.ValidateKind(req.Kind, req.Username, req.Metadata); err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return http.StatusBadRequest, "expiresAt must be in the future"
+	}
+
+	if req.Share3Hash != "" && !validShareHash(req.Share3Hash) {
+		return http.StatusBadRequest, "share3Hash must be a hex SHA-256 digest"
+	}
+	if errMsg := checkSplit(req.SplitScheme, req.Share3Hash); errMsg != "" {
+		return http.StatusBadRequest, errMsg
+	}
+
+	return 0, ""
+}
+
+// checkSplit validates a split scheme against the share3 commitment sent with
+// it: a recovery split needs one, and an XOR split cannot use one. An empty
+// scheme (older clients) is accepted and left unknown.
+func checkSplit(scheme, share3Hash string) string {
+	switch {
+	case scheme == "":
+		return ""
+	case !models.ValidSplitScheme(scheme):
+		return "splitScheme must be " + models.SplitXOR + " or " + models.SplitRecovery
+	case scheme == models.SplitRecovery && share3Hash == "":
+		return "share3Hash is required for a recovery split"
+	case scheme == models.SplitXOR && share3Hash != "":
+		return "share3Hash only applies to a recovery split"
+	}
+	return ""
+}
+
+// keyAllows checks a service-account key's scope; wallet callers always pass
+func (h *CredentialHandler) keyAllows(c *gin.Context, credential *models.Credential) bool {
+	key := middleware.APIKey(c)
+	return key == nil || key.Allows(credential.ID, credential.TagNames())
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Credential deleted successfully"})
+// nonEmpty keeps "IN ?" valid SQL for an empty list; the sentinel matches no UUID
+func nonEmpty(ids []string) []string {
+	if len(ids) == 0 {
+		return []string{"00000000-0000-0000-0000-000000000000"}
+	}
+	return ids
 }
 
+// findCredential loads a credential owned by any wallet linked to walletAddress.
+// Organization credentials are not owned by their creator; see findAuthorized.
+func (h *CredentialHandler) findCredential(walletAddress, id string) (*models.Credential, error) {
+	var credential models.Credential
+	err := h.db.Preload("Tags").Scopes(notExpired).Where("id = ? AND wallet_address IN ? AND org_id IS NULL", id, linkedWallets(h.db, walletAddress)).First(&credential).Error
+	return &credential, err
+}
+
+// notExpired hides credentials past their expiry that the reaper has not
+// removed yet
+func notExpired(db *gorm.DB) *gorm.DB {
+	return db.Where("expires_at IS NULL OR expires_at > now()")
+}
+
+// credentialVaultPath is where share1 of a credential lives (Vault KV v2
+// path format: secret/data/<path>). key is the credential ID; credentials
+// created before IDs were used keep their name-based paths.
+func credentialVaultPath(walletAddress, key string) string {
+	return "secret/data/passchain/" + walletAddress + "/" + key
+}
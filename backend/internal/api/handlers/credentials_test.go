@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/models"
+)
+
+func TestUpdateCredentialVersionConflict(t *testing.T) {
+	env := newTestEnv(t)
+	owner := newTestWallet(t)
+	id := env.createCredential(owner, "djE=")
+	vaultPath := env.credential(id).VaultPath
+
+	status, body := env.updateSecret(owner, id, "djI=", 1)
+	if status != http.StatusOK || body["version"] != float64(2) {
+		t.Fatalf("update: status %d, body %v", status, body)
+	}
+
+	// A second writer that read version 1 loses and writes nothing
+	status, body = env.updateSecret(owner, id, "c3RhbGU=", 1)
+	if status != http.StatusConflict {
+		t.Fatalf("stale update: status %d, want %d", status, http.StatusConflict)
+	}
+	if body["version"] != float64(2) {
+		t.Errorf("stale update: version = %v, want the current 2", body["version"])
+	}
+	if total, _ := env.vault.versions(vaultPath); total != 2 {
+		t.Errorf("Vault holds %d versions, want 2", total)
+	}
+
+	credential := env.credential(id)
+	if credential.Version != 2 || credential.EncryptedData != "ZW5jMg==" {
+		t.Errorf("credential = version %d, %q", credential.Version, credential.EncryptedData)
+	}
+	if status, body := env.reveal(owner, id); status != http.StatusOK || body["share1"] != "djI=" {
+		t.Errorf("reveal: status %d, share1 %v, want djI=", status, body["share1"])
+	}
+
+	// Without a version the update applies to whatever is current
+	if status, body := env.updateSecret(owner, id, "djM=", 0); status != http.StatusOK || body["version"] != float64(3) {
+		t.Errorf("unversioned update: status %d, body %v", status, body)
+	}
+}
+
+func TestUpdateCredentialOtherWallet(t *testing.T) {
+	env := newTestEnv(t)
+	owner, other := newTestWallet(t), newTestWallet(t)
+	id := env.createCredential(owner, "djE=")
+
+	if status, _ := env.updateSecret(other, id, "djI=", 1); status != http.StatusNotFound {
+		t.Errorf("update by another wallet: status %d, want %d", status, http.StatusNotFound)
+	}
+	if credential := env.credential(id); credential.Version != 1 {
+		t.Errorf("version = %d, want 1", credential.Version)
+	}
+}
+
+func TestSplitScheme(t *testing.T) {
+	env := newTestEnv(t)
+	owner := newTestWallet(t)
+	hash := strings.Repeat("ab", 32)
+
+	create := func(scheme, share3Hash string) (int, string) {
+		var created struct {
+			ID string `json:"id"`
+		}
+		status := env.do(owner, http.MethodPost, "/credentials", gin.H{
+			"name":          "github " + scheme + share3Hash,
+			"username":      "alice",
+			"encryptedData": "ZW5j",
+			"nonce":         "bm9uY2U=",
+			"share1":        "djE=",
+			"share2":        "czI=",
+			"share3Hash":    share3Hash,
+			"splitScheme":   scheme,
+			"walletAddress": owner.address,
+		}, nil, &created)
+		return status, created.ID
+	}
+
+	rejected := []struct {
+		name       string
+		scheme     string
+		share3Hash string
+	}{
+		{"unknown scheme", "shamir", ""},
+		{"recovery without a commitment", models.SplitRecovery, ""},
+		{"xor with a commitment", models.SplitXOR, hash},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := create(tt.scheme, tt.share3Hash); status != http.StatusBadRequest {
+				t.Errorf("create: status %d, want %d", status, http.StatusBadRequest)
+			}
+		})
+	}
+
+	status, id := create(models.SplitRecovery, hash)
+	if status != http.StatusCreated {
+		t.Fatalf("create: status %d", status)
+	}
+
+	// A new secret keeps the scheme, so it cannot silently drop the commitment
+	if status, _ := env.updateSecret(owner, id, "djI=", 1); status != http.StatusBadRequest {
+		t.Errorf("update without share3Hash: status %d, want %d", status, http.StatusBadRequest)
+	}
+	other := strings.Repeat("cd", 32)
+	status = env.do(owner, http.MethodPut, "/credentials/"+id, gin.H{
+		"encryptedData": "ZW5jMg==",
+		"nonce":         "bm9uY2Uy",
+		"share1":        "djI=",
+		"share2":        "czI=",
+		"share3Hash":    other,
+	}, nil, nil)
+	if status != http.StatusOK {
+		t.Fatalf("update: status %d", status)
+	}
+	if credential := env.credential(id); credential.SplitScheme != models.SplitRecovery || credential.Share3Hash != other {
+		t.Errorf("after update: scheme %q, share3Hash %q", credential.SplitScheme, credential.Share3Hash)
+	}
+
+	// Credentials from older clients have no scheme and keep none
+	legacy := env.createCredential(owner, "djE=")
+	if status, _ := env.updateSecret(owner, legacy, "djI=", 1); status != http.StatusOK {
+		t.Fatalf("legacy update: status %d", status)
+	}
+	if credential := env.credential(legacy); credential.SplitScheme != "" {
+		t.Errorf("legacy scheme = %q, want none", credential.SplitScheme)
+	}
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+const (
+	defaultEmergencyWait = 7 * 24 * time.Hour
+	minEmergencyWait     = time.Hour
+	maxEmergencyWait     = 90 * 24 * time.Hour
+
+	// maxEscrowKeys bounds one EscrowKeys request
+	maxEscrowKeys = 500
+)
+
+// EmergencyHandler manages emergency contacts and their access requests.
+// Granting a request once its wait is over is left to worker.EmergencyGranter.
+type EmergencyHandler struct {
+	db     *database.Database
+	audit  *auditTrail
+	logger *logger.Logger
+}
+
+func NewEmergencyHandler(db *database.Database, fabric *services.FabricClient, log *logger.Logger) *EmergencyHandler {
+	return &EmergencyHandler{
+		db:     db,
+		audit:  newAuditTrail(db, fabric, log),
+		logger: log,
+	}
+}
+
+// AddContact handles POST /api/v1/emergency/contacts
+// The contact must have published an encryption key, which the owner's client
+// then wraps credential keys to (EscrowKeys).
+func (h *EmergencyHandler) AddContact(c *gin.Context) {
+	var req models.AddEmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	wallets := linkedWallets(h.db, walletAddress)
+
+	wait := defaultEmergencyWait
+	if req.WaitPeriod != 0 {
+		wait = time.Duration(req.WaitPeriod) * time.Second
+	}
+	if wait < minEmergencyWait || wait > maxEmergencyWait {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "waitPeriod must be between 1 hour and 90 days"})
+		return
+	}
+
+	contactWallet, err := auth.ChecksumAddress(req.ContactWallet)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact wallet"})
+		return
+	}
+	if _, own := findWallet(wallets, contactWallet); own {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contact is linked to your own account"})
+		return
+	}
+
+	var key models.WalletKey
+	if err := h.db.Where("wallet_address = ?", contactWallet).First(&key).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contact has not published an encryption key"})
+		return
+	}
+	if key.Algorithm != services.WrapAlgorithm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contact's encryption key algorithm is not supported", "algorithm": key.Algorithm})
+		return
+	}
+
+	var existing int64
+	h.db.Model(&models.EmergencyContact{}).
+		Where("owner_wallet IN ? AND contact_wallet = ? AND revoked_at IS NULL", wallets, contactWallet).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Wallet is already an emergency contact"})
+		return
+	}
+
+	contact := &models.EmergencyContact{
+		OwnerWallet:   walletAddress,
+		ContactWallet: contactWallet,
+		WaitPeriod:    int(wait / time.Second),
+	}
+	if err := h.db.Create(contact).Error; err != nil {
+		h.logger.Error("Failed to add emergency contact", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add emergency contact"})
+		return
+	}
+
+	txID := h.audit.recordOnChain(c, walletAddress, contact.ID, "emergency_contact_add")
+
+	h.logger.Info("Emergency contact added", "id", contact.ID, "wallet", walletAddress, "contact", contactWallet)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"contact": contact,
+		"txId":    txID,
+	})
+}
+
+// GetContacts handles GET /api/v1/emergency/contacts
+// Lists the caller's contacts and the owners who made the caller theirs.
+func (h *EmergencyHandler) GetContacts(c *gin.Context) {
+	wallets := linkedWallets(h.db, middleware.WalletAddress(c))
+
+	var contacts, trustedBy []models.EmergencyContact
+	err := h.db.Where("owner_wallet IN ? AND revoked_at IS NULL", wallets).Order("created_at").Find(&contacts).Error
+	if err == nil {
+		err = h.db.Where("contact_wallet IN ? AND revoked_at IS NULL", wallets).Order("created_at").Find(&trustedBy).Error
+	}
+	if err != nil {
+		h.logger.Error("Failed to fetch emergency contacts", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch emergency contacts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contacts":  contacts,
+		"trustedBy": trustedBy,
+	})
+}
+
+// escrowView is an escrowed key as listed to the owner
+type escrowView struct {
+	models.EmergencyEscrow
+	Stale bool `json:"stale"` // Wrapped for an older version; it would not be released
+}
+
+// EscrowKeys handles PUT /api/v1/emergency/contacts/:id/keys
+//
+// The owner's client rebuilds each credential's data key and wraps it to the
+// contact's encryption key; the granter releases these to the contact. Keys
+// already escrowed for a credential are replaced.
+func (h *EmergencyHandler) EscrowKeys(c *gin.Context) {
+	var req models.EscrowKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if len(req.Keys) > maxEscrowKeys {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many keys in one request", "max": maxEscrowKeys})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	wallets := linkedWallets(h.db, walletAddress)
+
+	var contact models.EmergencyContact
+	if err := h.db.Where("id = ? AND owner_wallet IN ? AND revoked_at IS NULL", c.Param("id"), wallets).
+		First(&contact).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Emergency contact not found"})
+		return
+	}
+
+	ids := make([]string, len(req.Keys))
+	for i, key := range req.Keys {
+		ids[i] = key.CredentialID
+	}
+	var credentials []models.Credential
+	if err := h.db.Where("id IN ? AND wallet_address IN ? AND org_id IS NULL", ids, wallets).Find(&credentials).Error; err != nil {
+		h.logger.Error("Failed to look up credentials", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to escrow keys"})
+		return
+	}
+	byID := make(map[string]*models.Credential, len(credentials))
+	for i := range credentials {
+		byID[credentials[i].ID] = &credentials[i]
+	}
+
+	escrows := make([]models.EmergencyEscrow, len(req.Keys))
+	for i, key := range req.Keys {
+		credential, ok := byID[key.CredentialID]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found", "credentialId": key.CredentialID})
+			return
+		}
+		// The contact could satisfy neither, as with shares
+		if credential.RequirePasskey || credential.RequiresApproval() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Credentials that require a passkey or approval cannot be escrowed", "credentialId": key.CredentialID})
+			return
+		}
+		if key.Version != credential.Version {
+			c.JSON(http.StatusConflict, gin.H{"error": "Key was wrapped for another version", "credentialId": key.CredentialID, "version": credential.Version})
+			return
+		}
+		if !services.ValidWrapped(key.WrappedKey) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wrappedKey must be " + services.WrapAlgorithm + " encrypted data", "credentialId": key.CredentialID})
+			return
+		}
+		escrows[i] = models.EmergencyEscrow{
+			ContactID:    contact.ID,
+			CredentialID: credential.ID,
+			WrappedKey:   key.WrappedKey,
+			Version:      credential.Version,
+		}
+	}
+
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "contact_id"}, {Name: "credential_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"wrapped_key", "version", "updated_at"}),
+	}).Create(&escrows).Error; err != nil {
+		h.logger.Error("Failed to escrow keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to escrow keys"})
+		return
+	}
+
+	h.logger.Info("Emergency keys escrowed", "contact", contact.ID, "wallet", walletAddress, "count", len(escrows))
+
+	c.JSON(http.StatusOK, gin.H{"escrowed": len(escrows)})
+}
+
+// GetEscrowKeys handles GET /api/v1/emergency/contacts/:id/keys
+// Lists the credentials escrowed for a contact, so the owner can re-escrow
+// the stale ones after updating them.
+func (h *EmergencyHandler) GetEscrowKeys(c *gin.Context) {
+	var contact models.EmergencyContact
+	if err := h.db.Where("id = ? AND owner_wallet IN ? AND revoked_at IS NULL", c.Param("id"), linkedWallets(h.db, middleware.WalletAddress(c))).
+		First(&contact).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Emergency contact not found"})
+		return
+	}
+
+	var views []escrowView
+	if err := h.db.Model(&models.EmergencyEscrow{}).
+		Select("emergency_escrows.*, credentials.version <> emergency_escrows.version AS stale").
+		Joins("JOIN credentials ON credentials.id = emergency_escrows.credential_id AND credentials.deleted_at IS NULL").
+		Where("emergency_escrows.contact_id = ?", contact.ID).
+		Order("emergency_escrows.updated_at").
+		Scan(&views).Error; err != nil {
+		h.logger.Error("Failed to fetch escrowed keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch escrowed keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// RevokeContact handles DELETE /api/v1/emergency/contacts/:id
+//
+// Denies the contact's pending request, drops the keys escrowed for them and
+// revokes any shares an earlier granted request gave them.
+func (h *EmergencyHandler) RevokeContact(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var contact models.EmergencyContact
+	if err := h.db.Where("id = ? AND owner_wallet IN ? AND revoked_at IS NULL", c.Param("id"), linkedWallets(h.db, walletAddress)).
+		First(&contact).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Emergency contact not found"})
+		return
+	}
+
+	now := time.Now()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&contact).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.EmergencyRequest{}).
+			Where("contact_id = ? AND denied_at IS NULL AND granted_at IS NULL", contact.ID).
+			Update("denied_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("contact_id = ?", contact.ID).Delete(&models.EmergencyEscrow{}).Error; err != nil {
+			return err
+		}
+		requests := tx.Model(&models.EmergencyRequest{}).Select("id").Where("contact_id = ?", contact.ID)
+		return tx.Model(&models.CredentialShare{}).
+			Where("emergency_request_id IN (?) AND revoked_at IS NULL", requests).
+			Updates(map[string]interface{}{
+				"revoked_at":  now,
+				"wrapped_key": "",
+			}).Error
+	})
+	if err != nil {
+		h.logger.Error("Failed to revoke emergency contact", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke emergency contact"})
+		return
+	}
+
+	txID := h.audit.recordOnChain(c, walletAddress, contact.ID, "emergency_contact_revoke")
+
+	h.logger.Info("Emergency contact revoked", "id", contact.ID, "wallet", walletAddress, "contact", contact.ContactWallet)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Emergency contact revoked successfully",
+		"txId":    txID,
+	})
+}
+
+// RequestAccess handles POST /api/v1/emergency/contacts/:id/request
+// Called by the contact. The owner is notified and has the contact's wait
+// period to deny the request.
+func (h *EmergencyHandler) RequestAccess(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var contact models.EmergencyContact
+	if err := h.db.Where("id = ? AND contact_wallet IN ? AND revoked_at IS NULL", c.Param("id"), linkedWallets(h.db, walletAddress)).
+		First(&contact).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Emergency contact not found"})
+		return
+	}
+
+	request := &models.EmergencyRequest{
+		ContactID:     contact.ID,
+		OwnerWallet:   contact.OwnerWallet,
+		ContactWallet: contact.ContactWallet,
+		GrantAt:       time.Now().Add(time.Duration(contact.WaitPeriod) * time.Second),
+	}
+
+	// The partial unique index allows one pending request per contact
+	result := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(request)
+	if result.Error != nil {
+		h.logger.Error("Failed to create emergency request", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request access"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A request is already pending"})
+		return
+	}
+
+	request.RequestTxHash = h.audit.recordOnChain(c, contact.OwnerWallet, request.ID, "emergency_request")
+	if request.RequestTxHash != "" {
+		h.db.Model(request).Update("request_tx_hash", request.RequestTxHash)
+	}
+
+	notify(h.db, h.logger, contact.OwnerWallet, models.NotificationEmergencyRequest, "",
+		contact.ContactWallet+" requested emergency access to your credentials; deny it before "+request.GrantAt.UTC().Format(time.RFC1123))
+
+	h.logger.Info("Emergency access requested", "id", request.ID, "owner", contact.OwnerWallet, "contact", contact.ContactWallet, "grantAt", request.GrantAt)
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// GetRequests handles GET /api/v1/emergency/requests
+// Lists requests made of the caller and by the caller, most recent first.
+func (h *EmergencyHandler) GetRequests(c *gin.Context) {
+	wallets := linkedWallets(h.db, middleware.WalletAddress(c))
+
+	var requests []models.EmergencyRequest
+	if err := h.db.Where("owner_wallet IN ? OR contact_wallet IN ?", wallets, wallets).
+		Order("created_at DESC").
+		Limit(100).
+		Find(&requests).Error; err != nil {
+		h.logger.Error("Failed to fetch emergency requests", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch emergency requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, requests)
+}
+
+// DenyRequest handles POST /api/v1/emergency/requests/:id/deny
+func (h *EmergencyHandler) DenyRequest(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var request models.EmergencyRequest
+	if err := h.db.Where("id = ? AND owner_wallet IN ?", c.Param("id"), linkedWallets(h.db, walletAddress)).
+		First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Emergency request not found"})
+		return
+	}
+
+	// Loses the race against the granter claiming the request
+	result := h.db.Model(&models.EmergencyRequest{}).
+		Where("id = ? AND denied_at IS NULL AND granted_at IS NULL", request.ID).
+		Update("denied_at", time.Now())
+	if result.Error != nil {
+		h.logger.Error("Failed to deny emergency request", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deny request"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Request is no longer pending"})
+		return
+	}
+
+	txID := h.audit.recordOnChain(c, request.OwnerWallet, request.ID, "emergency_deny")
+	if txID != "" {
+		h.db.Model(&request).Update("result_tx_hash", txID)
+	}
+
+	notify(h.db, h.logger, request.ContactWallet, models.NotificationEmergencyDenied, "",
+		"Your emergency access request to "+request.OwnerWallet+" was denied")
+
+	h.logger.Info("Emergency request denied", "id", request.ID, "wallet", walletAddress, "contact", request.ContactWallet)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Request denied",
+		"txId":    txID,
+	})
+}
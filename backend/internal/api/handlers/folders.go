@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/pkg/logger"
+)
+
+type FolderHandler struct {
+	db     *database.Database
+	logger *logger.Logger
+}
+
+func NewFolderHandler(db *database.Database, log *logger.Logger) *FolderHandler {
+	return &FolderHandler{
+		db:     db,
+		logger: log,
+	}
+}
+
+var errFolderNotFound = errors.New("folder not found")
+
+// findFolder loads a folder owned by any wallet linked to walletAddress
+func findFolder(db *gorm.DB, wallets []string, id string) (*models.Folder, error) {
+	var folder models.Folder
+	if err := db.Where("id = ? AND wallet_address IN ?", id, wallets).First(&folder).Error; err != nil {
+		return nil, errFolderNotFound
+	}
+	return &folder, nil
+}
+
+// folderTree returns the ID of folder id and of every folder below it
+func folderTree(db *gorm.DB, id string) ([]string, error) {
+	var ids []string
+	err := db.Raw(`WITH RECURSIVE tree AS (
+		SELECT id FROM folders WHERE id = ?
+		UNION ALL
+		SELECT f.id FROM folders f JOIN tree t ON f.parent_id = t.id
+	) SELECT id FROM tree`, id).Scan(&ids).Error
+	return ids, err
+}
+
+// scopeCredentials narrows a credential query to ?folder= (the folder and its
+// subfolders, or "none" for unfiled credentials) and ?tag=
+func scopeCredentials(db *database.Database, query *gorm.DB, c *gin.Context, wallets []string) (*gorm.DB, error) {
+	switch folderID := c.Query("folder"); folderID {
+	case "":
+	case "none":
+		query = query.Where("folder_id IS NULL")
+	default:
+		if _, err := findFolder(db.DB, wallets, folderID); err != nil {
+			return nil, err
+		}
+		ids, err := folderTree(db.DB, folderID)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("folder_id IN ?", ids)
+	}
+
+	if tag := c.Query("tag"); tag != "" {
+		query = query.Where("id IN (?)", taggedCredentials(db, wallets, []string{tag}))
+	}
+
+	return query, nil
+}
+
+// GetFolders handles GET /api/v1/folders
+// Folders are returned flat; clients build the tree from parentId.
+func (h *FolderHandler) GetFolders(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var folders []models.Folder
+	if err := h.db.Where("wallet_address IN ?", linkedWallets(h.db, walletAddress)).Order("name").Find(&folders).Error; err != nil {
+		h.logger.Error("Failed to fetch folders", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch folders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, folders)
+}
+
+// CreateFolder handles POST /api/v1/folders
+func (h *FolderHandler) CreateFolder(c *gin.Context) {
+	var req models.CreateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+
+	folder := &models.Folder{WalletAddress: walletAddress, Name: req.Name}
+	if req.ParentID != nil && *req.ParentID != "" {
+		if _, err := findFolder(h.db.DB, linkedWallets(h.db, walletAddress), *req.ParentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent folder not found"})
+			return
+		}
+		folder.ParentID = req.ParentID
+	}
+
+	if err := h.db.Create(folder).Error; err != nil {
+		h.logger.Error("Failed to create folder", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create folder"})
+		return
+	}
+
+	h.logger.Info("Folder created", "id", folder.ID, "wallet", walletAddress)
+
+	c.JSON(http.StatusCreated, folder)
+}
+
+// UpdateFolder handles PATCH /api/v1/folders/:id (rename and/or move)
+func (h *FolderHandler) UpdateFolder(c *gin.Context) {
+	var req models.UpdateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	wallets := linkedWallets(h.db, middleware.WalletAddress(c))
+
+	folder, err := findFolder(h.db.DB, wallets, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if *req.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Folder name cannot be empty"})
+			return
+		}
+		updates["name"] = *req.Name
+	}
+
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			updates["parent_id"] = nil
+		} else {
+			if _, err := findFolder(h.db.DB, wallets, *req.ParentID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parent folder not found"})
+				return
+			}
+
+			// A folder cannot move into itself or one of its own subfolders
+			subtree, err := folderTree(h.db.DB, folder.ID)
+			if err != nil {
+				h.logger.Error("Failed to read folder tree", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update folder"})
+				return
+			}
+			if containsString(subtree, *req.ParentID) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot move a folder into itself"})
+				return
+			}
+			updates["parent_id"] = *req.ParentID
+		}
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if err := h.db.Model(folder).Updates(updates).Error; err != nil {
+		h.logger.Error("Failed to update folder", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update folder"})
+		return
+	}
+
+	h.db.First(folder, "id = ?", folder.ID)
+
+	c.JSON(http.StatusOK, folder)
+}
+
+// DeleteFolder handles DELETE /api/v1/folders/:id
+// Its credentials and subfolders move up to the deleted folder's parent.
+func (h *FolderHandler) DeleteFolder(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	folder, err := findFolder(h.db.DB, linkedWallets(h.db, walletAddress), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Folder not found"})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Folder{}).Where("parent_id = ?", folder.ID).Update("parent_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Credential{}).Where("folder_id = ?", folder.ID).Update("folder_id", folder.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(folder).Error
+	})
+	if err != nil {
+		h.logger.Error("Failed to delete folder", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete folder"})
+		return
+	}
+
+	h.logger.Info("Folder deleted", "id", id, "wallet", walletAddress)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Folder deleted successfully"})
+}
+
+// MoveCredential handles PUT /api/v1/credentials/:id/folder
+func (h *CredentialHandler) MoveCredential(c *gin.Context) {
+	var req models.MoveCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot organize credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	var folderID *string
+	if req.FolderID != "" {
+		if _, err := findFolder(h.db.DB, linkedWallets(h.db, walletAddress), req.FolderID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Folder not found"})
+			return
+		}
+		folderID = &req.FolderID
+	}
+
+	if err := h.db.Model(credential).Update("folder_id", folderID).Error; err != nil {
+		h.logger.Error("Failed to move credential", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move credential"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       credential.ID,
+		"folderId": folderID,
+	})
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// Handler tests run against the Postgres database at TEST_DATABASE_DSN (its
+// schema is migrated; every test uses fresh wallets) and an in-memory Vault.
+// Fabric is not configured.
+
+// fakeVault serves the subset of the Vault KV v2 API VaultService uses
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string][]map[string]interface{} // Path → data by version - 1; nil once destroyed
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *services.VaultService) {
+	t.Helper()
+	f := &fakeVault{secrets: map[string][]map[string]interface{}{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	vault, err := services.NewVaultService(server.URL, "test-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, vault
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	route := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+	kind, path, _ := strings.Cut(route, "/")
+
+	switch {
+	case kind == "data" && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.secrets[path] = append(f.secrets[path], body.Data)
+		writeVaultJSON(w, map[string]interface{}{"version": len(f.secrets[path])})
+
+	case kind == "data" && r.Method == http.MethodGet:
+		versions := f.secrets[path]
+		if len(versions) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		version := len(versions)
+		if raw := r.URL.Query().Get("version"); raw != "" {
+			version, _ = strconv.Atoi(raw)
+		}
+		if version < 1 || version > len(versions) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// Destroyed versions keep their metadata but lose their data
+		var data interface{}
+		if versions[version-1] != nil {
+			data = versions[version-1]
+		}
+		writeVaultJSON(w, map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": version}})
+
+	case kind == "destroy":
+		var body struct {
+			Versions []int `json:"versions"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, version := range body.Versions {
+			if version >= 1 && version <= len(f.secrets[path]) {
+				f.secrets[path][version-1] = nil
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case kind == "metadata" && r.Method == http.MethodDelete:
+		delete(f.secrets, path)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeVaultJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+// versions returns how many versions Vault holds at a credential's data
+// path, and how many of them still have their data
+func (f *fakeVault) versions(vaultPath string) (total, live int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	versions := f.secrets[strings.TrimPrefix(vaultPath, "secret/data/")]
+	for _, data := range versions {
+		if data != nil {
+			live++
+		}
+	}
+	return len(versions), live
+}
+
+// testWallet is a secp256k1 key that signs as personal_sign does
+type testWallet struct {
+	key     *secp256k1.PrivateKey
+	address string
+}
+
+func newTestWallet(t *testing.T) testWallet {
+	t.Helper()
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := testWallet{key: key}
+	wallet.address, err = auth.RecoverAddress([]byte("address"), wallet.sign("address"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+func (w testWallet) sign(message string) string {
+	compact := ecdsa.SignCompact(w.key, auth.HashPersonalMessage([]byte(message)), false)
+	sig := make([]byte, 65)
+	copy(sig, compact[1:])
+	sig[64] = compact[0]
+	return "0x" + hex.EncodeToString(sig)
+}
+
+// testEnv routes requests to the handlers under test as an authenticated,
+// signed request of the wallet in X-Test-Wallet
+type testEnv struct {
+	t      *testing.T
+	db     *database.Database
+	vault  *fakeVault
+	router *gin.Engine
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	db := &database.Database{DB: conn}
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	fake, vault := newFakeVault(t)
+	log := &logger.Logger{SugaredLogger: zap.NewNop().Sugar()}
+	nonces := services.NewMemoryNonceStore()
+
+	credHandler := NewCredentialHandler(db, vault, nil, nonces, time.Minute, time.Hour, time.Hour, log)
+	accountHandler := NewAccountHandler(db, vault, nil, nonces, time.Minute, log)
+	shareHandler := NewShareHandler(db, log)
+	approvalHandler := NewApprovalHandler(db, nil, log)
+	orgHandler := NewOrgHandler(db, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextWalletAddress, c.GetHeader("X-Test-Wallet"))
+		c.Set(middleware.ContextRequestSigned, true)
+	})
+	router.POST("/credentials", credHandler.CreateCredential)
+	router.GET("/credentials/:id", credHandler.GetCredentialByID)
+	router.POST("/credentials/:id/reveal-challenge", credHandler.CreateRevealChallenge)
+	router.PUT("/credentials/:id", credHandler.UpdateCredential)
+	router.POST("/credentials/:id/rollback", credHandler.RollbackCredential)
+	router.PUT("/credentials/:id/approval-policy", credHandler.SetApprovalPolicy)
+	router.GET("/credentials/:id/shares", credHandler.GetCredentialShares)
+	router.POST("/credentials/:id/shares", credHandler.ShareCredential)
+	router.DELETE("/credentials/:id/shares/:shareId", credHandler.RevokeShare)
+	router.POST("/accounts/migrate", accountHandler.MigrateWallet)
+	router.POST("/accounts/unlink-challenge", accountHandler.CreateUnlinkChallenge)
+	router.DELETE("/accounts/wallets/:address", accountHandler.UnlinkWallet)
+	router.PUT("/sharing/key", shareHandler.PublishKey)
+	router.GET("/approvals/:id", approvalHandler.GetApproval)
+	router.POST("/orgs", orgHandler.CreateOrganization)
+	router.POST("/orgs/:id/members", orgHandler.AddMember)
+	router.DELETE("/orgs/:id/members/:address", orgHandler.RemoveMember)
+	router.PUT("/orgs/:id/members/:address/keys", orgHandler.SetMemberKeys)
+	router.GET("/orgs/:id/keys", orgHandler.GetMemberKeys)
+	router.POST("/approvals/:id/approve", approvalHandler.Approve)
+
+	return &testEnv{t: t, db: db, vault: fake, router: router}
+}
+
+// do sends a request as wallet and decodes the JSON response into out (may be nil)
+func (e *testEnv) do(wallet testWallet, method, path string, body interface{}, header http.Header, out interface{}) int {
+	e.t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			e.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Wallet", wallet.address)
+
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			e.t.Fatalf("%s %s: %d %s", method, path, rec.Code, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
+
+// createCredential stores a login for wallet whose share1 is share1
+func (e *testEnv) createCredential(wallet testWallet, share1 string) string {
+	e.t.Helper()
+	var created struct {
+		ID string `json:"id"`
+	}
+	status := e.do(wallet, http.MethodPost, "/credentials", gin.H{
+		"name":          "github " + share1,
+		"username":      "alice",
+		"encryptedData": "ZW5j",
+		"nonce":         "bm9uY2U=",
+		"share1":        share1,
+		"share2":        "czI=",
+		"walletAddress": wallet.address,
+	}, nil, &created)
+	if status != http.StatusCreated {
+		e.t.Fatalf("create: status %d", status)
+	}
+	return created.ID
+}
+
+// updateSecret PUTs a new secret whose share1 is share1, expecting version
+func (e *testEnv) updateSecret(wallet testWallet, id, share1 string, version int) (int, map[string]interface{}) {
+	e.t.Helper()
+	var body map[string]interface{}
+	status := e.do(wallet, http.MethodPut, "/credentials/"+id, gin.H{
+		"encryptedData": "ZW5jMg==",
+		"nonce":         "bm9uY2Uy",
+		"share1":        share1,
+		"share2":        "czI=",
+		"version":       version,
+	}, nil, &body)
+	return status, body
+}
+
+// reveal signs a fresh reveal challenge as wallet and reads the credential
+func (e *testEnv) reveal(wallet testWallet, id string) (int, map[string]interface{}) {
+	e.t.Helper()
+	var challenge struct {
+		Nonce   string `json:"nonce"`
+		Message string `json:"message"`
+	}
+	if status := e.do(wallet, http.MethodPost, "/credentials/"+id+"/reveal-challenge", nil, nil, &challenge); status != http.StatusOK {
+		return status, nil
+	}
+
+	header := http.Header{}
+	header.Set("X-Reveal-Nonce", challenge.Nonce)
+	header.Set("X-Reveal-Signature", wallet.sign(challenge.Message))
+	var body map[string]interface{}
+	status := e.do(wallet, http.MethodGet, "/credentials/"+id, nil, header, &body)
+	return status, body
+}
+
+// credential loads credential id as stored
+func (e *testEnv) credential(id string) *models.Credential {
+	e.t.Helper()
+	var credential models.Credential
+	if err := e.db.Unscoped().Where("id = ?", id).First(&credential).Error; err != nil {
+		e.t.Fatalf("credential %s: %v", id, err)
+	}
+	return &credential
+}
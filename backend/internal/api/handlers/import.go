@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+)
+
+// maxImportItems bounds one import so its Vault and Fabric writes (and their
+// compensation on failure) finish within a request
+const maxImportItems = 500
+
+// importResult is the outcome of one item of an import, in request order
+type importResult struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	ID    string `json:"id,omitempty"`
+	TxID  string `json:"txId,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// importedShares is what an import wrote outside the database for one item,
+// so it can be undone if a later step fails
+type importedShares struct {
+	id           string // Of the credential to be created
+	vaultPath    string
+	vaultVersion int
+	shardKey     string // Empty unless share2 reached Fabric
+	txID         string
+}
+
+// nameInUse reports whether a live or restorable trashed credential of
+// walletAddress's own vault has the name; expired ones awaiting the purge
+// do not count
+func nameInUse(db *database.Database, walletAddress, name string) bool {
+	var count int64
+	db.Unscoped().Model(&models.Credential{}).
+		Where("wallet_address = ? AND org_id IS NULL AND credential_name = ? AND (deleted_at IS NULL OR purge_at > ?)", walletAddress, name, time.Now()).
+		Count(&count)
+	return count > 0
+}
+
+// ImportCredentials handles POST /api/v1/credentials/import
+//
+// Every item is validated before anything is written. Shares then go to Vault
+// and Fabric item by item; if any write or the final database transaction
+// fails, the shares already written are removed again and nothing is saved.
+func (h *CredentialHandler) ImportCredentials(c *gin.Context) {
+	var req models.ImportCredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	if !auth.SameAddress(req.WalletAddress, walletAddress) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Wallet address does not match signature"})
+		return
+	}
+
+	if key := middleware.APIKey(c); key != nil && !key.Unrestricted() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key is scoped to existing credentials"})
+		return
+	}
+
+	c.JSON(h.createAll(c, walletAddress, req.Credentials, "import"))
+}
+
+// createAll creates items for walletAddress all or nothing and returns the
+// response: 201 with per-item results, or an error with the failing items.
+// action names the audit event of each created credential.
+func (h *CredentialHandler) createAll(c *gin.Context, walletAddress string, items []models.CreateCredentialRequest, action string) (int, gin.H) {
+	if len(items) > maxImportItems {
+		return http.StatusBadRequest, gin.H{"error": "Too many credentials in one import", "max": maxImportItems}
+	}
+
+	// Validate everything first
+	results := make([]importResult, len(items))
+	names := make(map[string]int, len(items))
+	valid := true
+	for i := range items {
+		item := &items[i]
+		results[i] = importResult{Index: i, Name: item.Name}
+
+		if item.WalletAddress == "" {
+			item.WalletAddress = walletAddress
+		}
+		if errMsg := h.checkImportItem(item, walletAddress, names, i); errMsg != "" {
+			results[i].Error = errMsg
+			valid = false
+		}
+	}
+	if !valid {
+		return http.StatusBadRequest, gin.H{"error": "Import rejected; nothing was saved", "results": results}
+	}
+
+	h.logger.Info("Importing credentials", "wallet", walletAddress, "count", len(items))
+
+	// Write the shares, undoing them all on the first failure
+	written := make([]importedShares, 0, len(items))
+	for i := range items {
+		shares, errMsg := h.storeImportShares(&items[i])
+		if errMsg != "" {
+			h.undoImport(walletAddress, written)
+			results[i].Error = errMsg
+			return http.StatusInternalServerError, gin.H{"error": "Import failed; nothing was saved", "results": results}
+		}
+		written = append(written, shares)
+	}
+
+	credentials := make([]*models.Credential, len(items))
+	for i := range items {
+		item := &items[i]
+		credentials[i] = &models.Credential{
+			ID:             written[i].id,
+			CredentialName: item.Name,
+			Kind:           item.Kind,
+			Username:       item.Username,
+			ExpiresAt:      item.ExpiresAt,
+			URL:            item.URL,
+			EncryptedData:  item.EncryptedData,
+			Nonce:          item.Nonce,
+			Metadata:       item.Metadata,
+			WalletAddress:  walletAddress,
+			VaultPath:      written[i].vaultPath,
+			BlockchainTxID: written[i].txID,
+			Share2:         item.Share2, // Fallback storage in DB
+			Share3Hash:     item.Share3Hash,
+			SplitScheme:    item.SplitScheme,
+			RequirePasskey: item.RequirePasskey,
+			Version:        1,
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for i, credential := range credentials {
+			if err := tx.Create(credential).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(snapshotVersion(credential, written[i].vaultVersion, walletAddress)).Error; err != nil {
+				return err
+			}
+			for _, wrapped := range items[i].RefreshDeltas {
+				delta := &models.RefreshDelta{CredentialID: credential.ID, Version: credential.Version, WrappedDelta: wrapped}
+				if err := tx.Create(delta).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error("Failed to save imported credentials", "error", err)
+		h.undoImport(walletAddress, written)
+		return http.StatusInternalServerError, gin.H{"error": "Import failed; nothing was saved"}
+	}
+
+	for i, credential := range credentials {
+		h.audit.record(c, walletAddress, credential.ID, action)
+		results[i].ID = credential.ID
+		results[i].TxID = credential.BlockchainTxID
+	}
+
+	h.logger.Info("Credentials imported", "wallet", walletAddress, "count", len(credentials))
+
+	return http.StatusCreated, gin.H{
+		"imported": len(credentials),
+		"results":  results,
+	}
+}
+
+// checkImportItem validates item i of an import. names tracks the names seen
+// so far, which must be unique so that importing a file twice is caught.
+func (h *CredentialHandler) checkImportItem(item *models.CreateCredentialRequest, walletAddress string, names map[string]int, i int) string {
+	if err := binding.Validator.ValidateStruct(item); err != nil {
+		return err.Error()
+	}
+	if !auth.SameAddress(item.WalletAddress, walletAddress) {
+		return "Wallet address does not match signature"
+	}
+	item.WalletAddress = walletAddress
+	if item.OrgID != nil {
+		return "Organization credentials cannot be imported"
+	}
+
+	if first, ok := names[item.Name]; ok {
+		return fmt.Sprintf("Duplicate name (same as item %d)", first)
+	}
+	names[item.Name] = i
+
+	if _, errMsg := h.checkNewCredential(item); errMsg != "" {
+		return errMsg
+	}
+	if nameInUse(h.db, walletAddress, item.Name) {
+		return "A credential with this name already exists"
+	}
+	return ""
+}
+
+// storeImportShares writes share1 to Vault and, when Fabric is configured,
+// share2 to the ledger. Unlike a single create, a Fabric failure fails the
+// import rather than falling back to the database.
+func (h *CredentialHandler) storeImportShares(item *models.CreateCredentialRequest) (importedShares, string) {
+	shares := importedShares{
+		id:   uuid.NewString(),
+		txID: "fabric-not-configured",
+	}
+	shares.vaultPath = credentialVaultPath(item.WalletAddress, shares.id)
+
+	vaultVersion, err := h.vault.WriteSecretVersion(shares.vaultPath, map[string]interface{}{
+		"share1":     item.Share1,
+		"created_at": "now",
+	})
+	if err != nil {
+		h.logger.Error("Failed to store in Vault", "error", err)
+		return shares, "Failed to store encryption key"
+	}
+	shares.vaultVersion = vaultVersion
+
+	if h.fabric != nil {
+		txID, err := h.fabric.StoreShard(item.WalletAddress, shares.id, item.Share2)
+		if err != nil {
+			h.logger.Error("Failed to store in Fabric", "error", err)
+			h.purgeImportedSecret(shares.vaultPath)
+			return shares, "Failed to store share on the blockchain"
+		}
+		shares.shardKey = shares.id
+		shares.txID = txID
+	}
+
+	return shares, ""
+}
+
+// undoImport removes the shares written by an import that did not complete
+func (h *CredentialHandler) undoImport(walletAddress string, written []importedShares) {
+	for _, shares := range written {
+		h.purgeImportedSecret(shares.vaultPath)
+		if shares.shardKey != "" {
+			if _, err := h.fabric.DeleteShard(walletAddress, shares.shardKey); err != nil {
+				h.logger.Error("Failed to remove imported shard from Fabric", "key", shares.shardKey, "error", err)
+			}
+		}
+	}
+	h.logger.Warn("Import rolled back", "wallet", walletAddress, "items", len(written))
+}
+
+func (h *CredentialHandler) purgeImportedSecret(vaultPath string) {
+	if err := h.vault.PurgeSecret(vaultPath); err != nil {
+		h.logger.Error("Failed to remove imported secret from Vault", "path", vaultPath, "error", err)
+	}
+}
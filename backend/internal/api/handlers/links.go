@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+const (
+	defaultLinkTTL  = 24 * time.Hour
+	maxLinkTTL      = 7 * 24 * time.Hour
+	maxLinkMaxViews = 10
+)
+
+// LinkHandler serves the public side of share links
+type LinkHandler struct {
+	db     *database.Database
+	vault  *services.VaultService
+	audit  *auditTrail
+	logger *logger.Logger
+}
+
+func NewLinkHandler(db *database.Database, vault *services.VaultService, fabric *services.FabricClient, log *logger.Logger) *LinkHandler {
+	return &LinkHandler{
+		db:     db,
+		vault:  vault,
+		audit:  newAuditTrail(db, fabric, log),
+		logger: log,
+	}
+}
+
+// linkVaultPath is where share1 of a link payload lives
+func linkVaultPath(walletAddress, tokenHash string) string {
+	return "secret/data/passchain-links/" + walletAddress + "/" + tokenHash
+}
+
+// destroyLink removes a link's payload from Vault and the database
+func destroyLink(db *database.Database, vault *services.VaultService, link *models.ShareLink) error {
+	if err := vault.PurgeSecret(link.VaultPath); err != nil {
+		return err
+	}
+	return db.Model(link).Updates(map[string]interface{}{
+		"encrypted_data": "",
+		"nonce":          "",
+		"share2":         "",
+		"destroyed_at":   time.Now(),
+	}).Error
+}
+
+// CreateShareLink handles POST /api/v1/credentials/:id/links
+// The token is only ever returned here; the server keeps its hash.
+func (h *CredentialHandler) CreateShareLink(c *gin.Context) {
+	var req models.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot share credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	if credential.RequirePasskey {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Passkey-protected credentials cannot be shared"})
+		return
+	}
+	if credential.RequiresApproval() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Credentials that require approval cannot be shared"})
+		return
+	}
+
+	ttl := defaultLinkTTL
+	if req.ExpiresIn != 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if ttl <= 0 || ttl > maxLinkTTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresIn must be between 1 second and 7 days"})
+		return
+	}
+
+	maxViews := req.MaxViews
+	if maxViews == 0 {
+		maxViews = 1
+	}
+	if maxViews < 1 || maxViews > maxLinkMaxViews {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "maxViews must be between 1 and 10"})
+		return
+	}
+
+	token, tokenHash, err := auth.NewSessionToken()
+	if err != nil {
+		h.logger.Error("Failed to generate link token", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+
+	link := &models.ShareLink{
+		CredentialID:  credential.ID,
+		OwnerWallet:   credential.WalletAddress,
+		TokenHash:     tokenHash,
+		EncryptedData: req.EncryptedData,
+		Nonce:         req.Nonce,
+		VaultPath:     linkVaultPath(credential.WalletAddress, tokenHash),
+		Share2:        req.Share2,
+		MaxViews:      maxViews,
+		ExpiresAt:     time.Now().Add(ttl),
+	}
+
+	if err := h.vault.WriteSecret(link.VaultPath, map[string]interface{}{"share1": req.Share1}); err != nil {
+		h.logger.Error("Failed to store in Vault", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store encryption key"})
+		return
+	}
+
+	if err := h.db.Create(link).Error; err != nil {
+		h.logger.Error("Failed to save link", "error", err)
+		h.vault.PurgeSecret(link.VaultPath)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+
+	txID := h.audit.record(c, credential.WalletAddress, credential.ID, "link_create")
+
+	h.logger.Info("Share link created", "id", link.ID, "credential", credential.ID, "wallet", walletAddress)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":        link.ID,
+		"token":     token,
+		"expiresAt": link.ExpiresAt,
+		"maxViews":  link.MaxViews,
+		"txId":      txID,
+	})
+}
+
+// GetShareLinks handles GET /api/v1/credentials/:id/links
+func (h *CredentialHandler) GetShareLinks(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot share credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	var links []models.ShareLink
+	if err := h.db.Where("credential_id = ?", credential.ID).Order("created_at DESC").Find(&links).Error; err != nil {
+		h.logger.Error("Failed to fetch links", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink handles DELETE /api/v1/credentials/:id/links/:linkId
+func (h *CredentialHandler) RevokeShareLink(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot share credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	var link models.ShareLink
+	if err := h.db.Where("id = ? AND credential_id = ? AND destroyed_at IS NULL", c.Param("linkId"), credential.ID).First(&link).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+		return
+	}
+
+	if err := destroyLink(h.db, h.vault, &link); err != nil {
+		h.logger.Error("Failed to revoke link", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke link"})
+		return
+	}
+
+	h.audit.record(c, credential.WalletAddress, credential.ID, "link_revoke")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Link revoked successfully"})
+}
+
+// RedeemShareLink handles POST /api/v1/links/:token/redeem (public)
+//
+// Each redemption uses up one view; the payload is destroyed with the last
+// one. The view is claimed with a conditional update, so concurrent
+// redemptions cannot exceed maxViews.
+func (h *LinkHandler) RedeemShareLink(c *gin.Context) {
+	tokenHash := auth.HashToken(c.Param("token"))
+
+	// RETURNING loads the row as of this claim, including its view number
+	var link models.ShareLink
+	claim := h.db.Model(&link).Clauses(clause.Returning{}).
+		Where("token_hash = ? AND destroyed_at IS NULL AND views < max_views AND expires_at > ?", tokenHash, time.Now()).
+		Update("views", gorm.Expr("views + 1"))
+	if claim.Error != nil {
+		h.logger.Error("Failed to redeem link", "error", claim.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem link"})
+		return
+	}
+	if claim.RowsAffected == 0 {
+		// Unknown, used up, expired and revoked links look the same
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link is invalid or has expired"})
+		return
+	}
+
+	vaultData, err := h.vault.ReadSecret(link.VaultPath)
+	if err != nil {
+		h.logger.Error("Failed to read from Vault", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve encryption key"})
+		return
+	}
+	share1, ok := vaultData["share1"].(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid vault data"})
+		return
+	}
+
+	var credential models.Credential
+	h.db.Unscoped().Select("credential_name", "kind").Where("id = ?", link.CredentialID).First(&credential)
+
+	response := gin.H{
+		"name":          credential.CredentialName,
+		"kind":          credential.Kind,
+		"encryptedData": link.EncryptedData,
+		"nonce":         link.Nonce,
+		"share1":        share1,
+		"share2":        link.Share2,
+		"viewsLeft":     link.MaxViews - link.Views,
+		"expiresAt":     link.ExpiresAt,
+	}
+
+	if link.Views >= link.MaxViews {
+		if err := destroyLink(h.db, h.vault, &link); err != nil {
+			// The purger's next sweep of spent links retries it
+			h.logger.Error("Failed to destroy used-up link", "id", link.ID, "error", err)
+		}
+	}
+
+	h.audit.record(c, link.OwnerWallet, link.CredentialID, "link_redeem")
+
+	h.logger.Info("Share link redeemed", "id", link.ID, "credential", link.CredentialID, "views", link.Views)
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
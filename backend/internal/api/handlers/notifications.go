@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/pkg/logger"
+)
+
+type NotificationHandler struct {
+	db     *database.Database
+	logger *logger.Logger
+}
+
+func NewNotificationHandler(db *database.Database, log *logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		db:     db,
+		logger: log,
+	}
+}
+
+// GetNotifications handles GET /api/v1/notifications (?unread=true for unread only)
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	query := h.db.Where("wallet_address IN ?", linkedWallets(h.db, walletAddress))
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(100).Find(&notifications).Error; err != nil {
+		h.logger.Error("Failed to fetch notifications", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkNotificationRead handles POST /api/v1/notifications/:id/read
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	result := h.db.Model(&models.Notification{}).
+		Where("id = ? AND wallet_address IN ? AND read_at IS NULL", id, linkedWallets(h.db, walletAddress)).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		h.logger.Error("Failed to mark notification read", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification read"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
@@ -0,0 +1,554 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// maxMemberKeys bounds one SetMemberKeys request
+const maxMemberKeys = 500
+
+// OrgHandler manages organizations and their members. Organization
+// credentials themselves go through CredentialHandler.
+type OrgHandler struct {
+	db     *database.Database
+	logger *logger.Logger
+}
+
+func NewOrgHandler(db *database.Database, log *logger.Logger) *OrgHandler {
+	return &OrgHandler{
+		db:     db,
+		logger: log,
+	}
+}
+
+// orgRole is the highest role any of wallets holds in the organization, or
+// "" if none is a member
+func orgRole(db *database.Database, orgID string, wallets []string) string {
+	var roles []string
+	db.Model(&models.OrgMember{}).Where("org_id = ? AND wallet_address IN ?", orgID, wallets).Pluck("role", &roles)
+
+	best := ""
+	for _, role := range roles {
+		if best == "" || models.OrgRoleAtLeast(role, best) {
+			best = role
+		}
+	}
+	return best
+}
+
+// canManageRole reports whether a member with callerRole may grant role, or
+// change or remove a member who holds it
+func canManageRole(callerRole, role string) bool {
+	if callerRole == models.OrgRoleOwner {
+		return true
+	}
+	return callerRole == models.OrgRoleAdmin && !models.OrgRoleAtLeast(role, models.OrgRoleAdmin)
+}
+
+// notLastOwner keeps at least one owner: it matches a member unless they are
+// the organization's only owner. Used as a condition of the update or delete
+// itself, so concurrent demotions cannot both pass.
+func notLastOwner(db *gorm.DB, orgID string) *gorm.DB {
+	return db.Where("(role <> ? OR (?) > 1)", models.OrgRoleOwner,
+		db.Session(&gorm.Session{NewDB: true}).Model(&models.OrgMember{}).Select("count(*)").Where("org_id = ? AND role = ?", orgID, models.OrgRoleOwner))
+}
+
+// orgVaultPath is where share1 of an organization credential lives
+func orgVaultPath(orgID, key string) string {
+	return "secret/data/passchain-orgs/" + orgID + "/" + key
+}
+
+// newVaultPath is the share1 path of a credential about to be created with
+// id. Paths are keyed by ID so renames and reused names never touch another
+// credential's share1.
+func newVaultPath(req *models.CreateCredentialRequest, id string) string {
+	if req.OrgID != nil {
+		return orgVaultPath(*req.OrgID, id)
+	}
+	return credentialVaultPath(req.WalletAddress, id)
+}
+
+// memberRole loads the caller's role in the organization of the :id
+// parameter, answering 404 for non-members and 403 below min
+func (h *OrgHandler) memberRole(c *gin.Context, min string) (string, bool) {
+	role := orgRole(h.db, c.Param("id"), linkedWallets(h.db, middleware.WalletAddress(c)))
+	if role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return "", false
+	}
+	if !models.OrgRoleAtLeast(role, min) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in the organization does not allow this"})
+		return "", false
+	}
+	return role, true
+}
+
+// CreateOrganization handles POST /api/v1/orgs
+func (h *OrgHandler) CreateOrganization(c *gin.Context) {
+	var req models.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	org := &models.Organization{Name: req.Name, CreatedBy: walletAddress}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		owner := models.OrgMember{OrgID: org.ID, WalletAddress: walletAddress, Role: models.OrgRoleOwner, AddedBy: walletAddress}
+		if err := tx.Create(&owner).Error; err != nil {
+			return err
+		}
+		org.Members = []models.OrgMember{owner}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error("Failed to create organization", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		return
+	}
+
+	h.logger.Info("Organization created", "id", org.ID, "wallet", walletAddress)
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// orgView is an organization as listed to one of its members
+type orgView struct {
+	models.Organization
+	Role string `json:"role"`
+}
+
+// GetOrganizations handles GET /api/v1/orgs
+func (h *OrgHandler) GetOrganizations(c *gin.Context) {
+	wallets := linkedWallets(h.db, middleware.WalletAddress(c))
+
+	var orgs []models.Organization
+	if err := h.db.Where("id IN (?)", h.db.Model(&models.OrgMember{}).Select("org_id").Where("wallet_address IN ?", wallets)).
+		Order("name").Find(&orgs).Error; err != nil {
+		h.logger.Error("Failed to fetch organizations", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		return
+	}
+
+	views := make([]orgView, len(orgs))
+	for i, org := range orgs {
+		views[i] = orgView{Organization: org, Role: orgRole(h.db, org.ID, wallets)}
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// GetOrganization handles GET /api/v1/orgs/:id
+func (h *OrgHandler) GetOrganization(c *gin.Context) {
+	role, ok := h.memberRole(c, models.OrgRoleReadOnly)
+	if !ok {
+		return
+	}
+
+	var org models.Organization
+	if err := h.db.Preload("Members", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("id = ?", c.Param("id")).First(&org).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orgView{Organization: org, Role: role})
+}
+
+// AddMember handles POST /api/v1/orgs/:id/members
+// The new member can decrypt nothing until an admin wraps the organization's
+// credential keys to them (SetMemberKeys).
+func (h *OrgHandler) AddMember(c *gin.Context) {
+	var req models.AddOrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	role, ok := h.memberRole(c, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+	if !canManageRole(role, req.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can grant the admin and owner roles"})
+		return
+	}
+
+	wallet, err := auth.ChecksumAddress(req.WalletAddress)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+		return
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	member := &models.OrgMember{OrgID: c.Param("id"), WalletAddress: wallet, Role: req.Role, AddedBy: walletAddress}
+
+	result := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(member)
+	if result.Error != nil {
+		h.logger.Error("Failed to add member", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Wallet is already a member"})
+		return
+	}
+
+	h.logger.Info("Organization member added", "org", member.OrgID, "member", wallet, "role", req.Role, "wallet", walletAddress)
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// findMember loads the member of the :id organization named by :address
+func (h *OrgHandler) findMember(c *gin.Context) (*models.OrgMember, bool) {
+	var member models.OrgMember
+	address, err := auth.ChecksumAddress(c.Param("address"))
+	if err == nil {
+		err = h.db.Where("org_id = ? AND wallet_address = ?", c.Param("id"), address).First(&member).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return nil, false
+	}
+	return &member, true
+}
+
+// UpdateMember handles PATCH /api/v1/orgs/:id/members/:address
+// Every role may reveal the organization's credentials, so the member's
+// wrapped keys stay as they are.
+func (h *OrgHandler) UpdateMember(c *gin.Context) {
+	var req models.UpdateOrgMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	role, ok := h.memberRole(c, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+	member, ok := h.findMember(c)
+	if !ok {
+		return
+	}
+	if !canManageRole(role, member.Role) || !canManageRole(role, req.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can manage admins and owners"})
+		return
+	}
+
+	query := h.db.Model(&models.OrgMember{}).Where("org_id = ? AND wallet_address = ?", member.OrgID, member.WalletAddress)
+	if req.Role != models.OrgRoleOwner {
+		query = notLastOwner(query, member.OrgID)
+	}
+	result := query.Update("role", req.Role)
+	if result.Error != nil {
+		h.logger.Error("Failed to update member", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An organization needs at least one owner"})
+		return
+	}
+
+	h.logger.Info("Organization member updated", "org", member.OrgID, "member", member.WalletAddress, "role", req.Role, "wallet", middleware.WalletAddress(c))
+
+	member.Role = req.Role
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember handles DELETE /api/v1/orgs/:id/members/:address
+//
+// Any member may remove their own wallet to leave the organization. The
+// keys wrapped to the member are dropped with them; keys they already
+// unwrapped stay known to them until the credentials are updated.
+func (h *OrgHandler) RemoveMember(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	role, ok := h.memberRole(c, models.OrgRoleReadOnly)
+	if !ok {
+		return
+	}
+	member, ok := h.findMember(c)
+	if !ok {
+		return
+	}
+	if _, self := findWallet(linkedWallets(h.db, walletAddress), member.WalletAddress); !self && !canManageRole(role, member.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in the organization does not allow this"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := notLastOwner(tx.Where("org_id = ? AND wallet_address = ?", member.OrgID, member.WalletAddress), member.OrgID).
+			Delete(&models.OrgMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errLastOwner
+		}
+		return tx.Where("org_id = ? AND member_wallet = ?", member.OrgID, member.WalletAddress).Delete(&models.OrgMemberKey{}).Error
+	})
+	if errors.Is(err, errLastOwner) {
+		c.JSON(http.StatusConflict, gin.H{"error": "An organization needs at least one owner"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to remove member", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	h.logger.Info("Organization member removed", "org", member.OrgID, "member", member.WalletAddress, "wallet", walletAddress)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// errLastOwner aborts removing an organization's only owner
+var errLastOwner = errors.New("last owner")
+
+// SetMemberKeys handles PUT /api/v1/orgs/:id/members/:address/keys
+//
+// An admin's client rebuilds each organization credential's data key and
+// wraps it to the member's published encryption key. Keys already wrapped
+// to the member for a credential are replaced, which is how they are
+// re-wrapped after the credential is updated.
+func (h *OrgHandler) SetMemberKeys(c *gin.Context) {
+	var req models.OrgMemberKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if len(req.Keys) > maxMemberKeys {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many keys in one request", "max": maxMemberKeys})
+		return
+	}
+
+	if _, ok := h.memberRole(c, models.OrgRoleAdmin); !ok {
+		return
+	}
+	member, ok := h.findMember(c)
+	if !ok {
+		return
+	}
+
+	var walletKey models.WalletKey
+	if err := h.db.Where("wallet_address = ?", member.WalletAddress).First(&walletKey).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Member has not published an encryption key"})
+		return
+	}
+
+	ids := make([]string, len(req.Keys))
+	for i, key := range req.Keys {
+		ids[i] = key.CredentialID
+	}
+	var credentials []models.Credential
+	if err := h.db.Where("id IN ? AND org_id = ?", ids, member.OrgID).Find(&credentials).Error; err != nil {
+		h.logger.Error("Failed to look up credentials", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save member keys"})
+		return
+	}
+	byID := make(map[string]*models.Credential, len(credentials))
+	for i := range credentials {
+		byID[credentials[i].ID] = &credentials[i]
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+	keys := make([]models.OrgMemberKey, len(req.Keys))
+	for i, key := range req.Keys {
+		credential, ok := byID[key.CredentialID]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found", "credentialId": key.CredentialID})
+			return
+		}
+		if key.Version != credential.Version {
+			c.JSON(http.StatusConflict, gin.H{"error": "Key was wrapped for another version", "credentialId": key.CredentialID, "version": credential.Version})
+			return
+		}
+		if !services.ValidWrapped(key.WrappedKey) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "wrappedKey must be " + services.WrapAlgorithm + " encrypted data", "credentialId": key.CredentialID})
+			return
+		}
+		keys[i] = models.OrgMemberKey{
+			OrgID:        member.OrgID,
+			MemberWallet: member.WalletAddress,
+			CredentialID: credential.ID,
+			WrappedKey:   key.WrappedKey,
+			Version:      credential.Version,
+			WrappedBy:    walletAddress,
+		}
+	}
+
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "member_wallet"}, {Name: "credential_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"wrapped_key", "version", "wrapped_by", "updated_at"}),
+	}).Create(&keys).Error; err != nil {
+		h.logger.Error("Failed to save member keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save member keys"})
+		return
+	}
+
+	h.logger.Info("Organization member keys wrapped", "org", member.OrgID, "member", member.WalletAddress, "wallet", walletAddress, "count", len(keys))
+
+	c.JSON(http.StatusOK, gin.H{"wrapped": len(keys)})
+}
+
+// memberKeyView is a wrapped member key as listed to admins
+type memberKeyView struct {
+	models.OrgMemberKey
+	Stale bool `json:"stale"` // Wrapped for an older version; the member cannot read it
+}
+
+// GetMemberKeys handles GET /api/v1/orgs/:id/keys
+// Lists the keys wrapped to the organization's members, so an admin can
+// re-wrap the stale ones and wrap the missing ones.
+func (h *OrgHandler) GetMemberKeys(c *gin.Context) {
+	if _, ok := h.memberRole(c, models.OrgRoleAdmin); !ok {
+		return
+	}
+
+	var views []memberKeyView
+	if err := h.db.Model(&models.OrgMemberKey{}).
+		Select("org_member_keys.*, credentials.version <> org_member_keys.version AS stale").
+		Joins("JOIN credentials ON credentials.id = org_member_keys.credential_id AND credentials.deleted_at IS NULL").
+		Where("org_member_keys.org_id = ?", c.Param("id")).
+		Order("org_member_keys.member_wallet, org_member_keys.updated_at").
+		Scan(&views).Error; err != nil {
+		h.logger.Error("Failed to fetch member keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch member keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// orgAuditEntry is an audit entry as listed in an organization's audit view
+type orgAuditEntry struct {
+	models.AuditLog
+	CredentialName string `json:"credentialName"`
+}
+
+// GetOrgAuditLogs handles GET /api/v1/orgs/:id/audit-logs
+// Lists every member's actions on the organization's credentials, most
+// recent first; ?wallet= narrows it to one member's wallet (as actor or
+// approver).
+func (h *OrgHandler) GetOrgAuditLogs(c *gin.Context) {
+	if _, ok := h.memberRole(c, models.OrgRoleAdmin); !ok {
+		return
+	}
+
+	query := h.db.Where("org_id = ?", c.Param("id"))
+	if wallet := c.Query("wallet"); wallet != "" {
+		address, err := auth.ChecksumAddress(wallet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+			return
+		}
+		query = query.Where("(wallet_address = ? OR actor_wallet = ?)", address, address)
+	}
+
+	var auditLogs []models.AuditLog
+	if err := query.Order("timestamp DESC").Limit(100).Find(&auditLogs).Error; err != nil {
+		h.logger.Error("Failed to fetch audit logs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	// Names of purged credentials are gone; they stay "Unknown"
+	ids := make([]string, len(auditLogs))
+	for i, log := range auditLogs {
+		ids[i] = log.CredentialID
+	}
+	var credentials []models.Credential
+	h.db.Unscoped().Select("id", "credential_name").Where("id IN ?", nonEmpty(ids)).Find(&credentials)
+	names := make(map[string]string, len(credentials))
+	for _, credential := range credentials {
+		names[credential.ID] = credential.CredentialName
+	}
+
+	entries := make([]orgAuditEntry, len(auditLogs))
+	for i, log := range auditLogs {
+		name, ok := names[log.CredentialID]
+		if !ok {
+			name = "Unknown"
+		}
+		entries[i] = orgAuditEntry{AuditLog: log, CredentialName: name}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":  entries,
+		"count": len(entries),
+	})
+}
+
+// findOrgCredential loads an organization credential if walletAddress's
+// account holds at least role min in its organization. Returns an HTTP status
+// and error message on failure; non-members get 404.
+func (h *CredentialHandler) findOrgCredential(walletAddress, id, min string) (*models.Credential, int, string) {
+	var credential models.Credential
+	if err := h.db.Preload("Tags").Scopes(notExpired).Where("id = ? AND org_id IS NOT NULL", id).First(&credential).Error; err != nil {
+		return nil, http.StatusNotFound, "Credential not found"
+	}
+
+	role := orgRole(h.db, *credential.OrgID, linkedWallets(h.db, walletAddress))
+	if role == "" {
+		return nil, http.StatusNotFound, "Credential not found"
+	}
+	if !models.OrgRoleAtLeast(role, min) {
+		return nil, http.StatusForbidden, "Your role in the organization does not allow this"
+	}
+	return &credential, 0, ""
+}
+
+// orgMemberKey finds the key wrapped to the caller for revealing an
+// organization credential. The member who saved the credential holds
+// share3 and gets the shares instead (nil key). Returns an HTTP status and
+// error message when the caller has no current key.
+func (h *CredentialHandler) orgMemberKey(c *gin.Context, credential *models.Credential) (*models.OrgMemberKey, int, string) {
+	wallets := linkedWallets(h.db, middleware.WalletAddress(c))
+	if _, creator := findWallet(wallets, credential.WalletAddress); creator {
+		return nil, 0, ""
+	}
+
+	var key models.OrgMemberKey
+	if err := h.db.Where("credential_id = ? AND member_wallet IN ?", credential.ID, wallets).
+		Order("version DESC").First(&key).Error; err != nil {
+		return nil, http.StatusForbidden, "No key has been wrapped for you; ask an organization admin"
+	}
+	if key.Version != credential.Version {
+		return nil, http.StatusConflict, "Credential changed since your key was wrapped; ask an organization admin to wrap it again"
+	}
+	return &key, 0, ""
+}
+
+// findAuthorized loads a credential of the caller's own account or, for
+// wallet callers, of an organization where they hold at least role min
+func (h *CredentialHandler) findAuthorized(c *gin.Context, id, min string) (*models.Credential, int, string) {
+	walletAddress := middleware.WalletAddress(c)
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err == nil {
+		return credential, 0, ""
+	}
+
+	// API keys belong to a wallet, not to its organizations
+	if middleware.APIKey(c) != nil {
+		return nil, http.StatusNotFound, "Credential not found"
+	}
+	return h.findOrgCredential(walletAddress, id, min)
+}
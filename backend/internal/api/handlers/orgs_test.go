@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/models"
+)
+
+func TestOrgMemberKeys(t *testing.T) {
+	env := newTestEnv(t)
+	owner, member := newTestWallet(t), newTestWallet(t)
+	memberKey := env.publishKey(member)
+
+	var org struct {
+		ID string `json:"id"`
+	}
+	if status := env.do(owner, http.MethodPost, "/orgs", gin.H{"name": "acme"}, nil, &org); status != http.StatusCreated {
+		t.Fatalf("create org: status %d", status)
+	}
+	if status := env.do(owner, http.MethodPost, "/orgs/"+org.ID+"/members", gin.H{"walletAddress": member.address, "role": models.OrgRoleReadOnly}, nil, nil); status != http.StatusCreated {
+		t.Fatalf("add member: status %d", status)
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	status := env.do(owner, http.MethodPost, "/credentials", gin.H{
+		"name":          "deploy key",
+		"username":      "ci",
+		"encryptedData": "ZW5j",
+		"nonce":         "bm9uY2U=",
+		"share1":        "djE=",
+		"share2":        "czI=",
+		"walletAddress": owner.address,
+		"orgId":         org.ID,
+	}, nil, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("create credential: status %d", status)
+	}
+	id := created.ID
+	keysPath := "/orgs/" + org.ID + "/members/" + member.address + "/keys"
+
+	// The member holds no share3, so without a wrapped key there is nothing to reveal
+	if status, _ := env.reveal(member, id); status != http.StatusForbidden {
+		t.Fatalf("reveal without a key: status %d, want %d", status, http.StatusForbidden)
+	}
+	if status, body := env.reveal(owner, id); status != http.StatusOK || body["share1"] != "djE=" || body["wrappedKey"] != nil {
+		t.Fatalf("creator reveal: status %d, body %v", status, body)
+	}
+
+	wrapped := wrapKey(t, memberKey)
+	keys := gin.H{"keys": []gin.H{{"credentialId": id, "wrappedKey": wrapped, "version": 1}}}
+	if status := env.do(member, http.MethodPut, keysPath, keys, nil, nil); status != http.StatusForbidden {
+		t.Errorf("read-only member wraps keys: status %d, want %d", status, http.StatusForbidden)
+	}
+	if status := env.do(owner, http.MethodPut, keysPath, keys, nil, nil); status != http.StatusOK {
+		t.Fatalf("wrap keys: status %d", status)
+	}
+
+	status, body := env.reveal(member, id)
+	if status != http.StatusOK || body["wrappedKey"] != wrapped || body["wrappedFor"] != member.address {
+		t.Fatalf("member reveal: status %d, body %v", status, body)
+	}
+	if _, ok := body["share1"]; ok {
+		t.Error("member reveal returned share1")
+	}
+
+	// An update leaves the key wrapped for the old version until re-wrapped
+	if status, body := env.updateSecret(owner, id, "djI=", 1); status != http.StatusOK {
+		t.Fatalf("update: status %d, body %v", status, body)
+	}
+	if status, _ := env.reveal(member, id); status != http.StatusConflict {
+		t.Errorf("reveal with a stale key: status %d, want %d", status, http.StatusConflict)
+	}
+	var views []memberKeyView
+	if status := env.do(owner, http.MethodGet, "/orgs/"+org.ID+"/keys", nil, nil, &views); status != http.StatusOK || len(views) != 1 || !views[0].Stale {
+		t.Errorf("keys after update: status %d, %+v", status, views)
+	}
+	keys = gin.H{"keys": []gin.H{{"credentialId": id, "wrappedKey": wrapped, "version": 2}}}
+	if status := env.do(owner, http.MethodPut, keysPath, keys, nil, nil); status != http.StatusOK {
+		t.Fatalf("re-wrap keys: status %d", status)
+	}
+	if status, _ := env.reveal(member, id); status != http.StatusOK {
+		t.Errorf("reveal after re-wrap: status %d", status)
+	}
+
+	// Removing the member drops their keys
+	if status := env.do(owner, http.MethodDelete, "/orgs/"+org.ID+"/members/"+member.address, nil, nil, nil); status != http.StatusOK {
+		t.Fatalf("remove member: status %d", status)
+	}
+	var count int64
+	env.db.Model(&models.OrgMemberKey{}).Where("member_wallet = ?", member.address).Count(&count)
+	if count != 0 {
+		t.Errorf("%d keys left for the removed member", count)
+	}
+	if status, _ := env.reveal(member, id); status != http.StatusNotFound {
+		t.Errorf("reveal after removal: status %d, want %d", status, http.StatusNotFound)
+	}
+}
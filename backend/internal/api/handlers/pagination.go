@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// credentialSorts maps the ?sort= values to the column they order by.
+// lastAccessed sorts never-accessed credentials as the oldest.
+var credentialSorts = map[string]string{
+	"name":         "credential_name",
+	"createdAt":    "created_at",
+	"lastAccessed": "COALESCE(last_accessed, 'epoch'::timestamptz)",
+}
+
+// urlHostExpr extracts the lower-cased host of the url column in SQL, so the
+// domain filter also works for rows stored before it existed
+const urlHostExpr = `LOWER(substring(url from '^(?:[A-Za-z][A-Za-z0-9+.-]*://)?(?:[^@/]*@)?([^/:?#]+)'))`
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position after the last item of a page: the sort value
+// and the ID that breaks ties between equal values
+type pageCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// pageRequest is the parsed ?limit=&cursor=&sort=&order= of a list call
+type pageRequest struct {
+	Limit  int
+	Cursor *pageCursor
+	Column string // SQL sort expression
+	Sort   string // Sort name as given by the client
+	Desc   bool
+}
+
+// parsePageRequest reads paging and sorting parameters, defaulting to the
+// newest credentials first
+func parsePageRequest(c *gin.Context) (*pageRequest, error) {
+	req := &pageRequest{Limit: defaultPageSize, Sort: c.DefaultQuery("sort", "createdAt")}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return nil, errors.New("limit must be a positive number")
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+		req.Limit = limit
+	}
+
+	column, ok := credentialSorts[req.Sort]
+	if !ok {
+		return nil, errors.New("sort must be one of name, createdAt, lastAccessed")
+	}
+	req.Column = column
+
+	switch c.Query("order") {
+	case "":
+		req.Desc = req.Sort != "name"
+	case "asc":
+		req.Desc = false
+	case "desc":
+		req.Desc = true
+	default:
+		return nil, errors.New("order must be asc or desc")
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil {
+			return nil, err
+		}
+		req.Cursor = cursor
+	}
+
+	return req, nil
+}
+
+// apply adds keyset ordering, the cursor position and a one-item lookahead
+// (to know whether another page follows) to query
+func (p *pageRequest) apply(query *gorm.DB) *gorm.DB {
+	direction, compare := "ASC", ">"
+	if p.Desc {
+		direction, compare = "DESC", "<"
+	}
+
+	if p.Cursor != nil {
+		query = query.Where("("+p.Column+", id) "+compare+" (?, ?)", p.Cursor.Value, p.Cursor.ID)
+	}
+
+	return query.
+		Order(p.Column + " " + direction).
+		Order("id " + direction).
+		Limit(p.Limit + 1)
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
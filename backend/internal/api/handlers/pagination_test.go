@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []pageCursor{
+		{Value: "2024-05-01T10:00:00.123456Z", ID: "3f2b8c1e-0000-4000-8000-000000000001"},
+		{Value: "", ID: "3f2b8c1e-0000-4000-8000-000000000002"},
+		{Value: `name with "quotes" & unicode ✓`, ID: "3f2b8c1e-0000-4000-8000-000000000003"},
+	}
+
+	for _, want := range tests {
+		raw := encodeCursor(want)
+		got, err := decodeCursor(raw)
+		if err != nil {
+			t.Fatalf("decodeCursor(%q): %v", raw, err)
+		}
+		if *got != want {
+			t.Errorf("round trip = %+v, want %+v", *got, want)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"not base64", "***"},
+		{"not JSON", encode("hello")},
+		{"no ID", encode(`{"v":"a"}`)},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.raw); !errors.Is(err, errInvalidCursor) {
+				t.Errorf("err = %v, want %v", err, errInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// ceremonyTTL bounds how long a WebAuthn registration or assertion may take
+const ceremonyTTL = 5 * time.Minute
+
+// passkeyScopeRegister is the assertion scope that allows registering another
+// passkey; other assertions are scoped to a credential ID
+const passkeyScopeRegister = "register"
+
+type PasskeyHandler struct {
+	db       *database.Database
+	webauthn *webauthn.WebAuthn
+	nonces   services.NonceStore
+	tokenTTL time.Duration
+	logger   *logger.Logger
+}
+
+func NewPasskeyHandler(db *database.Database, wa *webauthn.WebAuthn, nonces services.NonceStore, tokenTTL time.Duration, log *logger.Logger) *PasskeyHandler {
+	return &PasskeyHandler{
+		db:       db,
+		webauthn: wa,
+		nonces:   nonces,
+		tokenTTL: tokenTTL,
+		logger:   log,
+	}
+}
+
+// passkeyUser adapts a wallet account to webauthn.User. Passkeys registered
+// by any linked wallet count for the whole account.
+type passkeyUser struct {
+	wallet   string
+	passkeys []models.Passkey
+}
+
+func (u *passkeyUser) WebAuthnID() []byte {
+	sum := sha256.Sum256([]byte(strings.ToLower(u.wallet)))
+	return sum[:]
+}
+
+func (u *passkeyUser) WebAuthnName() string        { return u.wallet }
+func (u *passkeyUser) WebAuthnDisplayName() string { return u.wallet }
+func (u *passkeyUser) WebAuthnIcon() string        { return "" }
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, passkey := range u.passkeys {
+		credentials = append(credentials, passkey.Key)
+	}
+	return credentials
+}
+
+// ceremony is what the server remembers between begin and finish
+type ceremony struct {
+	Wallet       string               `json:"wallet"`
+	CredentialID string               `json:"credentialId,omitempty"` // Assertions only: the credential ID or passkeyScopeRegister
+	Session      webauthn.SessionData `json:"session"`
+}
+
+func ceremonyKey(id string) string {
+	return "webauthn:" + id
+}
+
+// passkeyTokenKey namespaces passkey assertion tokens in the nonce store
+func passkeyTokenKey(token string) string {
+	return "passkey:" + token
+}
+
+func (h *PasskeyHandler) loadUser(walletAddress string) (*passkeyUser, error) {
+	user := &passkeyUser{wallet: walletAddress}
+	err := h.db.Where("wallet_address IN ?", linkedWallets(h.db, walletAddress)).Find(&user.passkeys).Error
+	return user, err
+}
+
+func (h *PasskeyHandler) saveCeremony(c *gin.Context, state ceremony) (string, error) {
+	id, err := auth.NewNonce()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	return id, h.nonces.Put(c.Request.Context(), ceremonyKey(id), string(data), ceremonyTTL)
+}
+
+// takeCeremony consumes the ceremony named by ?ceremony= if it belongs to the caller
+func (h *PasskeyHandler) takeCeremony(c *gin.Context, walletAddress string) (*ceremony, bool) {
+	data, ok, err := h.nonces.Take(c.Request.Context(), ceremonyKey(c.Query("ceremony")))
+	if err != nil {
+		h.logger.Error("Failed to read WebAuthn ceremony", "error", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var state ceremony
+	if err := json.Unmarshal([]byte(data), &state); err != nil || state.Wallet != walletAddress {
+		return nil, false
+	}
+	return &state, true
+}
+
+// BeginRegistration handles POST /api/v1/passkeys/register/begin
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	user, err := h.loadUser(walletAddress)
+	if err != nil {
+		h.logger.Error("Failed to fetch passkeys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	// Don't let the same authenticator register twice
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.passkeys))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, session, err := h.webauthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		h.logger.Error("Failed to begin passkey registration", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	id, err := h.saveCeremony(c, ceremony{Wallet: walletAddress, Session: *session})
+	if err != nil {
+		h.logger.Error("Failed to store WebAuthn ceremony", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ceremony": id,
+		"options":  options,
+	})
+}
+
+// FinishRegistration handles POST /api/v1/passkeys/register/finish?ceremony=<id>&name=<label>
+//
+// The body is the PublicKeyCredential returned by navigator.credentials.create().
+// Once the account has a passkey, adding another also needs an X-Passkey-Token
+// from an assertion started with {"register": true}, so a stolen wallet
+// signature alone cannot enroll an attacker's authenticator.
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot register passkeys"})
+		return
+	}
+
+	user, err := h.loadUser(walletAddress)
+	if err != nil {
+		h.logger.Error("Failed to fetch passkeys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register passkey"})
+		return
+	}
+
+	if len(user.passkeys) > 0 {
+		if status, errMsg := takePasskeyToken(c, h.nonces, h.logger, passkeyScopeRegister); errMsg != "" {
+			c.JSON(status, gin.H{"error": errMsg})
+			return
+		}
+	}
+
+	state, ok := h.takeCeremony(c, walletAddress)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired registration ceremony"})
+		return
+	}
+
+	credential, err := h.webauthn.FinishRegistration(user, state.Session, c.Request)
+	if err != nil {
+		h.logger.Warn("Passkey registration rejected", "wallet", walletAddress, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid passkey registration"})
+		return
+	}
+
+	name := c.Query("name")
+	if name == "" {
+		name = "Passkey"
+	}
+
+	passkey := &models.Passkey{
+		WalletAddress: walletAddress,
+		Name:          name,
+		CredentialID:  base64.RawURLEncoding.EncodeToString(credential.ID),
+		Key:           *credential,
+	}
+	if err := h.db.Create(passkey).Error; err != nil {
+		h.logger.Error("Failed to save passkey", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register passkey"})
+		return
+	}
+
+	h.logger.Info("Passkey registered", "id", passkey.ID, "wallet", walletAddress)
+
+	c.JSON(http.StatusCreated, passkey)
+}
+
+// GetPasskeys handles GET /api/v1/passkeys
+func (h *PasskeyHandler) GetPasskeys(c *gin.Context) {
+	user, err := h.loadUser(middleware.WalletAddress(c))
+	if err != nil {
+		h.logger.Error("Failed to fetch passkeys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch passkeys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.passkeys)
+}
+
+// DeletePasskey handles DELETE /api/v1/passkeys/:id
+func (h *PasskeyHandler) DeletePasskey(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	wallets := linkedWallets(h.db, walletAddress)
+
+	// Removing the last passkey would lock the account out of its protected credentials
+	var remaining, protected int64
+	h.db.Model(&models.Passkey{}).Where("id <> ? AND wallet_address IN ?", id, wallets).Count(&remaining)
+	h.db.Model(&models.Credential{}).Where("require_passkey AND wallet_address IN ?", wallets).Count(&protected)
+	if remaining == 0 && protected > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete the last passkey while credentials require one"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND wallet_address IN ?", id, wallets).Delete(&models.Passkey{})
+	if result.Error != nil {
+		h.logger.Error("Failed to delete passkey", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete passkey"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+		return
+	}
+
+	h.logger.Info("Passkey deleted", "id", id, "wallet", walletAddress)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey deleted successfully"})
+}
+
+// BeginAssertion handles POST /api/v1/passkeys/assert/begin
+// The assertion is made for one credential that requires a passkey
+// ({"credentialId": ...}) or for registering another passkey ({"register": true}).
+func (h *PasskeyHandler) BeginAssertion(c *gin.Context) {
+	var req struct {
+		CredentialID string `json:"credentialId"`
+		Register     bool   `json:"register"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if (req.CredentialID == "") == !req.Register {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give either credentialId or register"})
+		return
+	}
+	scope := req.CredentialID
+	if req.Register {
+		scope = passkeyScopeRegister
+	}
+
+	walletAddress := middleware.WalletAddress(c)
+
+	user, err := h.loadUser(walletAddress)
+	if err != nil {
+		h.logger.Error("Failed to fetch passkeys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey assertion"})
+		return
+	}
+	if len(user.passkeys) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No passkey registered"})
+		return
+	}
+
+	options, session, err := h.webauthn.BeginLogin(user)
+	if err != nil {
+		h.logger.Error("Failed to begin passkey assertion", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey assertion"})
+		return
+	}
+
+	id, err := h.saveCeremony(c, ceremony{Wallet: walletAddress, CredentialID: scope, Session: *session})
+	if err != nil {
+		h.logger.Error("Failed to store WebAuthn ceremony", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey assertion"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ceremony": id,
+		"options":  options,
+	})
+}
+
+// FinishAssertion handles POST /api/v1/passkeys/assert/finish?ceremony=<id>
+//
+// The body is the PublicKeyCredential returned by navigator.credentials.get().
+// On success it returns a single-use token to send as X-Passkey-Token with the
+// reveal or delete of the credential the assertion was started for, or with
+// the registration of another passkey.
+func (h *PasskeyHandler) FinishAssertion(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	state, ok := h.takeCeremony(c, walletAddress)
+	if !ok || state.CredentialID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired assertion ceremony"})
+		return
+	}
+
+	user, err := h.loadUser(walletAddress)
+	if err != nil {
+		h.logger.Error("Failed to fetch passkeys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify passkey"})
+		return
+	}
+
+	credential, err := h.webauthn.FinishLogin(user, state.Session, c.Request)
+	if err != nil {
+		h.logger.Warn("Passkey assertion rejected", "wallet", walletAddress, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid passkey assertion"})
+		return
+	}
+	if credential.Authenticator.CloneWarning {
+		h.logger.Warn("Passkey sign count went backwards", "wallet", walletAddress)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Passkey may have been cloned"})
+		return
+	}
+
+	// Keep the sign count current for clone detection
+	now := time.Now()
+	var passkey models.Passkey
+	if err := h.db.Where("credential_id = ?", base64.RawURLEncoding.EncodeToString(credential.ID)).First(&passkey).Error; err == nil {
+		passkey.Key = *credential
+		passkey.LastUsedAt = &now
+		h.db.Save(&passkey)
+	}
+
+	token, err := auth.NewNonce()
+	if err != nil {
+		h.logger.Error("Failed to generate passkey token", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify passkey"})
+		return
+	}
+
+	binding := strings.ToLower(walletAddress) + "|" + state.CredentialID
+	if err := h.nonces.Put(c.Request.Context(), passkeyTokenKey(token), binding, h.tokenTTL); err != nil {
+		h.logger.Error("Failed to store passkey token", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify passkey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"expiresAt": now.Add(h.tokenTTL),
+	})
+}
+
+// verifyPasskey enforces Credential.RequirePasskey: the request must carry an
+// X-Passkey-Token from an assertion started for this credential. Returns a
+// status and message when the request must be rejected, or an empty message.
+func (h *CredentialHandler) verifyPasskey(c *gin.Context, credential *models.Credential) (int, string) {
+	if !credential.RequirePasskey {
+		return 0, ""
+	}
+
+	// Service accounts have no authenticator to satisfy the requirement
+	if middleware.APIKey(c) != nil {
+		return http.StatusForbidden, "Credential requires a passkey; API keys cannot access it"
+	}
+
+	return takePasskeyToken(c, h.nonces, h.logger, credential.ID)
+}
+
+// takePasskeyToken consumes the request's X-Passkey-Token, which must come from
+// an assertion the caller made for scope. Returns a status and message when
+// the request must be rejected, or an empty message.
+func takePasskeyToken(c *gin.Context, nonces services.NonceStore, log *logger.Logger, scope string) (int, string) {
+	token := c.GetHeader("X-Passkey-Token")
+	if token == "" {
+		return http.StatusUnauthorized, "Passkey assertion required"
+	}
+
+	binding, ok, err := nonces.Take(c.Request.Context(), passkeyTokenKey(token))
+	if err != nil {
+		log.Error("Failed to read passkey token", "error", err)
+		return http.StatusServiceUnavailable, "Failed to verify passkey"
+	}
+	if !ok || binding != strings.ToLower(middleware.WalletAddress(c))+"|"+scope {
+		return http.StatusUnauthorized, "Unknown or expired passkey token"
+	}
+
+	return 0, ""
+}
+
+// hasPasskey reports whether any wallet on walletAddress's account has registered a passkey
+func hasPasskey(db *database.Database, walletAddress string) bool {
+	var count int64
+	db.Model(&models.Passkey{}).Where("wallet_address IN ?", linkedWallets(db, walletAddress)).Count(&count)
+	return count > 0
+}
+
+// SetRequirePasskey handles PUT /api/v1/credentials/:id/require-passkey
+// Turning the requirement off needs a passkey assertion like any other protected access.
+func (h *CredentialHandler) SetRequirePasskey(c *gin.Context) {
+	var req models.SetRequirePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot change passkey requirements"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	if *req.Required && !hasPasskey(h.db, walletAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Register a passkey first"})
+		return
+	}
+	if status, errMsg := h.verifyPasskey(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if err := h.db.Model(credential).Update("require_passkey", *req.Required).Error; err != nil {
+		h.logger.Error("Failed to update credential", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update credential"})
+		return
+	}
+
+	h.logger.Info("Passkey requirement changed", "id", id, "wallet", walletAddress, "required", *req.Required)
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":             credential.ID,
+		"requirePasskey": *req.Required,
+	})
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+)
+
+var (
+	errShareMissing  = errors.New("share missing")
+	errShareMismatch = errors.New("share copies differ")
+)
+
+// validShareHash reports whether s is a hex SHA-256 digest
+func validShareHash(s string) bool {
+	digest, err := hex.DecodeString(s)
+	return err == nil && len(digest) == sha256.Size
+}
+
+// matchesShareHash reports whether share hashes (as text) to the commitment
+func matchesShareHash(share, commitment string) bool {
+	want, err := hex.DecodeString(commitment)
+	if err != nil {
+		return false
+	}
+	got := sha256.Sum256([]byte(share))
+	return subtle.ConstantTimeCompare(got[:], want) == 1
+}
+
+// shareLost reports whether a share read failed because the custodian no
+// longer has the share, as opposed to not answering
+func shareLost(err error) bool {
+	return errors.Is(err, errShareMissing) || errors.Is(err, services.ErrSecretNotFound) || errors.Is(err, services.ErrShardNotFound)
+}
+
+// RecoverCredential handles POST /api/v1/credentials/:id/recover
+//
+// For when Vault lost share1 or Fabric lost share2 of the current version:
+// the custodian must answer that the share is gone, not just fail to answer.
+// The caller's share3 must match the commitment recorded with that version;
+// share3 is only hashed, never stored. The surviving share is returned so the
+// client can rebuild the key and re-split it with a PUT, which gives both
+// custodians fresh shares.
+func (h *CredentialHandler) RecoverCredential(c *gin.Context) {
+	var req models.RecoverCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	// Re-splitting is an update, so it needs the same role
+	credential, status, errMsg := h.findAuthorized(c, id, models.OrgRoleMember)
+	if errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot recover credentials"})
+		return
+	}
+
+	if status, errMsg := h.verifyPasskey(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if credential.Share3Hash == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "No share3 commitment was recorded for this version; it cannot be recovered"})
+		return
+	}
+	if !matchesShareHash(req.Share3, credential.Share3Hash) {
+		h.audit.recordCredential(c, walletAddress, credential, "recover_denied")
+		c.JSON(http.StatusForbidden, gin.H{"error": "share3 does not match this version of the credential"})
+		return
+	}
+
+	// The surviving share is as good as a reveal
+	approval, ok := h.checkApproval(c, credential)
+	if !ok {
+		return
+	}
+
+	share1, share1Err := h.readShare1(credential)
+	share2, share2Err := h.readShare2(credential)
+	if errors.Is(share2Err, errShareMismatch) {
+		h.logger.Error("Share2 on Fabric does not match the database", "id", id, "version", credential.Version)
+		c.JSON(http.StatusConflict, gin.H{"error": "Share2 on the ledger does not match the database"})
+		return
+	}
+
+	// An unreachable custodian is not a lost share
+	if (share1Err != nil && !shareLost(share1Err)) || (share2Err != nil && !shareLost(share2Err)) {
+		h.logger.Error("Failed to read shares for recovery", "id", id, "share1", share1Err, "share2", share2Err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Could not reach Vault or Fabric to check the shares; try again"})
+		return
+	}
+
+	response := gin.H{
+		"id":            credential.ID,
+		"version":       credential.Version,
+		"encryptedData": credential.EncryptedData,
+		"nonce":         credential.Nonce,
+		"metadata":      credential.Metadata,
+		"refreshDeltas": pendingDeltas(h.db, credential), // share3 must take these first
+	}
+	switch {
+	case share1Err == nil && share2Err == nil:
+		c.JSON(http.StatusConflict, gin.H{"error": "Both shares are intact; nothing to recover"})
+		return
+	case share1Err != nil && share2Err != nil:
+		h.logger.Error("Both shares lost", "id", id, "share1", share1Err, "share2", share2Err)
+		c.JSON(http.StatusConflict, gin.H{"error": "Both share1 and share2 are lost; share3 alone cannot recover the credential"})
+		return
+	case share1Err != nil:
+		h.logger.Warn("Share1 lost from Vault", "id", id, "error", share1Err)
+		response["lost"] = "share1"
+		response["share2"] = share2
+	default:
+		h.logger.Warn("Share2 lost from Fabric", "id", id, "error", share2Err)
+		response["lost"] = "share2"
+		response["share1"] = share1
+	}
+
+	if !h.releaseApproval(c, credential, approval) {
+		return
+	}
+
+	h.audit.recordCredential(c, walletAddress, credential, "recover")
+
+	h.logger.Info("Credential recovery verified", "id", id, "wallet", walletAddress, "lost", response["lost"], "version", credential.Version)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// readShare2 reads the current share2 from Fabric, checked against the
+// database copy. Without Fabric the database copy is the custodian.
+func (h *CredentialHandler) readShare2(credential *models.Credential) (string, error) {
+	if h.fabric == nil {
+		if credential.Share2 == "" {
+			return "", errShareMissing
+		}
+		return credential.Share2, nil
+	}
+
+	// Credentials moved by wallet migrations before it kept history have
+	// their current share2 under the last segment of the Vault path
+	keys := []string{versionShardKey(credential, credential.Version)}
+	if credential.Version > 1 {
+		keys = append(keys, path.Base(credential.VaultPath))
+	}
+
+	err := errShareMissing
+	for i, key := range keys {
+		onChain, readErr := h.fabric.ReadShard(credential.WalletAddress, key)
+		if readErr != nil {
+			err = readErr
+			continue
+		}
+		if onChain == credential.Share2 {
+			return onChain, nil
+		}
+		// The fallback key may hold the share of an earlier version
+		if i == 0 {
+			return "", errShareMismatch
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/worker"
+	"pass-chain/backend/pkg/logger"
+)
+
+var errDeltasChanged = errors.New("pending refresh deltas changed")
+
+// RefreshHandler re-shares credentials on request; the same refresh also runs
+// on a schedule (see worker.ShareRefresher)
+type RefreshHandler struct {
+	credentials *CredentialHandler
+	refresher   *worker.ShareRefresher
+	logger      *logger.Logger
+}
+
+func NewRefreshHandler(credentials *CredentialHandler, refresher *worker.ShareRefresher, log *logger.Logger) *RefreshHandler {
+	return &RefreshHandler{
+		credentials: credentials,
+		refresher:   refresher,
+		logger:      log,
+	}
+}
+
+// RefreshShares handles POST /api/v1/credentials/:id/refresh
+//
+// Gives Vault and Fabric new shares of the same key without a new version;
+// encryptedData is untouched and the replaced shares are destroyed. The body
+// may be empty, see models.RefreshSharesRequest; for a credential split for
+// recovery the response then carries the RefreshDelta to apply to share3.
+func (h *RefreshHandler) RefreshShares(c *gin.Context) {
+	var req models.RefreshSharesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	if req.Fresh() && !req.Complete() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "share1, share2 and share3Hash must be refreshed together"})
+		return
+	}
+	if req.Fresh() && !validShareHash(req.Share3Hash) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "share3Hash must be a hex SHA-256 digest"})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	credential, status, errMsg := h.credentials.findAuthorized(c, id, models.OrgRoleMember)
+	if errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if !h.credentials.keyAllows(c, credential) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not cover this credential"})
+		return
+	}
+
+	var fresh *worker.FreshShares
+	if req.Fresh() {
+		// Replacing the shares is as sensitive as updating the secret
+		if status, errMsg := h.credentials.verifyPasskey(c, credential); errMsg != "" {
+			c.JSON(status, gin.H{"error": errMsg})
+			return
+		}
+		// A fresh split comes with its commitment, so it is a recovery split
+		fresh = &worker.FreshShares{
+			Share1:      req.Share1,
+			Share2:      req.Share2,
+			Share3Hash:  req.Share3Hash,
+			SplitScheme: models.SplitRecovery,
+		}
+	}
+
+	txID, err := h.refresher.Refresh(c.Request.Context(), credential, fresh)
+	if errors.Is(err, worker.ErrNoWalletKey) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Credential is split for recovery; publish an encryption key or send a fresh split of its key (share1, share2, share3Hash)"})
+		return
+	}
+	if errors.Is(err, worker.ErrUnknownSplit) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Credential's split scheme is unknown; send a fresh split of its key (share1, share2, share3Hash)"})
+		return
+	}
+	if errors.Is(err, worker.ErrRefreshInProgress) || errors.Is(err, worker.ErrRefreshConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Credential is being changed; try again"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to refresh shares", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh shares"})
+		return
+	}
+
+	h.credentials.audit.recordCredential(c, walletAddress, credential, "refresh")
+
+	h.logger.Info("Credential shares refreshed", "id", id, "wallet", walletAddress, "fresh", fresh != nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                credential.ID,
+		"version":           credential.Version,
+		"sharesRefreshedAt": credential.SharesRefreshedAt,
+		"txId":              txID,
+		"refreshDeltas":     pendingDeltas(h.credentials.db, credential),
+		"message":           "Shares refreshed successfully",
+	})
+}
+
+// ApplyRefresh handles POST /api/v1/credentials/:id/refresh/apply
+//
+// After the owner XORed the pending RefreshDeltas into share3, records the
+// commitment to the result and drops the deltas.
+func (h *RefreshHandler) ApplyRefresh(c *gin.Context) {
+	var req models.ApplyRefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if !validShareHash(req.Share3Hash) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "share3Hash must be a hex SHA-256 digest"})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	ch := h.credentials
+	credential, status, errMsg := ch.findAuthorized(c, id, models.OrgRoleMember)
+	if errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if !ch.keyAllows(c, credential) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not cover this credential"})
+		return
+	}
+
+	// Replacing the commitment is as sensitive as a fresh split
+	if status, errMsg := ch.verifyPasskey(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	err := ch.db.Transaction(func(tx *gorm.DB) error {
+		// Updating the row first holds off a refresh until the deltas are
+		// compared; one that got in before fails the share2 guard
+		result := tx.Model(&models.Credential{}).
+			Where("id = ? AND version = ? AND share2 = ? AND share3_hash = ?", credential.ID, credential.Version, credential.Share2, credential.Share3Hash).
+			Update("share3_hash", req.Share3Hash)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+
+		var pending []string
+		if err := tx.Model(&models.RefreshDelta{}).
+			Where("credential_id = ? AND version = ?", credential.ID, credential.Version).
+			Pluck("id", &pending).Error; err != nil {
+			return err
+		}
+		if len(pending) == 0 || !sameIDs(pending, req.DeltaIDs) {
+			return errDeltasChanged
+		}
+
+		if err := tx.Where("credential_id = ? AND version = ?", credential.ID, credential.Version).Delete(&models.RefreshDelta{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.CredentialVersion{}).
+			Where("credential_id = ? AND version = ?", credential.ID, credential.Version).
+			Update("share3_hash", req.Share3Hash).Error
+	})
+	if errors.Is(err, errVersionConflict) || errors.Is(err, errDeltasChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Shares changed since the deltas were read; read the credential again"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to apply refresh deltas", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply refresh deltas"})
+		return
+	}
+
+	ch.audit.recordCredential(c, walletAddress, credential, "refresh_apply")
+
+	h.logger.Info("Refresh deltas applied", "id", id, "wallet", walletAddress, "count", len(req.DeltaIDs))
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         credential.ID,
+		"version":    credential.Version,
+		"share3Hash": req.Share3Hash,
+		"message":    "Refresh deltas applied successfully",
+	})
+}
+
+// deltaView is a pending RefreshDelta as handed to the owner
+type deltaView struct {
+	ID           string `json:"id"`
+	WrappedDelta string `json:"wrappedDelta"`
+}
+
+// pendingDeltas lists the RefreshDeltas the share3 of the credential's
+// current version has yet to take; nil when there are none
+func pendingDeltas(db *database.Database, credential *models.Credential) []deltaView {
+	if credential.Share3Hash == "" {
+		return nil
+	}
+	var deltas []deltaView
+	db.Model(&models.RefreshDelta{}).
+		Where("credential_id = ? AND version = ?", credential.ID, credential.Version).
+		Order("created_at").
+		Find(&deltas)
+	return deltas
+}
+
+// sameIDs reports whether a and b hold the same IDs
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+)
+
+// revealNonceKey namespaces step-up nonces in the nonce store
+func revealNonceKey(nonce string) string {
+	return "reveal:" + nonce
+}
+
+// CreateRevealChallenge handles POST /api/v1/credentials/:id/reveal-challenge
+//
+// Revealing shares needs a fresh signature over auth.RevealMessage, on top of
+// the normal session or request authentication. The client signs the returned
+// message and sends X-Reveal-Nonce and X-Reveal-Signature with GET /credentials/:id.
+func (h *CredentialHandler) CreateRevealChallenge(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "API keys do not use reveal challenges"})
+		return
+	}
+
+	credential, status, errMsg := h.findAuthorized(c, id, models.OrgRoleReadOnly)
+	if errMsg != "" {
+		// Share recipients reveal through the same challenge
+		shared, _, err := h.findShared(walletAddress, id)
+		if err != nil {
+			c.JSON(status, gin.H{"error": errMsg})
+			return
+		}
+		credential = shared
+	}
+
+	nonce, err := auth.NewNonce()
+	if err != nil {
+		h.logger.Error("Failed to generate nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reveal challenge"})
+		return
+	}
+
+	expiresAt := time.Now().Add(h.revealTTL).Truncate(time.Second)
+
+	// Remember who the nonce was issued to, for which credential, until when
+	binding := strings.Join([]string{strings.ToLower(walletAddress), credential.ID, strconv.FormatInt(expiresAt.Unix(), 10)}, "|")
+	if err := h.nonces.Put(c.Request.Context(), revealNonceKey(nonce), binding, h.revealTTL); err != nil {
+		h.logger.Error("Failed to store reveal nonce", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reveal challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"nonce":     nonce,
+		"message":   auth.RevealMessage(walletAddress, credential.ID, nonce, expiresAt),
+		"expiresAt": expiresAt,
+	})
+}
+
+// verifyReveal checks the step-up signature for revealing credential.
+// Service-account keys are exempt: they cannot sign and are already scoped.
+// Returns an HTTP status and error message on failure.
+func (h *CredentialHandler) verifyReveal(c *gin.Context, credential *models.Credential) (int, string) {
+	if middleware.APIKey(c) != nil {
+		return 0, ""
+	}
+
+	nonce := c.GetHeader("X-Reveal-Nonce")
+	signature := c.GetHeader("X-Reveal-Signature")
+	if nonce == "" || signature == "" {
+		return http.StatusUnauthorized, "Reveal requires a fresh signature (see reveal-challenge)"
+	}
+
+	// Single use: the nonce is gone whether or not the signature checks out
+	binding, ok, err := h.nonces.Take(c.Request.Context(), revealNonceKey(nonce))
+	if err != nil {
+		h.logger.Error("Failed to read reveal nonce", "error", err)
+		return http.StatusServiceUnavailable, "Failed to verify reveal challenge"
+	}
+	if !ok {
+		return http.StatusUnauthorized, "Unknown or expired reveal challenge"
+	}
+
+	parts := strings.Split(binding, "|")
+	walletAddress := middleware.WalletAddress(c)
+	if len(parts) != 3 || parts[0] != strings.ToLower(walletAddress) || parts[1] != credential.ID {
+		return http.StatusForbidden, "Reveal challenge was issued for another credential"
+	}
+
+	expiresUnix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return http.StatusUnauthorized, "Unknown or expired reveal challenge"
+	}
+	expiresAt := time.Unix(expiresUnix, 0)
+	if time.Now().After(expiresAt) {
+		return http.StatusUnauthorized, "Unknown or expired reveal challenge"
+	}
+
+	message := auth.RevealMessage(walletAddress, credential.ID, nonce, expiresAt)
+	if _, err := auth.VerifySignature([]byte(message), signature, walletAddress); err != nil {
+		return http.StatusUnauthorized, "Invalid reveal signature"
+	}
+
+	return 0, ""
+}
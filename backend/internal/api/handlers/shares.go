@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// defaultKeyAlgorithm is what MetaMask's eth_getEncryptionPublicKey keys use
+const defaultKeyAlgorithm = services.WrapAlgorithm
+
+type ShareHandler struct {
+	db     *database.Database
+	logger *logger.Logger
+}
+
+func NewShareHandler(db *database.Database, log *logger.Logger) *ShareHandler {
+	return &ShareHandler{
+		db:     db,
+		logger: log,
+	}
+}
+
+// sharedWith is a subquery selecting the IDs of credentials actively shared
+// with any of wallets
+func sharedWith(db *database.Database, wallets []string) *gorm.DB {
+	return db.Model(&models.CredentialShare{}).
+		Select("credential_id").
+		Where("recipient_wallet IN ? AND revoked_at IS NULL", wallets)
+}
+
+// activeShare loads the unrevoked share of credential id with any of wallets
+func activeShare(db *database.Database, wallets []string, id string) (*models.CredentialShare, error) {
+	var share models.CredentialShare
+	err := db.Where("credential_id = ? AND recipient_wallet IN ? AND revoked_at IS NULL", id, wallets).First(&share).Error
+	return &share, err
+}
+
+// PublishKey handles PUT /api/v1/sharing/key
+// The caller's wallet publishes the public key others wrap credential keys to.
+func (h *ShareHandler) PublishKey(c *gin.Context) {
+	var req models.PublishWalletKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if req.Algorithm == "" {
+		req.Algorithm = defaultKeyAlgorithm
+	}
+
+	key := &models.WalletKey{
+		WalletAddress: middleware.WalletAddress(c),
+		PublicKey:     req.PublicKey,
+		Algorithm:     req.Algorithm,
+	}
+	if err := h.db.Save(key).Error; err != nil {
+		h.logger.Error("Failed to publish wallet key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// GetWalletKey handles GET /api/v1/sharing/keys/:address
+func (h *ShareHandler) GetWalletKey(c *gin.Context) {
+	address, err := auth.ChecksumAddress(c.Param("address"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet address"})
+		return
+	}
+
+	var key models.WalletKey
+	if err := h.db.Where("wallet_address = ?", address).First(&key).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet has not published an encryption key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// ShareCredential handles POST /api/v1/credentials/:id/shares
+//
+// The owner's client rebuilds the data key from its three shares and wraps it
+// to the recipient's published key. Sharing again with the same recipient
+// replaces the wrapped key, which is how the owner refreshes a share after
+// updating the credential.
+func (h *CredentialHandler) ShareCredential(c *gin.Context) {
+	var req models.ShareCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot share credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	// The recipient has no way to satisfy the owner's passkey
+	if credential.RequirePasskey {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Passkey-protected credentials cannot be shared"})
+		return
+	}
+
+	// A wrapped key would bypass the approvers
+	if credential.RequiresApproval() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Credentials that require approval cannot be shared"})
+		return
+	}
+
+	if req.Version != credential.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": "Key was wrapped for another version", "version": credential.Version})
+		return
+	}
+
+	if !services.ValidWrapped(req.WrappedKey) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "wrappedKey must be " + services.WrapAlgorithm + " encrypted data"})
+		return
+	}
+
+	recipient, err := auth.ChecksumAddress(req.RecipientWallet)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipient wallet"})
+		return
+	}
+	if _, own := findWallet(linkedWallets(h.db, walletAddress), recipient); own {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipient is linked to your own account"})
+		return
+	}
+
+	var key models.WalletKey
+	if err := h.db.Where("wallet_address = ?", recipient).First(&key).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Recipient has not published an encryption key"})
+		return
+	}
+
+	share, err := activeShare(h.db, []string{recipient}, credential.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		share = &models.CredentialShare{
+			CredentialID:    credential.ID,
+			OwnerWallet:     credential.WalletAddress,
+			RecipientWallet: recipient,
+		}
+	} else if err != nil {
+		h.logger.Error("Failed to look up share", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share credential"})
+		return
+	}
+	share.WrappedKey = req.WrappedKey
+	share.Version = credential.Version
+
+	if err := h.db.Save(share).Error; err != nil {
+		h.logger.Error("Failed to save share", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share credential"})
+		return
+	}
+
+	h.audit.recordActor(c, credential.WalletAddress, recipient, credential.ID, "share")
+
+	h.logger.Info("Credential shared", "id", credential.ID, "wallet", walletAddress, "recipient", recipient)
+
+	c.JSON(http.StatusOK, share)
+}
+
+// shareView is a share as listed to the owner
+type shareView struct {
+	models.CredentialShare
+	Stale bool `json:"stale"` // Wrapped for an older version; the recipient cannot read it
+}
+
+// GetCredentialShares handles GET /api/v1/credentials/:id/shares
+func (h *CredentialHandler) GetCredentialShares(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot share credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	var shares []models.CredentialShare
+	if err := h.db.Where("credential_id = ? AND revoked_at IS NULL", credential.ID).Order("created_at").Find(&shares).Error; err != nil {
+		h.logger.Error("Failed to fetch shares", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shares"})
+		return
+	}
+
+	views := make([]shareView, len(shares))
+	for i, share := range shares {
+		views[i] = shareView{CredentialShare: share, Stale: share.Version != credential.Version}
+	}
+
+	c.JSON(http.StatusOK, views)
+}
+
+// RevokeShare handles DELETE /api/v1/credentials/:id/shares/:shareId
+func (h *CredentialHandler) RevokeShare(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot share credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	var share models.CredentialShare
+	if err := h.db.Where("id = ? AND credential_id = ? AND revoked_at IS NULL", c.Param("shareId"), credential.ID).First(&share).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+
+	// Drop the wrapped key too; a revoked share is only kept as a record
+	if err := h.db.Model(&share).Updates(map[string]interface{}{
+		"revoked_at":  time.Now(),
+		"wrapped_key": "",
+	}).Error; err != nil {
+		h.logger.Error("Failed to revoke share", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share"})
+		return
+	}
+
+	h.audit.recordActor(c, credential.WalletAddress, share.RecipientWallet, credential.ID, "unshare")
+
+	h.logger.Info("Share revoked", "id", credential.ID, "wallet", walletAddress, "recipient", share.RecipientWallet)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share revoked successfully"})
+}
+
+// findShared loads a credential of another account shared with walletAddress's
+// account, with the share
+func (h *CredentialHandler) findShared(walletAddress, id string) (*models.Credential, *models.CredentialShare, error) {
+	share, err := activeShare(h.db, linkedWallets(h.db, walletAddress), id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var credential models.Credential
+	if err := h.db.Scopes(notExpired).Where("id = ?", id).First(&credential).Error; err != nil {
+		return nil, nil, err
+	}
+	return &credential, share, nil
+}
+
+// revealShared answers GET /credentials/:id for a share recipient: the
+// ciphertext with its data key wrapped to the recipient's key. The read is
+// audited on the owner's trail with the recipient as actor.
+func (h *CredentialHandler) revealShared(c *gin.Context, credential *models.Credential, share *models.CredentialShare) {
+	walletAddress := middleware.WalletAddress(c)
+
+	if status, errMsg := h.verifyReveal(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if credential.RequirePasskey {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Credential now requires the owner's passkey"})
+		return
+	}
+
+	if credential.RequiresApproval() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Credential now requires approval; ask the owner"})
+		return
+	}
+
+	if share.Version != credential.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": "Credential changed since it was shared; ask the owner to share it again"})
+		return
+	}
+
+	h.audit.recordActor(c, credential.WalletAddress, walletAddress, credential.ID, "shared_read")
+
+	h.logger.Info("Shared credential accessed", "id", credential.ID, "owner", credential.WalletAddress, "wallet", walletAddress)
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":            credential.ID,
+		"name":          credential.CredentialName,
+		"kind":          credential.Kind,
+		"username":      credential.Username,
+		"url":           credential.URL,
+		"encryptedData": credential.EncryptedData,
+		"nonce":         credential.Nonce,
+		"metadata":      credential.Metadata,
+		"walletAddress": credential.WalletAddress,
+		"createdAt":     credential.CreatedAt,
+		"sharedWithMe":  true,
+		"wrappedKey":    share.WrappedKey,
+	})
+}
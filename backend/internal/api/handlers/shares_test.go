@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/nacl/box"
+	"pass-chain/backend/internal/services"
+)
+
+// publishKey publishes a fresh encryption key for wallet and returns it
+func (e *testEnv) publishKey(wallet testWallet) string {
+	e.t.Helper()
+	public, _, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	publicKey := base64.StdEncoding.EncodeToString(public[:])
+	if status := e.do(wallet, http.MethodPut, "/sharing/key", gin.H{"publicKey": publicKey}, nil, nil); status != http.StatusOK {
+		e.t.Fatalf("publish key: status %d", status)
+	}
+	return publicKey
+}
+
+func wrapKey(t *testing.T, publicKey string) string {
+	t.Helper()
+	wrapped, err := services.WrapForWallet(publicKey, "ZGF0YSBrZXk=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wrapped
+}
+
+func TestShareCredential(t *testing.T) {
+	env := newTestEnv(t)
+	owner, recipient := newTestWallet(t), newTestWallet(t)
+	recipientKey := env.publishKey(recipient)
+	id := env.createCredential(owner, "djE=")
+
+	// Strangers see nothing before the credential is shared
+	if status, _ := env.reveal(recipient, id); status != http.StatusNotFound {
+		t.Fatalf("reveal before sharing: status %d, want %d", status, http.StatusNotFound)
+	}
+
+	wrapped := wrapKey(t, recipientKey)
+	var share struct {
+		ID string `json:"id"`
+	}
+	status := env.do(owner, http.MethodPost, "/credentials/"+id+"/shares", gin.H{"recipientWallet": recipient.address, "wrappedKey": wrapped, "version": 1}, nil, &share)
+	if status != http.StatusOK || share.ID == "" {
+		t.Fatalf("share: status %d, %+v", status, share)
+	}
+
+	status, revealed := env.reveal(recipient, id)
+	if status != http.StatusOK || revealed["sharedWithMe"] != true || revealed["wrappedKey"] != wrapped {
+		t.Fatalf("shared reveal: status %d, body %v", status, revealed)
+	}
+	if _, ok := revealed["share1"]; ok {
+		t.Error("shared reveal returned share1")
+	}
+
+	// An update leaves the share wrapped for the old key until re-shared
+	if status, body := env.updateSecret(owner, id, "djI=", 1); status != http.StatusOK {
+		t.Fatalf("update: status %d, body %v", status, body)
+	}
+	if status, _ := env.reveal(recipient, id); status != http.StatusConflict {
+		t.Errorf("reveal of a stale share: status %d, want %d", status, http.StatusConflict)
+	}
+	var shares []shareView
+	if status := env.do(owner, http.MethodGet, "/credentials/"+id+"/shares", nil, nil, &shares); status != http.StatusOK || len(shares) != 1 || !shares[0].Stale {
+		t.Errorf("shares after update: status %d, %+v", status, shares)
+	}
+
+	rewrapped := wrapKey(t, recipientKey)
+	var reshare struct {
+		ID string `json:"id"`
+	}
+	status = env.do(owner, http.MethodPost, "/credentials/"+id+"/shares", gin.H{"recipientWallet": recipient.address, "wrappedKey": rewrapped, "version": 2}, nil, &reshare)
+	if status != http.StatusOK || reshare.ID != share.ID {
+		t.Fatalf("re-share: status %d, id %s, want the existing %s", status, reshare.ID, share.ID)
+	}
+	if status, revealed := env.reveal(recipient, id); status != http.StatusOK || revealed["wrappedKey"] != rewrapped {
+		t.Errorf("reveal after re-share: status %d, body %v", status, revealed)
+	}
+
+	if status := env.do(owner, http.MethodDelete, "/credentials/"+id+"/shares/"+share.ID, nil, nil, nil); status != http.StatusOK {
+		t.Fatalf("revoke: status %d", status)
+	}
+	if status, _ := env.reveal(recipient, id); status != http.StatusNotFound {
+		t.Errorf("reveal after revoke: status %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestShareCredentialRejects(t *testing.T) {
+	env := newTestEnv(t)
+	owner, recipient, keyless, linked := newTestWallet(t), newTestWallet(t), newTestWallet(t), newTestWallet(t)
+	recipientKey := env.publishKey(recipient)
+	env.publishKey(linked)
+	env.linkWallets(owner, linked)
+	id := env.createCredential(owner, "djE=")
+	wrapped := wrapKey(t, recipientKey)
+
+	tests := []struct {
+		name       string
+		caller     testWallet
+		recipient  string
+		wrappedKey string
+		version    int
+		want       int
+	}{
+		{"stale version", owner, recipient.address, wrapped, 2, http.StatusConflict},
+		{"not wrapped", owner, recipient.address, "ZGF0YSBrZXk=", 1, http.StatusBadRequest},
+		{"invalid recipient", owner, "0x1234", wrapped, 1, http.StatusBadRequest},
+		{"recipient without a key", owner, keyless.address, wrapped, 1, http.StatusBadRequest},
+		{"own linked wallet", owner, linked.address, wrapped, 1, http.StatusBadRequest},
+		{"not the owner", recipient, keyless.address, wrapped, 1, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := gin.H{"recipientWallet": tt.recipient, "wrappedKey": tt.wrappedKey, "version": tt.version}
+			if status := env.do(tt.caller, http.MethodPost, "/credentials/"+id+"/shares", body, nil, nil); status != tt.want {
+				t.Errorf("status %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	var shares []shareView
+	env.do(owner, http.MethodGet, "/credentials/"+id+"/shares", nil, nil, &shares)
+	if len(shares) != 0 {
+		t.Errorf("rejected shares were saved: %+v", shares)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/pkg/logger"
+)
+
+type TagHandler struct {
+	db     *database.Database
+	logger *logger.Logger
+}
+
+func NewTagHandler(db *database.Database, log *logger.Logger) *TagHandler {
+	return &TagHandler{
+		db:     db,
+		logger: log,
+	}
+}
+
+// taggedCredentials is a subquery selecting the IDs of credentials carrying
+// any of the named tags of the given wallets
+func taggedCredentials(db *database.Database, wallets, names []string) *gorm.DB {
+	return db.Table("credential_tags").
+		Select("credential_tags.credential_id").
+		Joins("JOIN tags ON tags.id = credential_tags.tag_id").
+		Where("tags.wallet_address IN ? AND tags.name IN ?", wallets, nonEmpty(names))
+}
+
+// tagView is a tag with the number of credentials carrying it
+type tagView struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Credentials int    `json:"credentials"`
+}
+
+// GetTags handles GET /api/v1/tags
+func (h *TagHandler) GetTags(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	var tags []tagView
+	err := h.db.Table("tags").
+		Select("tags.id, tags.name, COUNT(credentials.id) AS credentials").
+		Joins("LEFT JOIN credential_tags ON credential_tags.tag_id = tags.id").
+		Joins("LEFT JOIN credentials ON credentials.id = credential_tags.credential_id AND credentials.deleted_at IS NULL").
+		Where("tags.wallet_address IN ?", linkedWallets(h.db, walletAddress)).
+		Group("tags.id, tags.name").
+		Order("tags.name").
+		Scan(&tags).Error
+	if err != nil {
+		h.logger.Error("Failed to fetch tags", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// DeleteTag handles DELETE /api/v1/tags/:id
+// The tag is removed from every credential carrying it.
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	var tag models.Tag
+	if err := h.db.Where("id = ? AND wallet_address IN ?", id, linkedWallets(h.db, walletAddress)).First(&tag).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM credential_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		h.logger.Error("Failed to delete tag", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+		return
+	}
+
+	h.logger.Info("Tag deleted", "id", id, "wallet", walletAddress)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// TagCredential handles POST /api/v1/credentials/:id/tags
+func (h *CredentialHandler) TagCredential(c *gin.Context) {
+	var req models.TagCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	// A key could otherwise widen what tag-scoped keys can reach
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot organize credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	wallets := linkedWallets(h.db, walletAddress)
+	tags := make([]models.Tag, 0, len(req.Tags))
+	for _, name := range req.Tags {
+		name = strings.TrimSpace(name)
+		if name == "" || containsString(credential.TagNames(), name) {
+			continue
+		}
+
+		// Reuse the account's tag of that name, else create it for the caller
+		var tag models.Tag
+		if err := h.db.Where("name = ? AND wallet_address IN ?", name, wallets).First(&tag).Error; err != nil {
+			tag = models.Tag{WalletAddress: walletAddress, Name: name}
+			if err := h.db.Create(&tag).Error; err != nil {
+				h.logger.Error("Failed to create tag", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag credential"})
+				return
+			}
+		}
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > 0 {
+		if err := h.db.Model(credential).Association("Tags").Append(tags); err != nil {
+			h.logger.Error("Failed to tag credential", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tag credential"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":   credential.ID,
+		"tags": credential.TagNames(),
+	})
+}
+
+// UntagCredential handles DELETE /api/v1/credentials/:id/tags/:tag
+func (h *CredentialHandler) UntagCredential(c *gin.Context) {
+	id := c.Param("id")
+	name := c.Param("tag")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot organize credentials"})
+		return
+	}
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	var remove []models.Tag
+	keep := []string{}
+	for _, tag := range credential.Tags {
+		if tag.Name == name {
+			remove = append(remove, tag)
+		} else {
+			keep = append(keep, tag.Name)
+		}
+	}
+	if len(remove) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential does not have this tag"})
+		return
+	}
+
+	if err := h.db.Model(credential).Association("Tags").Delete(remove); err != nil {
+		h.logger.Error("Failed to untag credential", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to untag credential"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":   credential.ID,
+		"tags": keep,
+	})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+)
+
+// GetTrash handles GET /api/v1/credentials/trash
+// Items are listed soonest purge first. ?org= lists an organization's trash
+// instead, for its admins.
+func (h *CredentialHandler) GetTrash(c *gin.Context) {
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot access the trash"})
+		return
+	}
+
+	wallets := linkedWallets(h.db, walletAddress)
+	query := h.db.Unscoped().Preload("Tags").Where("wallet_address IN ? AND org_id IS NULL", wallets)
+	if org := c.Query("org"); org != "" {
+		role := orgRole(h.db, org, wallets)
+		if role == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+			return
+		}
+		if !models.OrgRoleAtLeast(role, models.OrgRoleAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your role in the organization does not allow this"})
+			return
+		}
+		query = h.db.Unscoped().Preload("Tags").Where("org_id = ?", org)
+	}
+
+	var credentials []models.Credential
+	err := query.
+		Where("deleted_at IS NOT NULL AND purge_at > ?", time.Now()).
+		Order("purge_at").
+		Find(&credentials).Error
+	if err != nil {
+		h.logger.Error("Failed to fetch trash", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// RestoreCredential handles POST /api/v1/credentials/:id/restore
+func (h *CredentialHandler) RestoreCredential(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	if middleware.APIKey(c) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot access the trash"})
+		return
+	}
+
+	wallets := linkedWallets(h.db, walletAddress)
+
+	var credential models.Credential
+	err := h.db.Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL AND purge_at > ?", id, time.Now()).
+		First(&credential).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found in trash"})
+		return
+	}
+
+	// Deleting organization credentials is for admins, and so is restoring them
+	if credential.OrgID != nil {
+		role := orgRole(h.db, *credential.OrgID, wallets)
+		if role == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found in trash"})
+			return
+		}
+		if !models.OrgRoleAtLeast(role, models.OrgRoleAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your role in the organization does not allow this"})
+			return
+		}
+	} else if _, own := findWallet(wallets, credential.WalletAddress); !own {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found in trash"})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"deleted_at": nil,
+		"purge_at":   nil,
+	}
+
+	// Folder deletion only reparents live credentials
+	if credential.FolderID != nil {
+		if _, err := findFolder(h.db.DB, wallets, *credential.FolderID); err != nil {
+			updates["folder_id"] = nil
+		}
+	}
+
+	// Otherwise the reaper would remove it again straight away
+	if credential.ExpiresAt != nil && credential.ExpiresAt.Before(time.Now()) {
+		updates["expires_at"] = nil
+		updates["expiry_notified_at"] = nil
+	}
+
+	// The purge_at guard loses the race against a purge already underway
+	result := h.db.Unscoped().Model(&models.Credential{}).
+		Where("id = ? AND purge_at > ?", credential.ID, time.Now()).
+		Updates(updates)
+	if result.Error != nil {
+		h.logger.Error("Failed to restore credential", "error", result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore credential"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found in trash"})
+		return
+	}
+
+	h.audit.recordCredential(c, walletAddress, &credential, "restore")
+
+	h.logger.Info("Credential restored", "id", id, "wallet", walletAddress)
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"message": "Credential restored successfully",
+	})
+}
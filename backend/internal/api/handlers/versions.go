@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/middleware"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+)
+
+var errVersionConflict = errors.New("credential version changed")
+
+// snapshotVersion returns the history row of the credential's current version
+func snapshotVersion(credential *models.Credential, vaultVersion int, walletAddress string) *models.CredentialVersion {
+	return &models.CredentialVersion{
+		CredentialID:   credential.ID,
+		Version:        credential.Version,
+		EncryptedData:  credential.EncryptedData,
+		Nonce:          credential.Nonce,
+		Metadata:       credential.Metadata,
+		Share2:         credential.Share2,
+		Share3Hash:     credential.Share3Hash,
+		SplitScheme:    credential.SplitScheme,
+		VaultVersion:   vaultVersion,
+		BlockchainTxID: credential.BlockchainTxID,
+		WalletAddress:  walletAddress,
+	}
+}
+
+// ensureVersion records the current version of a credential created before
+// version history existed, so an update does not lose it
+func ensureVersion(tx *gorm.DB, credential *models.Credential) error {
+	var count int64
+	if err := tx.Model(&models.CredentialVersion{}).
+		Where("credential_id = ? AND version = ?", credential.ID, credential.Version).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return tx.Create(snapshotVersion(credential, 0, credential.WalletAddress)).Error
+}
+
+// writeVersion stores a new secret for credential: share1 as a new Vault KV v2
+// version of the same path (Vault keeps the previous one) and share2 under a
+// new Fabric key. It returns the unsaved history row for the new version.
+func (h *CredentialHandler) writeVersion(credential *models.Credential, walletAddress, encryptedData, nonce string, metadata map[string]string, share1, share2 string) (*models.CredentialVersion, error) {
+	vaultVersion, err := h.vault.WriteSecretVersion(credential.VaultPath, map[string]interface{}{
+		"share1":     share1,
+		"created_at": "now",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	version := credential.Version + 1
+	txID := "fabric-not-configured"
+	if h.fabric != nil {
+		txID, err = h.fabric.StoreShard(credential.WalletAddress, services.ShardKey(credential.ID, version), share2)
+		if err != nil {
+			h.logger.Error("Failed to store in Fabric", "error", err)
+			txID = "fabric-unavailable"
+		}
+	}
+
+	return &models.CredentialVersion{
+		CredentialID:   credential.ID,
+		Version:        version,
+		EncryptedData:  encryptedData,
+		Nonce:          nonce,
+		Metadata:       metadata,
+		Share2:         share2,
+		VaultVersion:   vaultVersion,
+		BlockchainTxID: txID,
+		WalletAddress:  walletAddress,
+	}, nil
+}
+
+// versionShardKey is the Fabric key of share2 of a version of credential: the
+// last segment of the Vault path for the first version, ShardKey after
+func versionShardKey(credential *models.Credential, version int) string {
+	if version == 1 {
+		return path.Base(credential.VaultPath)
+	}
+	return services.ShardKey(credential.ID, version)
+}
+
+// discardVersion undoes writeVersion after its history row could not be
+// saved: the orphaned share1 is destroyed in Vault (reveals read the pinned
+// version, so it is only clutter) and, if a concurrent update won the same
+// version number, its share2 is written back over ours on Fabric.
+func (h *CredentialHandler) discardVersion(credential *models.Credential, next *models.CredentialVersion) {
+	if err := h.vault.DestroySecretVersions(credential.VaultPath, next.VaultVersion); err != nil {
+		h.logger.Error("Failed to destroy orphaned share1 in Vault", "id", credential.ID, "vaultVersion", next.VaultVersion, "error", err)
+	}
+
+	if h.fabric == nil {
+		return
+	}
+	var winner models.Credential
+	if err := h.db.Select("share2", "version").Where("id = ?", credential.ID).First(&winner).Error; err != nil || winner.Version != next.Version {
+		return
+	}
+	key := services.ShardKey(credential.ID, next.Version)
+	if _, err := h.fabric.StoreShard(credential.WalletAddress, key, winner.Share2); err != nil {
+		h.logger.Error("Failed to restore share2 on Fabric", "id", credential.ID, "key", key, "error", err)
+	}
+}
+
+// readShare1 reads share1 of the credential's current version from Vault: the
+// KV v2 version pinned in its history row, so a version written by an update
+// that then failed is never served. Credentials from before version history
+// read the latest version.
+func (h *CredentialHandler) readShare1(credential *models.Credential) (string, error) {
+	var pinned models.CredentialVersion
+	err := h.db.Select("vault_version").
+		Where("credential_id = ? AND version = ?", credential.ID, credential.Version).
+		First(&pinned).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	var vaultData map[string]interface{}
+	if pinned.VaultVersion != 0 {
+		vaultData, err = h.vault.ReadSecretVersion(credential.VaultPath, pinned.VaultVersion)
+	} else {
+		vaultData, err = h.vault.ReadSecret(credential.VaultPath)
+	}
+	if err != nil {
+		return "", err
+	}
+	share1, ok := vaultData["share1"].(string)
+	if !ok || share1 == "" {
+		return "", errShareMissing
+	}
+	return share1, nil
+}
+
+// metadataJSON encodes metadata for a map-based update, which bypasses the
+// column's JSON serializer
+func metadataJSON(metadata map[string]string) interface{} {
+	if metadata == nil {
+		return nil
+	}
+	data, _ := json.Marshal(metadata)
+	return string(data)
+}
+
+// saveVersion makes next (may be nil) the current version of credential and
+// applies any other column updates. Returns errVersionConflict if the
+// credential changed since it was loaded.
+func (h *CredentialHandler) saveVersion(credential *models.Credential, next *models.CredentialVersion, updates map[string]interface{}) error {
+	if next != nil {
+		updates["encrypted_data"] = next.EncryptedData
+		updates["nonce"] = next.Nonce
+		updates["metadata"] = metadataJSON(next.Metadata)
+		updates["share2"] = next.Share2
+		updates["share3_hash"] = next.Share3Hash
+		updates["split_scheme"] = next.SplitScheme
+		updates["blockchain_tx_id"] = next.BlockchainTxID
+		updates["version"] = next.Version
+	}
+
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		if next != nil {
+			if err := ensureVersion(tx, credential); err != nil {
+				return err
+			}
+			if err := tx.Create(next).Error; err != nil {
+				return err
+			}
+			// A restored version's share3 still has its refreshes to take
+			if next.RestoredFrom != 0 {
+				if err := tx.Exec(`INSERT INTO refresh_deltas (credential_id, version, wrapped_delta, created_at)
+					SELECT credential_id, ?, wrapped_delta, created_at FROM refresh_deltas WHERE credential_id = ? AND version = ?`,
+					next.Version, credential.ID, next.RestoredFrom).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Guard against a concurrent update of the same version
+		result := tx.Model(&models.Credential{}).
+			Where("id = ? AND version = ?", credential.ID, credential.Version).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		return nil
+	})
+}
+
+// versionView is a history entry as listed to the owner (no secret material)
+type versionView struct {
+	models.CredentialVersion
+	Current    bool                    `json:"current"`
+	Restorable bool                    `json:"restorable"`
+	Vault      *services.SecretVersion `json:"vault,omitempty"`
+}
+
+// GetCredentialVersions handles GET /api/v1/credentials/:id/versions
+func (h *CredentialHandler) GetCredentialVersions(c *gin.Context) {
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	if !h.keyAllows(c, credential) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not cover this credential"})
+		return
+	}
+
+	var history []models.CredentialVersion
+	if err := h.db.Where("credential_id = ?", credential.ID).Order("version DESC").Find(&history).Error; err != nil {
+		h.logger.Error("Failed to fetch credential versions", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credential versions"})
+		return
+	}
+	if len(history) == 0 {
+		// Created before version history existed
+		history = append(history, *snapshotVersion(credential, 0, credential.WalletAddress))
+	}
+
+	// Vault metadata tells which share1 versions can still be read
+	vaultVersions, err := h.vault.ReadSecretVersions(credential.VaultPath)
+	if err != nil {
+		h.logger.Warn("Failed to read Vault version metadata", "id", id, "error", err)
+	}
+
+	versions := make([]versionView, 0, len(history))
+	for _, entry := range history {
+		view := versionView{CredentialVersion: entry, Current: entry.Version == credential.Version}
+		if meta, ok := vaultVersions[entry.VaultVersion]; ok {
+			view.Vault = &meta
+			view.Restorable = !view.Current && !meta.Destroyed && meta.DeletionTime == ""
+		}
+		versions = append(versions, view)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       credential.ID,
+		"current":  credential.Version,
+		"versions": versions,
+	})
+}
+
+// RollbackCredential handles POST /api/v1/credentials/:id/rollback
+//
+// The chosen version's encryptedData and nonce (DB history), share1 (its Vault
+// KV v2 version) and share2 (DB history, checked against Fabric) are written
+// back together as a new version, so the rollback itself stays in history.
+func (h *CredentialHandler) RollbackCredential(c *gin.Context) {
+	var req models.RollbackCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	walletAddress := middleware.WalletAddress(c)
+
+	credential, err := h.findCredential(walletAddress, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+		return
+	}
+
+	if !h.keyAllows(c, credential) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API key does not cover this credential"})
+		return
+	}
+
+	if status, errMsg := h.verifyPasskey(c, credential); errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	if req.Version == credential.Version {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Version is already current"})
+		return
+	}
+
+	var target models.CredentialVersion
+	if err := h.db.Where("credential_id = ? AND version = ?", credential.ID, req.Version).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		return
+	}
+
+	if target.VaultVersion == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Share1 of this version was not recorded in Vault history"})
+		return
+	}
+
+	vaultData, err := h.vault.ReadSecretVersion(credential.VaultPath, target.VaultVersion)
+	if err != nil {
+		h.logger.Warn("Failed to read Vault version", "id", id, "vaultVersion", target.VaultVersion, "error", err)
+		c.JSON(http.StatusConflict, gin.H{"error": "Share1 of this version is no longer available in Vault"})
+		return
+	}
+	share1, ok := vaultData["share1"].(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid vault data"})
+		return
+	}
+
+	// The ledger copy of share2 must agree with the DB history
+	if h.fabric != nil {
+		onChain, err := h.fabric.ReadShard(credential.WalletAddress, versionShardKey(credential, target.Version))
+		if err != nil {
+			h.logger.Warn("Failed to read share2 from Fabric, using DB history", "id", id, "error", err)
+		} else if onChain != target.Share2 {
+			h.logger.Error("Share2 history does not match Fabric", "id", id, "version", target.Version)
+			c.JSON(http.StatusConflict, gin.H{"error": "Version history does not match the ledger"})
+			return
+		}
+	}
+
+	next, err := h.writeVersion(credential, walletAddress, target.EncryptedData, target.Nonce, target.Metadata, share1, target.Share2)
+	if err != nil {
+		h.logger.Error("Failed to store in Vault", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store encryption key"})
+		return
+	}
+	next.RestoredFrom = target.Version
+	next.Share3Hash = target.Share3Hash // The client's share3 of that version
+	next.SplitScheme = target.SplitScheme
+
+	err = h.saveVersion(credential, next, map[string]interface{}{})
+	if err != nil {
+		h.discardVersion(credential, next)
+	}
+	if errors.Is(err, errVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Credential was changed since it was read"})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to roll back credential", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back credential"})
+		return
+	}
+
+	h.audit.record(c, walletAddress, id, "rollback")
+
+	h.logger.Info("Credential rolled back", "id", id, "wallet", walletAddress, "from", target.Version, "version", next.Version)
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":           id,
+		"version":      next.Version,
+		"restoredFrom": target.Version,
+		"txId":         next.BlockchainTxID,
+		"message":      "Credential rolled back successfully",
+	})
+}
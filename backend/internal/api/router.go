@@ -1,17 +1,20 @@
 package api
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"pass-chain/backend/internal/api/handlers"
 	"pass-chain/backend/internal/config"
 	"pass-chain/backend/internal/database"
 	"pass-chain/backend/internal/middleware"
-	"pass-chain/backend/internal/services"
+	"pass-chain/backend/internal/worker"
 	"pass-chain/backend/pkg/logger"
 )
 
 // NewRouter creates a new Gin router with all routes configured
-func NewRouter(cfg *config.Config, db *database.Database, log *logger.Logger) *gin.Engine {
+func NewRouter(cfg *config.Config, db *database.Database, svc *Services, log *logger.Logger) *gin.Engine {
 	router := gin.New()
 
 	// Middleware
@@ -27,53 +30,205 @@ func NewRouter(cfg *config.Config, db *database.Database, log *logger.Logger) *g
 		})
 	})
 
-	// Initialize services
-	vaultService, err := services.NewVaultService(cfg.Vault.Address, cfg.Vault.Token)
-	if err != nil {
-		log.Error("Failed to initialize Vault service", "error", err)
-		// Continue without Vault for now
-		vaultService = nil
-	}
-
-	// Initialize Fabric client (optional - gracefully handle if unavailable)
-	var fabricClient *services.FabricClient
-	fabricConfig := &services.FabricConfig{
-		ConfigPath:   "./config/fabric-connection.yaml",
-		ChannelID:    "passchain",
-		ChaincodeID:  "credentials",
-		OrgName:      "Org1",
-		OrgUser:      "Admin",
-		PeerEndpoint: "fabric-peer.fabric.svc.cluster.local:7051",
-	}
-	
-	// Try to initialize Fabric - if it fails, log but continue
-	fabricClient, err = services.NewFabricClient(fabricConfig, log)
+	// WebAuthn relying party for passkey second factors
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.Auth.WebAuthnRPID,
+		RPDisplayName: cfg.Auth.WebAuthnRPName,
+		RPOrigins:     cfg.Auth.WebAuthnOrigins,
+	})
 	if err != nil {
-		log.Warn("Fabric client not available, app will work without blockchain", "error", err)
-		fabricClient = nil
-	} else {
-		log.Info("✅ Fabric client initialized - blockchain integration active!")
+		log.Fatal("Invalid WebAuthn configuration", "error", err)
 	}
 
 	// Initialize handlers
-	credHandler := handlers.NewCredentialHandler(db, vaultService, fabricClient, log)
+	credHandler := handlers.NewCredentialHandler(db, svc.Vault, svc.Fabric, svc.Nonces, time.Duration(cfg.Auth.RevealTTL)*time.Second, time.Duration(cfg.Worker.TrashRetention)*time.Second, time.Duration(cfg.Auth.ApprovalTTL)*time.Second, log)
 	auditHandler := handlers.NewAuditHandler(db, log)
+	authHandler := handlers.NewAuthHandler(cfg.Auth, svc.Nonces, svc.Sessions, log)
+	apiKeyHandler := handlers.NewAPIKeyHandler(db, log)
+	folderHandler := handlers.NewFolderHandler(db, log)
+	tagHandler := handlers.NewTagHandler(db, log)
+	passkeyHandler := handlers.NewPasskeyHandler(db, webAuthn, svc.Nonces, time.Duration(cfg.Auth.PasskeyTTL)*time.Second, log)
+	notificationHandler := handlers.NewNotificationHandler(db, log)
+	shareHandler := handlers.NewShareHandler(db, log)
+	linkHandler := handlers.NewLinkHandler(db, svc.Vault, svc.Fabric, log)
+	orgHandler := handlers.NewOrgHandler(db, log)
+	approvalHandler := handlers.NewApprovalHandler(db, svc.Fabric, log)
+	emergencyHandler := handlers.NewEmergencyHandler(db, svc.Fabric, log)
+	refreshHandler := handlers.NewRefreshHandler(credHandler, worker.NewShareRefresher(db, svc.Vault, svc.Fabric, time.Duration(cfg.Worker.ShareMaxAge)*time.Second, log), log)
+	backupHandler := handlers.NewBackupHandler(credHandler, svc.Backup, int64(cfg.Backup.MaxSize), log)
+	accountHandler := handlers.NewAccountHandler(db, svc.Vault, svc.Fabric, svc.Nonces, time.Duration(cfg.Auth.NonceTTL)*time.Second, log)
+
+	authConfig := middleware.WalletAuthConfig{
+		Sessions:  svc.Sessions,
+		Nonces:    svc.Nonces,
+		DB:        db,
+		MaxAge:    time.Duration(cfg.Auth.SignatureMaxAge) * time.Second,
+		ClockSkew: time.Duration(cfg.Auth.ClockSkew) * time.Second,
+		Domain:    cfg.Auth.SIWEDomain,
+	}
+	walletAuth := middleware.WalletAuth(authConfig)
+	signedRequest := middleware.SignedRequest(svc.Nonces, authConfig.ClockSkew)
+
+	// Service-account API keys are accepted on the credential routes only
+	keyAuthConfig := authConfig
+	keyAuthConfig.AllowAPIKeys = true
+	credentialAuth := middleware.WalletAuth(keyAuthConfig)
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
+		// Sign-In-With-Ethereum
+		authGroup := v1.Group("/auth")
+		{
+			authGroup.GET("/nonce", authHandler.GetNonce)
+			authGroup.POST("/login", authHandler.Login)
+			authGroup.POST("/logout", walletAuth, authHandler.Logout)
+
+			// Session and device management
+			authGroup.GET("/sessions", walletAuth, authHandler.GetSessions)
+			authGroup.GET("/devices", walletAuth, authHandler.GetDevices)
+			authGroup.DELETE("/sessions", walletAuth, signedRequest, authHandler.RevokeAllSessions)
+			authGroup.DELETE("/sessions/:id", walletAuth, signedRequest, authHandler.RevokeSession)
+		}
+
 		// Credentials
-		credentials := v1.Group("/credentials")
+		credentials := v1.Group("/credentials", credentialAuth)
 		{
-			credentials.POST("", credHandler.CreateCredential)
+			credentials.POST("", signedRequest, credHandler.CreateCredential)
 			credentials.GET("", credHandler.GetCredentials)
+			credentials.POST("/import", signedRequest, credHandler.ImportCredentials)
+			credentials.GET("/trash", credHandler.GetTrash)
+			credentials.POST("/:id/restore", signedRequest, credHandler.RestoreCredential)
 			credentials.GET("/:id", credHandler.GetCredentialByID)
-			credentials.DELETE("/:id", credHandler.DeleteCredential)
+			credentials.POST("/:id/reveal-challenge", credHandler.CreateRevealChallenge)
+			credentials.PUT("/:id", signedRequest, credHandler.UpdateCredential)
+			credentials.PATCH("/:id", signedRequest, credHandler.UpdateCredential)
+			credentials.DELETE("/:id", signedRequest, credHandler.DeleteCredential)
+			credentials.GET("/:id/versions", credHandler.GetCredentialVersions)
+			credentials.POST("/:id/rollback", signedRequest, credHandler.RollbackCredential)
+			credentials.POST("/:id/recover", signedRequest, credHandler.RecoverCredential)
+			credentials.POST("/:id/refresh", signedRequest, refreshHandler.RefreshShares)
+			credentials.POST("/:id/refresh/apply", signedRequest, refreshHandler.ApplyRefresh)
+			credentials.PUT("/:id/folder", signedRequest, credHandler.MoveCredential)
+			credentials.POST("/:id/tags", signedRequest, credHandler.TagCredential)
+			credentials.DELETE("/:id/tags/:tag", signedRequest, credHandler.UntagCredential)
+			credentials.PUT("/:id/require-passkey", signedRequest, credHandler.SetRequirePasskey)
+			credentials.PUT("/:id/approval-policy", signedRequest, credHandler.SetApprovalPolicy)
+			credentials.GET("/:id/shares", credHandler.GetCredentialShares)
+			credentials.POST("/:id/shares", signedRequest, credHandler.ShareCredential)
+			credentials.DELETE("/:id/shares/:shareId", signedRequest, credHandler.RevokeShare)
+			credentials.GET("/:id/links", credHandler.GetShareLinks)
+			credentials.POST("/:id/links", signedRequest, credHandler.CreateShareLink)
+			credentials.DELETE("/:id/links/:linkId", signedRequest, credHandler.RevokeShareLink)
+		}
+
+		// Service-account API keys
+		apiKeys := v1.Group("/api-keys", walletAuth)
+		{
+			apiKeys.POST("", signedRequest, apiKeyHandler.CreateAPIKey)
+			apiKeys.GET("", apiKeyHandler.GetAPIKeys)
+			apiKeys.DELETE("/:id", signedRequest, apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Folders and tags
+		folders := v1.Group("/folders", walletAuth)
+		{
+			folders.GET("", folderHandler.GetFolders)
+			folders.POST("", signedRequest, folderHandler.CreateFolder)
+			folders.PATCH("/:id", signedRequest, folderHandler.UpdateFolder)
+			folders.DELETE("/:id", signedRequest, folderHandler.DeleteFolder)
+		}
+		tags := v1.Group("/tags", walletAuth)
+		{
+			tags.GET("", tagHandler.GetTags)
+			tags.DELETE("/:id", signedRequest, tagHandler.DeleteTag)
+		}
+
+		// WebAuthn passkeys (second factor)
+		passkeys := v1.Group("/passkeys", walletAuth)
+		{
+			passkeys.POST("/register/begin", passkeyHandler.BeginRegistration)
+			passkeys.POST("/register/finish", signedRequest, passkeyHandler.FinishRegistration)
+			passkeys.GET("", passkeyHandler.GetPasskeys)
+			passkeys.DELETE("/:id", signedRequest, passkeyHandler.DeletePasskey)
+			passkeys.POST("/assert/begin", passkeyHandler.BeginAssertion)
+			passkeys.POST("/assert/finish", passkeyHandler.FinishAssertion)
+		}
+
+		// Accounts with several linked wallets
+		accounts := v1.Group("/accounts", walletAuth)
+		{
+			accounts.GET("/me", accountHandler.GetAccount)
+			accounts.POST("/link-challenge", accountHandler.CreateLinkChallenge)
+			accounts.POST("/wallets", signedRequest, accountHandler.LinkWallet)
+			accounts.POST("/unlink-challenge", accountHandler.CreateUnlinkChallenge)
+			accounts.DELETE("/wallets/:address", signedRequest, accountHandler.UnlinkWallet)
+			accounts.POST("/migrate", signedRequest, accountHandler.MigrateWallet)
+		}
+
+		// Organizations (team vaults live under /credentials?org=)
+		orgs := v1.Group("/orgs", walletAuth)
+		{
+			orgs.POST("", signedRequest, orgHandler.CreateOrganization)
+			orgs.GET("", orgHandler.GetOrganizations)
+			orgs.GET("/:id", orgHandler.GetOrganization)
+			orgs.POST("/:id/members", signedRequest, orgHandler.AddMember)
+			orgs.PATCH("/:id/members/:address", signedRequest, orgHandler.UpdateMember)
+			orgs.DELETE("/:id/members/:address", signedRequest, orgHandler.RemoveMember)
+			orgs.PUT("/:id/members/:address/keys", signedRequest, orgHandler.SetMemberKeys)
+			orgs.GET("/:id/keys", orgHandler.GetMemberKeys)
+			orgs.GET("/:id/audit-logs", orgHandler.GetOrgAuditLogs)
+		}
+
+		// M-of-N reveal approvals
+		approvals := v1.Group("/approvals", walletAuth)
+		{
+			approvals.GET("", approvalHandler.GetApprovals)
+			approvals.GET("/:id", approvalHandler.GetApproval)
+			approvals.POST("/:id/approve", signedRequest, approvalHandler.Approve)
+		}
+
+		// Emergency access (granted by worker.EmergencyGranter)
+		emergency := v1.Group("/emergency", walletAuth)
+		{
+			emergency.POST("/contacts", signedRequest, emergencyHandler.AddContact)
+			emergency.GET("/contacts", emergencyHandler.GetContacts)
+			emergency.DELETE("/contacts/:id", signedRequest, emergencyHandler.RevokeContact)
+			emergency.PUT("/contacts/:id/keys", signedRequest, emergencyHandler.EscrowKeys)
+			emergency.GET("/contacts/:id/keys", emergencyHandler.GetEscrowKeys)
+			emergency.POST("/contacts/:id/request", signedRequest, emergencyHandler.RequestAccess)
+			emergency.GET("/requests", emergencyHandler.GetRequests)
+			emergency.POST("/requests/:id/deny", signedRequest, emergencyHandler.DenyRequest)
+		}
+
+		// Share links (public: the token is the credential)
+		v1.POST("/links/:token/redeem", linkHandler.RedeemShareLink)
+
+		// Wallet encryption keys for sharing
+		sharing := v1.Group("/sharing", walletAuth)
+		{
+			sharing.PUT("/key", signedRequest, shareHandler.PublishKey)
+			sharing.GET("/keys/:address", shareHandler.GetWalletKey)
+		}
+
+		// Signed backup archives
+		backups := v1.Group("/backups", walletAuth)
+		{
+			backups.GET("/public-key", backupHandler.GetPublicKey)
+			backups.POST("/export", signedRequest, backupHandler.ExportBackup)
+			backups.POST("/restore", signedRequest, backupHandler.RestoreBackup)
+		}
+
+		// Notifications (e.g. expiring credentials)
+		notifications := v1.Group("/notifications", walletAuth)
+		{
+			notifications.GET("", notificationHandler.GetNotifications)
+			notifications.POST("/:id/read", notificationHandler.MarkNotificationRead)
 		}
 
 		// Audit logs & blockchain explorer
-		v1.GET("/audit-logs", auditHandler.GetAuditLogs)
-		v1.GET("/stats", auditHandler.GetStats)
+		v1.GET("/audit-logs", walletAuth, auditHandler.GetAuditLogs)
+		v1.GET("/stats", walletAuth, auditHandler.GetStats)
 	}
 
 	return router
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+
+	"pass-chain/backend/internal/config"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// Services are the backends shared by the HTTP handlers and the background workers
+type Services struct {
+	Vault    *services.VaultService
+	Fabric   *services.FabricClient // nil when Fabric is unavailable
+	Nonces   services.NonceStore
+	Sessions services.SessionStore
+	Backup   *services.BackupSigner
+}
+
+// NewServices connects to Vault, Fabric and Redis and loads the backup key.
+// Only Vault is required; without Fabric share2 stays in the database,
+// without Redis the nonce and session stores are in-process.
+func NewServices(cfg *config.Config, log *logger.Logger) *Services {
+	vault, err := services.NewVaultService(cfg.Vault.Address, cfg.Vault.Token)
+	if err != nil {
+		log.Error("Failed to initialize Vault service", "error", err)
+		// Continue without Vault for now
+	}
+
+	// Initialize Fabric client (optional - gracefully handle if unavailable)
+	fabricConfig := &services.FabricConfig{
+		ConfigPath:   "./config/fabric-connection.yaml",
+		ChannelID:    "passchain",
+		ChaincodeID:  "credentials",
+		OrgName:      "Org1",
+		OrgUser:      "Admin",
+		PeerEndpoint: "fabric-peer.fabric.svc.cluster.local:7051",
+	}
+
+	// Try to initialize Fabric - if it fails, log but continue
+	fabric, err := services.NewFabricClient(fabricConfig, log)
+	if err != nil {
+		log.Warn("Fabric client not available, app will work without blockchain", "error", err)
+		fabric = nil
+	} else {
+		log.Info("✅ Fabric client initialized - blockchain integration active!")
+	}
+
+	// Nonces, replay fingerprints and sessions live in Redis so every replica
+	// sees them (and a revoked session dies everywhere at once).
+	// Fall back to in-process stores (single replica only) if Redis is down.
+	var nonceStore services.NonceStore
+	var sessionStore services.SessionStore
+	redisAddr := fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)
+	redisClient, err := services.NewRedisClient(redisAddr, cfg.Redis.Password)
+	if err != nil {
+		log.Warn("Redis not available, using in-memory nonce and session stores", "error", err)
+		nonceStore = services.NewMemoryNonceStore()
+		sessionStore = services.NewMemorySessionStore()
+	} else {
+		log.Info("Redis nonce and session stores initialized", "addr", redisAddr)
+		nonceStore = services.NewRedisNonceStore(redisClient)
+		sessionStore = services.NewRedisSessionStore(redisClient)
+	}
+
+	backup, err := services.NewBackupSigner(cfg.Backup.SigningKey, cfg.Backup.TrustedKeys)
+	if err != nil {
+		log.Fatal("Failed to load backup keys", "error", err)
+	}
+	if !backup.CanSign() {
+		log.Warn("BACKUP_SIGNING_KEY not set, backup export is disabled")
+	}
+
+	return &Services{
+		Vault:    vault,
+		Fabric:   fabric,
+		Nonces:   nonceStore,
+		Sessions: sessionStore,
+		Backup:   backup,
+	}
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const apiKeyPrefix = "pck_"
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// NewAPIKey returns a new key of the form pck_<lookup id>_<secret>, the
+// lookup id stored in clear for indexing, and the hash stored for verification.
+// The full key is only ever shown to the owner once.
+func NewAPIKey() (key, lookupID, hash string, err error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	lookupID = hex.EncodeToString(id)
+	key = apiKeyPrefix + lookupID + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, lookupID, HashToken(key), nil
+}
+
+// ParseAPIKey returns the lookup id of a presented key
+func ParseAPIKey(key string) (string, error) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", ErrInvalidAPIKey
+	}
+	lookupID, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(lookupID) != 12 || secret == "" {
+		return "", ErrInvalidAPIKey
+	}
+	return lookupID, nil
+}
+
+// CheckAPIKey compares a presented key with the stored hash in constant time
+func CheckAPIKey(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(key)), []byte(hash)) == 1
+}
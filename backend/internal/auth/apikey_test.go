@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, lookupID, hash, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("NewAPIKey: %v", err)
+	}
+
+	if !strings.HasPrefix(key, apiKeyPrefix+lookupID+"_") {
+		t.Errorf("key %q does not start with the prefix and lookup id %q", key, lookupID)
+	}
+	parsed, err := ParseAPIKey(key)
+	if err != nil || parsed != lookupID {
+		t.Errorf("ParseAPIKey = %q, %v; want %q", parsed, err, lookupID)
+	}
+	if !CheckAPIKey(key, hash) {
+		t.Error("CheckAPIKey rejected the key it was issued with")
+	}
+	if CheckAPIKey(key+"x", hash) {
+		t.Error("CheckAPIKey accepted a different key")
+	}
+
+	other, otherID, _, _ := NewAPIKey()
+	if other == key || otherID == lookupID {
+		t.Error("two keys came out the same")
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{"valid", "pck_0123456789ab_c2VjcmV0", "0123456789ab", nil},
+		{"secret with underscore", "pck_0123456789ab_se_cret", "0123456789ab", nil},
+		{"no prefix", "0123456789ab_c2VjcmV0", "", ErrInvalidAPIKey},
+		{"other prefix", "pk_0123456789ab_c2VjcmV0", "", ErrInvalidAPIKey},
+		{"short lookup id", "pck_0123_c2VjcmV0", "", ErrInvalidAPIKey},
+		{"no secret", "pck_0123456789ab_", "", ErrInvalidAPIKey},
+		{"no separator", "pck_0123456789abc2VjcmV0", "", ErrInvalidAPIKey},
+		{"empty", "", "", ErrInvalidAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAPIKey(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAPIKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ApprovalMessage is the text an approver wallet signs to approve one request
+// to reveal a credential. It binds the request, the credential and the
+// requesting wallet, and is only valid until expiresAt.
+func ApprovalMessage(requestID, credentialID, requester string, expiresAt time.Time) string {
+	return fmt.Sprintf(
+		"PassChain Reveal Approval\n\nRequest: %s\nCredential: %s\nRequested by: %s\nExpires: %s\n\nSign only if you approve this wallet revealing this credential.",
+		requestID, credentialID, requester, expiresAt.UTC().Format(time.RFC3339),
+	)
+}
+
+// PolicyChangeMessage is the text an approver wallet signs to let requester
+// weaken a credential's approval policy to threshold of approvers (0 removes it)
+func PolicyChangeMessage(requestID, credentialID, requester string, approvers []string, threshold int, expiresAt time.Time) string {
+	policy := "none"
+	if threshold > 0 {
+		policy = fmt.Sprintf("%d of %s", threshold, strings.Join(approvers, ", "))
+	}
+	return fmt.Sprintf(
+		"PassChain Approval Policy Change\n\nRequest: %s\nCredential: %s\nRequested by: %s\nNew policy: %s\nExpires: %s\n\nSign only if you approve this wallet changing who must approve reveals of this credential.",
+		requestID, credentialID, requester, policy, expiresAt.UTC().Format(time.RFC3339),
+	)
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrClockSkew = errors.New("request timestamp outside allowed clock skew")
+
+// CanonicalRequest builds the text a wallet signs (personal_sign) to
+// authorize one specific API call. Any change to the method, path, query,
+// body, timestamp or nonce yields a different string, so a signature for
+// one request cannot be reused for another.
+//
+//	PassChain Signed Request v1
+//	Method: DELETE
+//	Path: /api/v1/credentials/<id>
+//	Query: a=1&b=2
+//	Body-SHA256: <hex sha256 of raw body>
+//	Timestamp: <unix millis>
+//	Nonce: <client nonce>
+func CanonicalRequest(method, path, rawQuery string, body []byte, timestamp, nonce string) string {
+	sum := sha256.Sum256(body)
+
+	var b strings.Builder
+	b.WriteString("PassChain Signed Request v1\n")
+	b.WriteString("Method: " + strings.ToUpper(method) + "\n")
+	b.WriteString("Path: " + path + "\n")
+	b.WriteString("Query: " + canonicalQuery(rawQuery) + "\n")
+	b.WriteString("Body-SHA256: " + hex.EncodeToString(sum[:]) + "\n")
+	b.WriteString("Timestamp: " + timestamp + "\n")
+	b.WriteString("Nonce: " + nonce)
+	return b.String()
+}
+
+// canonicalQuery sorts parameters by key so clients need not preserve order
+func canonicalQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// CheckClockSkew parses a unix-millis timestamp and checks it is within skew of now
+func CheckClockSkew(timestamp string, skew time.Duration, now time.Time) error {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrMissingTimestamp
+	}
+
+	ts := time.UnixMilli(ms)
+	if now.Sub(ts) > skew || ts.Sub(now) > skew {
+		return ErrClockSkew
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCanonicalRequest(t *testing.T) {
+	got := CanonicalRequest("delete", "/api/v1/credentials/42", "b=2&a=1", []byte(`{"x":1}`), "1700000000000", "n0nce")
+	want := "PassChain Signed Request v1\n" +
+		"Method: DELETE\n" +
+		"Path: /api/v1/credentials/42\n" +
+		"Query: a=1&b=2\n" +
+		"Body-SHA256: 5041bf1f713df204784353e82f6a4a535931cb64f1f4b4a5aeaffcb720918b22\n" +
+		"Timestamp: 1700000000000\n" +
+		"Nonce: n0nce"
+	if got != want {
+		t.Errorf("CanonicalRequest =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCanonicalRequestBindsEveryPart(t *testing.T) {
+	base := func() []string { return []string{"POST", "/api/v1/credentials", "a=1", "{}", "1700000000000", "n0nce"} }
+	build := func(p []string) string { return CanonicalRequest(p[0], p[1], p[2], []byte(p[3]), p[4], p[5]) }
+	reference := build(base())
+
+	tests := []struct {
+		name  string
+		part  int
+		value string
+		same  bool
+	}{
+		{"method case", 0, "post", true},
+		{"method", 0, "PUT", false},
+		{"path", 1, "/api/v1/credentials/1", false},
+		{"query", 2, "a=2", false},
+		{"body", 3, "{ }", false},
+		{"timestamp", 4, "1700000000001", false},
+		{"nonce", 5, "other", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := base()
+			parts[tt.part] = tt.value
+			if same := build(parts) == reference; same != tt.same {
+				t.Errorf("same = %v, want %v", same, tt.same)
+			}
+		})
+	}
+
+	if build([]string{"GET", "/p", "b=2&a=1", "", "1", "n"}) != build([]string{"GET", "/p", "a=1&b=2", "", "1", "n"}) {
+		t.Error("query parameter order changed the canonical request")
+	}
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	skew := 5 * time.Minute
+	at := func(d time.Duration) string { return strconv.FormatInt(now.Add(d).UnixMilli(), 10) }
+
+	tests := []struct {
+		name      string
+		timestamp string
+		wantErr   error
+	}{
+		{"now", at(0), nil},
+		{"just past", at(-skew), nil},
+		{"just ahead", at(skew), nil},
+		{"too old", at(-skew - time.Millisecond), ErrClockSkew},
+		{"too far ahead", at(skew + time.Millisecond), ErrClockSkew},
+		{"seconds not millis", strconv.FormatInt(now.Unix(), 10), ErrClockSkew},
+		{"not a number", "yesterday", ErrMissingTimestamp},
+		{"empty", "", ErrMissingTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckClockSkew(tt.timestamp, skew, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+)
+
+// CoarseIPHash hashes the network prefix of ip (/24 for IPv4, /48 for IPv6)
+// so sessions can be told apart by network without storing client addresses
+func CoarseIPHash(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	var prefix net.IP
+	if v4 := parsed.To4(); v4 != nil {
+		prefix = v4.Mask(net.CIDRMask(24, 32))
+	} else {
+		prefix = parsed.Mask(net.CIDRMask(48, 128))
+	}
+
+	sum := sha256.Sum256(prefix)
+	return hex.EncodeToString(sum[:6])
+}
+
+// DeviceID derives a stable identifier for a browser or client from its user agent
+func DeviceID(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidAddress   = errors.New("invalid wallet address")
+	ErrSignerMismatch   = errors.New("signature does not match wallet address")
+)
+
+// HashPersonalMessage returns the EIP-191 personal_sign digest of message
+func HashPersonalMessage(message []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	fmt.Fprintf(h, "\x19Ethereum Signed Message:\n%d", len(message))
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// RecoverAddress recovers the checksummed address that produced an
+// EIP-191 personal_sign signature (0x-prefixed hex, r || s || v) over message
+func RecoverAddress(message []byte, signature string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return "", ErrInvalidSignature
+	}
+
+	// Wallets emit v as 27/28; some hardware wallets emit 0/1
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", ErrInvalidSignature
+	}
+
+	// decred expects the compact format: header byte || r || s
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+
+	pub, _, err := ecdsa.RecoverCompact(compact, HashPersonalMessage(message))
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	// Address is the last 20 bytes of keccak256(X || Y)
+	h := sha3.NewLegacyKeccak256()
+	h.Write(pub.SerializeUncompressed()[1:])
+	return checksum(h.Sum(nil)[12:]), nil
+}
+
+// VerifySignature checks that signature over message was produced by wallet
+// and returns the checksummed signer address
+func VerifySignature(message []byte, signature, wallet string) (string, error) {
+	if !IsAddress(wallet) {
+		return "", ErrInvalidAddress
+	}
+
+	signer, err := RecoverAddress(message, signature)
+	if err != nil {
+		return "", err
+	}
+
+	if !SameAddress(signer, wallet) {
+		return "", ErrSignerMismatch
+	}
+
+	return signer, nil
+}
+
+// IsAddress reports whether s is a 0x-prefixed 20-byte hex address
+func IsAddress(s string) bool {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
+
+// SameAddress compares two addresses ignoring EIP-55 checksum casing
+func SameAddress(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// ChecksumAddress returns the EIP-55 mixed-case form of address
+func ChecksumAddress(address string) (string, error) {
+	if !IsAddress(address) {
+		return "", ErrInvalidAddress
+	}
+	raw, _ := hex.DecodeString(address[2:])
+	return checksum(raw), nil
+}
+
+func checksum(addr []byte) string {
+	lower := hex.EncodeToString(addr)
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' {
+			continue
+		}
+		// Uppercase the letter if the matching hash nibble is >= 8
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0x0f >= 8 {
+			out[i] = c - 32
+		}
+	}
+
+	return "0x" + string(out)
+}
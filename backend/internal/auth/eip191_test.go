@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// testKey is the private key of the web3.js accounts documentation
+const (
+	testKey     = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testAddress = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+)
+
+// signPersonal signs message as personal_sign does: r || s || v, v = 27/28
+func signPersonal(t *testing.T, message string) string {
+	t.Helper()
+	raw, err := hex.DecodeString(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact := ecdsa.SignCompact(secp256k1.PrivKeyFromBytes(raw), HashPersonalMessage([]byte(message)), false)
+
+	sig := make([]byte, 65)
+	copy(sig, compact[1:])
+	sig[64] = compact[0]
+	return "0x" + hex.EncodeToString(sig)
+}
+
+func TestHashPersonalMessage(t *testing.T) {
+	got := hex.EncodeToString(HashPersonalMessage([]byte("Hello World")))
+	want := "a1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2"
+	if got != want {
+		t.Errorf("HashPersonalMessage = %s, want %s", got, want)
+	}
+}
+
+func TestRecoverAddress(t *testing.T) {
+	signature := signPersonal(t, "Some data")
+	raw, _ := hex.DecodeString(signature[2:])
+
+	lowV := append([]byte(nil), raw...)
+	lowV[64] -= 27
+	badV := append([]byte(nil), raw...)
+	badV[64] = 29
+
+	tests := []struct {
+		name      string
+		message   string
+		signature string
+		want      string
+		wantErr   error
+	}{
+		{"valid", "Some data", signature, testAddress, nil},
+		{"without 0x", "Some data", signature[2:], testAddress, nil},
+		{"v as 0/1", "Some data", "0x" + hex.EncodeToString(lowV), testAddress, nil},
+		{"other message", "Other data", signature, "", nil},
+		{"bad v", "Some data", "0x" + hex.EncodeToString(badV), "", ErrInvalidSignature},
+		{"short", "Some data", signature[:len(signature)-2], "", ErrInvalidSignature},
+		{"not hex", "Some data", "0xzz", "", ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RecoverAddress([]byte(tt.message), tt.signature)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == "" {
+				if got == testAddress {
+					t.Errorf("recovered the signer from a different message")
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RecoverAddress = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	signature := signPersonal(t, "Some data")
+
+	tests := []struct {
+		name    string
+		wallet  string
+		wantErr error
+	}{
+		{"checksummed", testAddress, nil},
+		{"lower case", "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", nil},
+		{"other wallet", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrSignerMismatch},
+		{"not an address", "0x2c7536", ErrInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := VerifySignature([]byte("Some data"), signature, tt.wallet)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && signer != testAddress {
+				t.Errorf("signer = %s, want %s", signer, testAddress)
+			}
+		})
+	}
+}
+
+func TestChecksumAddress(t *testing.T) {
+	// Test vectors from EIP-55
+	tests := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+
+	for _, want := range tests {
+		t.Run(want, func(t *testing.T) {
+			for _, input := range []string{want, "0x" + strings.ToLower(want[2:])} {
+				got, err := ChecksumAddress(input)
+				if err != nil {
+					t.Fatalf("ChecksumAddress(%s): %v", input, err)
+				}
+				if got != want {
+					t.Errorf("ChecksumAddress(%s) = %s, want %s", input, got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := ChecksumAddress("5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("address without 0x: err = %v, want %v", err, ErrInvalidAddress)
+	}
+}
+
+func TestIsAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    bool
+	}{
+		{testAddress, true},
+		{"0x0000000000000000000000000000000000000000", true},
+		{"2c7536E3605D9C16a7a3D7b1898e529396a65c23", false},
+		{"0x2c7536E3605D9C16a7a3D7b1898e529396a65c2", false},
+		{"0x2c7536E3605D9C16a7a3D7b1898e529396a65cZZ", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAddress(tt.address); got != tt.want {
+			t.Errorf("IsAddress(%q) = %v, want %v", tt.address, got, tt.want)
+		}
+	}
+}
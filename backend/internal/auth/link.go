@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+)
+
+// LinkWalletMessage is the text both wallets sign to link newWallet to the
+// account that accountWallet belongs to
+func LinkWalletMessage(accountWallet, newWallet, nonce string, expiresAt time.Time) string {
+	return fmt.Sprintf(
+		"PassChain Link Wallet\n\nAccount wallet: %s\nNew wallet: %s\nNonce: %s\nExpires: %s\n\nBoth wallets will be able to access the same credentials.",
+		accountWallet, newWallet, nonce, expiresAt.UTC().Format(time.RFC3339),
+	)
+}
+
+// UnlinkWalletMessage is the text signed to remove wallet from its account,
+// by wallet itself or by a wallet linked to the account before it
+func UnlinkWalletMessage(wallet, nonce string, expiresAt time.Time) string {
+	return fmt.Sprintf(
+		"PassChain Unlink Wallet\n\nWallet: %s\nNonce: %s\nExpires: %s\n\nThis wallet will lose access to the account's credentials.",
+		wallet, nonce, expiresAt.UTC().Format(time.RFC3339),
+	)
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingTimestamp = errors.New("signed message has no timestamp")
+	ErrStaleMessage     = errors.New("signed message has expired")
+	ErrNotAuthMessage   = errors.New("signed message is not an auth message for this domain and wallet")
+)
+
+// authMessagePreamble is the first line of every auth message
+const authMessagePreamble = "pass-chain auth"
+
+// AuthMessageHeader is how a message signed for X-Auth-Message login starts:
+// the preamble, then the domain and wallet it was made for. Other messages the
+// wallet signs for the app (SIWE, signed requests, link challenges) never
+// start like this, so their signatures cannot be replayed as a login.
+func AuthMessageHeader(domain, wallet string) string {
+	return authMessagePreamble + "\nDomain: " + domain + "\nWallet: " + wallet + "\n"
+}
+
+// CheckAuthMessage verifies message starts with AuthMessageHeader for domain
+// and wallet (compared case-insensitively)
+func CheckAuthMessage(message []byte, domain, wallet string) error {
+	lines := strings.SplitN(string(message), "\n", 4)
+	if len(lines) < 4 || lines[0] != authMessagePreamble || lines[1] != "Domain: "+domain {
+		return ErrNotAuthMessage
+	}
+	signedFor, ok := strings.CutPrefix(lines[2], "Wallet: ")
+	if !ok || !SameAddress(signedFor, wallet) {
+		return ErrNotAuthMessage
+	}
+	return nil
+}
+
+// MessageTimestamp extracts the "Timestamp: <unix millis>" line the frontend
+// embeds in every message it asks the wallet to sign
+func MessageTimestamp(message []byte) (time.Time, error) {
+	for _, line := range strings.Split(string(message), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "Timestamp:")
+		if !ok {
+			continue
+		}
+		ms, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return time.Time{}, ErrMissingTimestamp
+		}
+		return time.UnixMilli(ms), nil
+	}
+
+	return time.Time{}, ErrMissingTimestamp
+}
+
+// CheckFreshness rejects messages signed more than maxAge ago (or too far in the future)
+func CheckFreshness(message []byte, maxAge time.Duration, now time.Time) error {
+	ts, err := MessageTimestamp(message)
+	if err != nil {
+		return err
+	}
+
+	if now.Sub(ts) > maxAge || ts.Sub(now) > maxAge {
+		return ErrStaleMessage
+	}
+
+	return nil
+}
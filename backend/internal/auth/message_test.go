@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCheckAuthMessage(t *testing.T) {
+	const domain = "app.example.com"
+	header := AuthMessageHeader(domain, testAddress)
+
+	tests := []struct {
+		name    string
+		message string
+		wallet  string
+		wantErr error
+	}{
+		{"valid", header + "Action: login\nTimestamp: 1", testAddress, nil},
+		{"wallet case", header + "Action: login", "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", nil},
+		{"header only", header, testAddress, nil},
+		{"other wallet", header + "Action: login", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrNotAuthMessage},
+		{"other domain", AuthMessageHeader("evil.example.com", testAddress) + "Action: login", testAddress, ErrNotAuthMessage},
+		{"no preamble", "Domain: " + domain + "\nWallet: " + testAddress + "\nAction: login", testAddress, ErrNotAuthMessage},
+		{"signed request", CanonicalRequest("GET", "/", "", nil, "1", "n"), testAddress, ErrNotAuthMessage},
+		{"siwe", siweMessage, testAddress, ErrNotAuthMessage},
+		{"truncated", "pass-chain auth\nDomain: " + domain, testAddress, ErrNotAuthMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckAuthMessage([]byte(tt.message), domain, tt.wallet); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckFreshness(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	maxAge := 5 * time.Minute
+	signedAt := func(d time.Duration) string {
+		return "Action: login\nTimestamp: " + strconv.FormatInt(now.Add(d).UnixMilli(), 10) + "\n"
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		wantErr error
+	}{
+		{"fresh", signedAt(-time.Minute), nil},
+		{"indented", "Action: login\n  Timestamp: " + strconv.FormatInt(now.UnixMilli(), 10), nil},
+		{"too old", signedAt(-maxAge - time.Second), ErrStaleMessage},
+		{"from the future", signedAt(maxAge + time.Second), ErrStaleMessage},
+		{"no timestamp", "Action: login", ErrMissingTimestamp},
+		{"bad timestamp", "Timestamp: soon", ErrMissingTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckFreshness([]byte(tt.message), maxAge, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSIWEMessage = errors.New("invalid SIWE message")
+	ErrSIWEDomainMismatch = errors.New("SIWE domain mismatch")
+	ErrSIWEURIMismatch    = errors.New("SIWE URI mismatch")
+	ErrSIWEChainMismatch  = errors.New("SIWE chain ID mismatch")
+	ErrSIWEExpired        = errors.New("SIWE message expired")
+	ErrSIWENotYetValid    = errors.New("SIWE message not yet valid")
+)
+
+const siwePreamble = " wants you to sign in with your Ethereum account:"
+
+// SIWEMessage is a parsed EIP-4361 Sign-In-With-Ethereum message
+type SIWEMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// SIWEExpectations are the server-side values a SIWE message must match
+type SIWEExpectations struct {
+	Domain  string
+	URI     string
+	ChainID int64
+	Now     time.Time
+}
+
+// ParseSIWEMessage parses the EIP-4361 text format
+func ParseSIWEMessage(raw string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, ErrInvalidSIWEMessage
+	}
+
+	domain, ok := strings.CutSuffix(lines[0], siwePreamble)
+	if !ok || domain == "" {
+		return nil, fmt.Errorf("%w: missing preamble", ErrInvalidSIWEMessage)
+	}
+
+	msg := &SIWEMessage{Domain: domain, Address: strings.TrimSpace(lines[1])}
+	if !IsAddress(msg.Address) {
+		return nil, fmt.Errorf("%w: bad address", ErrInvalidSIWEMessage)
+	}
+
+	// Everything between the address and the "URI:" field is the optional statement
+	i := 2
+	var statement []string
+	for ; i < len(lines) && !strings.HasPrefix(lines[i], "URI: "); i++ {
+		statement = append(statement, lines[i])
+	}
+	msg.Statement = strings.TrimSpace(strings.Join(statement, "\n"))
+
+	inResources := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if inResources {
+			if res, ok := strings.CutPrefix(line, "- "); ok {
+				msg.Resources = append(msg.Resources, res)
+				continue
+			}
+		}
+		if line == "" {
+			continue
+		}
+		if line == "Resources:" {
+			inResources = true
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed line %q", ErrInvalidSIWEMessage, line)
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID, err = strconv.ParseInt(value, 10, 64)
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.ExpirationTime = &t
+		case "Not Before":
+			var t time.Time
+			t, err = time.Parse(time.RFC3339, value)
+			msg.NotBefore = &t
+		case "Request ID":
+			msg.RequestID = value
+		default:
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSIWEMessage, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad %s", ErrInvalidSIWEMessage, key)
+		}
+	}
+
+	if msg.URI == "" || msg.Version != "1" || msg.ChainID == 0 || len(msg.Nonce) < 8 || msg.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("%w: missing required field", ErrInvalidSIWEMessage)
+	}
+
+	return msg, nil
+}
+
+// Validate checks the message against the server's domain, URI, chain and clock.
+// The nonce is checked separately by the caller against its nonce store.
+func (m *SIWEMessage) Validate(exp SIWEExpectations) error {
+	if m.Domain != exp.Domain {
+		return ErrSIWEDomainMismatch
+	}
+
+	// Accept the configured origin itself or any page below it
+	if m.URI != exp.URI && !strings.HasPrefix(m.URI, strings.TrimSuffix(exp.URI, "/")+"/") {
+		return ErrSIWEURIMismatch
+	}
+
+	if m.ChainID != exp.ChainID {
+		return ErrSIWEChainMismatch
+	}
+
+	if m.ExpirationTime != nil && !exp.Now.Before(*m.ExpirationTime) {
+		return ErrSIWEExpired
+	}
+
+	if m.NotBefore != nil && exp.Now.Before(*m.NotBefore) {
+		return ErrSIWENotYetValid
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const siweMessage = `app.example.com wants you to sign in with your Ethereum account:
+0x2c7536E3605D9C16a7a3D7b1898e529396a65c23
+
+Sign in to pass-chain
+
+URI: https://app.example.com/login
+Version: 1
+Chain ID: 1
+Nonce: abcdef123456
+Issued At: 2024-05-01T10:00:00Z
+Expiration Time: 2024-05-01T10:10:00Z
+Not Before: 2024-05-01T09:59:00Z
+Request ID: req-1
+Resources:
+- https://app.example.com/terms
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq`
+
+func TestParseSIWEMessage(t *testing.T) {
+	msg, err := ParseSIWEMessage(siweMessage)
+	if err != nil {
+		t.Fatalf("ParseSIWEMessage: %v", err)
+	}
+
+	issued := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	switch {
+	case msg.Domain != "app.example.com":
+		t.Errorf("Domain = %q", msg.Domain)
+	case msg.Address != testAddress:
+		t.Errorf("Address = %q", msg.Address)
+	case msg.Statement != "Sign in to pass-chain":
+		t.Errorf("Statement = %q", msg.Statement)
+	case msg.URI != "https://app.example.com/login":
+		t.Errorf("URI = %q", msg.URI)
+	case msg.ChainID != 1:
+		t.Errorf("ChainID = %d", msg.ChainID)
+	case msg.Nonce != "abcdef123456":
+		t.Errorf("Nonce = %q", msg.Nonce)
+	case !msg.IssuedAt.Equal(issued):
+		t.Errorf("IssuedAt = %v", msg.IssuedAt)
+	case msg.ExpirationTime == nil || !msg.ExpirationTime.Equal(issued.Add(10*time.Minute)):
+		t.Errorf("ExpirationTime = %v", msg.ExpirationTime)
+	case msg.NotBefore == nil || !msg.NotBefore.Equal(issued.Add(-time.Minute)):
+		t.Errorf("NotBefore = %v", msg.NotBefore)
+	case msg.RequestID != "req-1":
+		t.Errorf("RequestID = %q", msg.RequestID)
+	case len(msg.Resources) != 2:
+		t.Errorf("Resources = %v", msg.Resources)
+	}
+}
+
+func TestParseSIWEMessageInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+	}{
+		{"no preamble", [2]string{" wants you to sign in with your Ethereum account:", ""}},
+		{"bad address", [2]string{testAddress, "0x1234"}},
+		{"wrong version", [2]string{"Version: 1", "Version: 2"}},
+		{"no URI", [2]string{"URI: https://app.example.com/login\n", ""}},
+		{"bad chain ID", [2]string{"Chain ID: 1", "Chain ID: one"}},
+		{"short nonce", [2]string{"Nonce: abcdef123456", "Nonce: abc"}},
+		{"bad issued at", [2]string{"Issued At: 2024-05-01T10:00:00Z", "Issued At: yesterday"}},
+		{"unknown field", [2]string{"Request ID: req-1", "Color: blue"}},
+		{"malformed line", [2]string{"Request ID: req-1", "Request ID"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := strings.Replace(siweMessage, tt.replace[0], tt.replace[1], 1)
+			if _, err := ParseSIWEMessage(raw); !errors.Is(err, ErrInvalidSIWEMessage) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidSIWEMessage)
+			}
+		})
+	}
+}
+
+func TestParseSIWEMessageCRLF(t *testing.T) {
+	msg, err := ParseSIWEMessage(strings.ReplaceAll(siweMessage, "\n", "\r\n"))
+	if err != nil {
+		t.Fatalf("ParseSIWEMessage: %v", err)
+	}
+	if msg.Nonce != "abcdef123456" {
+		t.Errorf("Nonce = %q", msg.Nonce)
+	}
+}
+
+func TestSIWEValidate(t *testing.T) {
+	msg, err := ParseSIWEMessage(siweMessage)
+	if err != nil {
+		t.Fatalf("ParseSIWEMessage: %v", err)
+	}
+
+	valid := SIWEExpectations{
+		Domain:  "app.example.com",
+		URI:     "https://app.example.com",
+		ChainID: 1,
+		Now:     time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name    string
+		change  func(*SIWEExpectations)
+		wantErr error
+	}{
+		{"valid", func(*SIWEExpectations) {}, nil},
+		{"exact URI", func(e *SIWEExpectations) { e.URI = "https://app.example.com/login" }, nil},
+		{"URI with trailing slash", func(e *SIWEExpectations) { e.URI = "https://app.example.com/" }, nil},
+		{"other domain", func(e *SIWEExpectations) { e.Domain = "evil.example.com" }, ErrSIWEDomainMismatch},
+		{"URI prefix only", func(e *SIWEExpectations) { e.URI = "https://app.example.co" }, ErrSIWEURIMismatch},
+		{"other chain", func(e *SIWEExpectations) { e.ChainID = 137 }, ErrSIWEChainMismatch},
+		{"at expiry", func(e *SIWEExpectations) { e.Now = time.Date(2024, 5, 1, 10, 10, 0, 0, time.UTC) }, ErrSIWEExpired},
+		{"before not before", func(e *SIWEExpectations) { e.Now = time.Date(2024, 5, 1, 9, 58, 0, 0, time.UTC) }, ErrSIWENotYetValid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := valid
+			tt.change(&exp)
+			if err := msg.Validate(exp); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+)
+
+// RevealMessage is the text a wallet signs to reveal one credential's shares.
+// It binds the wallet, the credential and a single-use server nonce, and is
+// only valid until expiresAt.
+func RevealMessage(wallet, credentialID, nonce string, expiresAt time.Time) string {
+	return fmt.Sprintf(
+		"PassChain Credential Reveal\n\nWallet: %s\nCredential: %s\nNonce: %s\nExpires: %s\n\nSign only if you are revealing this credential now.",
+		wallet, credentialID, nonce, expiresAt.UTC().Format(time.RFC3339),
+	)
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+)
+
+const nonceAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// NewNonce returns a random alphanumeric nonce (SIWE requires at least 8 chars)
+func NewNonce() (string, error) {
+	out := make([]byte, 17)
+	max := big.NewInt(int64(len(nonceAlphabet)))
+	for i := range out {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		out[i] = nonceAlphabet[n.Int64()]
+	}
+	return string(out), nil
+}
+
+// NewSessionToken returns an opaque bearer token and the hash it is stored under.
+// Only the hash is kept server-side so a leaked store cannot be replayed.
+func NewSessionToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex SHA-256 of a bearer token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +15,9 @@ type Config struct {
 	Database    DatabaseConfig
 	Vault       VaultConfig
 	Redis       RedisConfig
+	Worker      WorkerConfig
+	Backup      BackupConfig
+	Auth        AuthConfig
 }
 
 type ServerConfig struct {
@@ -42,6 +46,43 @@ type RedisConfig struct {
 	Password string
 }
 
+type WorkerConfig struct {
+	ReaperInterval int // seconds between expiry sweeps
+	ExpiryWarning  int // seconds before expiry the owner is notified
+	PurgeInterval  int // seconds between trash purges
+	TrashRetention int // seconds a deleted credential stays restorable
+
+	EmergencyInterval int // seconds between grants of emergency requests past their wait
+	RefreshInterval   int // seconds between share refresh sweeps
+	ShareMaxAge       int // seconds before share1 and share2 of a credential are re-shared
+}
+
+type BackupConfig struct {
+	SigningKey  string   // base64 Ed25519 seed; export is disabled without it
+	TrustedKeys []string // base64 Ed25519 public keys of other deployments whose archives restore here
+	MaxSize     int      // bytes accepted by a restore
+}
+
+type AuthConfig struct {
+	SignatureMaxAge int // seconds a signed wallet message stays valid
+	ClockSkew       int // seconds a signed request timestamp may differ from server time
+	RevealTTL       int // seconds a credential reveal challenge stays valid
+	ApprovalTTL     int // seconds an M-of-N reveal approval request stays open
+
+	// Sign-In-With-Ethereum (EIP-4361)
+	SIWEDomain string
+	SIWEURI    string
+	ChainID    int64
+	NonceTTL   int // seconds
+	SessionTTL int // seconds
+
+	// WebAuthn passkeys (second factor for sensitive credentials)
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins []string
+	PasskeyTTL      int // seconds a passkey assertion stays usable
+}
+
 func Load() (*Config, error) {
 	// Load .env file if it exists
 	godotenv.Load()
@@ -70,6 +111,36 @@ func Load() (*Config, error) {
 			Port:     getEnvAsInt("REDIS_PORT", 6379),
 			Password: getEnv("REDIS_PASSWORD", ""),
 		},
+		Worker: WorkerConfig{
+			ReaperInterval: getEnvAsInt("WORKER_REAPER_INTERVAL", 60),
+			ExpiryWarning:  getEnvAsInt("CREDENTIAL_EXPIRY_WARNING", 86400),
+			PurgeInterval:  getEnvAsInt("WORKER_PURGE_INTERVAL", 3600),
+			TrashRetention: getEnvAsInt("CREDENTIAL_TRASH_RETENTION", 2592000), // 30 days
+
+			EmergencyInterval: getEnvAsInt("WORKER_EMERGENCY_INTERVAL", 300),
+			RefreshInterval:   getEnvAsInt("WORKER_REFRESH_INTERVAL", 3600),
+			ShareMaxAge:       getEnvAsInt("CREDENTIAL_SHARE_MAX_AGE", 2592000), // 30 days
+		},
+		Backup: BackupConfig{
+			SigningKey:  getEnv("BACKUP_SIGNING_KEY", ""),
+			TrustedKeys: getEnvAsList("BACKUP_TRUSTED_KEYS", nil),
+			MaxSize:     getEnvAsInt("BACKUP_MAX_SIZE", 64<<20),
+		},
+		Auth: AuthConfig{
+			SignatureMaxAge: getEnvAsInt("AUTH_SIGNATURE_MAX_AGE", 300),
+			ClockSkew:       getEnvAsInt("AUTH_CLOCK_SKEW", 120),
+			RevealTTL:       getEnvAsInt("AUTH_REVEAL_TTL", 30),
+			ApprovalTTL:     getEnvAsInt("AUTH_APPROVAL_TTL", 3600),
+			SIWEDomain:      getEnv("AUTH_SIWE_DOMAIN", "localhost:3000"),
+			SIWEURI:         getEnv("AUTH_SIWE_URI", "http://localhost:3000"),
+			ChainID:         int64(getEnvAsInt("AUTH_CHAIN_ID", 1)),
+			NonceTTL:        getEnvAsInt("AUTH_NONCE_TTL", 300),
+			SessionTTL:      getEnvAsInt("AUTH_SESSION_TTL", 900),
+			WebAuthnRPID:    getEnv("AUTH_WEBAUTHN_RP_ID", "localhost"),
+			WebAuthnRPName:  getEnv("AUTH_WEBAUTHN_RP_NAME", "PassChain"),
+			WebAuthnOrigins: getEnvAsList("AUTH_WEBAUTHN_ORIGINS", []string{"http://localhost:3000"}),
+			PasskeyTTL:      getEnvAsInt("AUTH_PASSKEY_TTL", 60),
+		},
 	}
 
 	return cfg, nil
@@ -91,6 +162,21 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsList reads a comma-separated list
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func (c *Config) Validate() error {
 	if c.Database.Password == "" {
 		return fmt.Errorf("database password is required")
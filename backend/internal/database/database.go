@@ -35,7 +35,27 @@ func New(cfg config.DatabaseConfig) (*Database, error) {
 func (db *Database) Migrate() error {
 	return db.AutoMigrate(
 		&models.Credential{},
+		&models.CredentialVersion{},
 		&models.AuditLog{},
+		&models.APIKey{},
+		&models.Account{},
+		&models.AccountWallet{},
+		&models.Passkey{},
+		&models.Folder{},
+		&models.Tag{},
+		&models.Notification{},
+		&models.CredentialShare{},
+		&models.WalletKey{},
+		&models.ShareLink{},
+		&models.Organization{},
+		&models.OrgMember{},
+		&models.OrgMemberKey{},
+		&models.ApprovalRequest{},
+		&models.Approval{},
+		&models.EmergencyContact{},
+		&models.EmergencyRequest{},
+		&models.EmergencyEscrow{},
+		&models.RefreshDelta{},
 	)
 }
 
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/models"
+)
+
+// authenticateAPIKey resolves a presented X-API-Key to an active key record
+func authenticateAPIKey(c *gin.Context, db *database.Database, rawKey string) (*models.APIKey, error) {
+	lookupID, err := auth.ParseAPIKey(rawKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var key models.APIKey
+	if err := db.WithContext(c.Request.Context()).Where("lookup_id = ?", lookupID).First(&key).Error; err != nil {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !auth.CheckAPIKey(rawKey, key.KeyHash) || !key.Active(now) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	db.Model(&key).Update("last_used_at", now)
+
+	return &key, nil
+}
+
+// APIKey returns the service-account key set by WalletAuth, or nil for wallet callers
+func APIKey(c *gin.Context) *models.APIKey {
+	if key, ok := c.Get(ContextAPIKey); ok {
+		return key.(*models.APIKey)
+	}
+	return nil
+}
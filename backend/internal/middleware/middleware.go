@@ -1,9 +1,18 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"pass-chain/backend/internal/auth"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/services"
 	"pass-chain/backend/pkg/logger"
 )
 
@@ -41,7 +50,7 @@ func Logger(log *logger.Logger) gin.HandlerFunc {
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
-		
+
 		// Allow requests from localhost (dev) and minikube IP (prod)
 		allowedOrigins := []string{
 			"http://localhost:3000",
@@ -49,7 +58,7 @@ func CORS() gin.HandlerFunc {
 			"http://127.0.0.1:3000",
 			"http://192.168.49.2:30300",
 		}
-		
+
 		isAllowed := false
 		for _, allowed := range allowedOrigins {
 			if origin == allowed {
@@ -57,16 +66,16 @@ func CORS() gin.HandlerFunc {
 				break
 			}
 		}
-		
+
 		if isAllowed {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 		} else {
 			// In development, allow all origins
 			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		}
-		
+
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Wallet-Address, X-Signature")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Wallet-Address, X-Signature, X-Auth-Message, X-Timestamp, X-Nonce, X-API-Key, X-Reveal-Nonce, X-Reveal-Signature, X-Unlink-Nonce, X-Unlink-Signature, X-Approval-Request, X-Passkey-Token, X-Backup-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -78,11 +87,270 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
-// WalletAuth middleware for wallet-based authentication
-func WalletAuth(secret string) gin.HandlerFunc {
+const (
+	// ContextWalletAddress is the gin context key holding the verified wallet address
+	ContextWalletAddress = "walletAddress"
+	// ContextSessionTokenHash holds the token hash when the request used a session
+	ContextSessionTokenHash = "sessionTokenHash"
+	// ContextSessionID holds the public session ID when the request used a session
+	ContextSessionID = "sessionID"
+	// ContextRequestSigned is true once the canonical request signature was verified
+	ContextRequestSigned = "requestSigned"
+	// ContextAPIKey holds the *models.APIKey when a service account authenticated
+	ContextAPIKey = "apiKey"
+)
+
+// WalletAuthConfig wires the stores WalletAuth checks credentials against
+type WalletAuthConfig struct {
+	Sessions services.SessionStore
+	Nonces   services.NonceStore
+	DB       *database.Database
+
+	MaxAge    time.Duration // How long a signed X-Auth-Message stays valid
+	ClockSkew time.Duration // Allowed drift of X-Timestamp on signed requests
+	Domain    string        // Domain X-Auth-Messages must be made for (auth.AuthMessageHeader)
+
+	// AllowAPIKeys accepts service-account keys (X-API-Key). Only enabled on
+	// the credential routes; keys cannot manage sessions or other keys.
+	AllowAPIKeys bool
+}
+
+// WalletAuth middleware for wallet-based authentication.
+//
+// A request authenticates either with a session issued by /auth/login:
+//   - Authorization: Bearer <session token>
+//
+// or, where cfg.AllowAPIKeys is set, with a service-account key:
+//   - X-API-Key: pck_<lookup id>_<secret>
+//
+// or by signing the canonical request (see SignedRequest) and sending
+// X-Wallet-Address, X-Signature, X-Timestamp and X-Nonce,
+//
+// or by signing a message with personal_sign (EIP-191) and sending:
+//   - X-Wallet-Address: the claimed wallet
+//   - X-Signature: the 0x-prefixed signature
+//   - X-Auth-Message: the signed message, base64 encoded (it contains newlines)
+//
+// For signatures the signer is recovered and must match the claimed wallet.
+// An X-Auth-Message must start with auth.AuthMessageHeader for cfg.Domain and
+// that wallet, and carry a "Timestamp:" line no older than cfg.MaxAge.
+// Each signed message is accepted once: its fingerprint is kept in cfg.Nonces
+// until it would have expired anyway, so a captured signature cannot be replayed.
+func WalletAuth(cfg WalletAuthConfig) gin.HandlerFunc {
+	sessions, nonces, maxAge, skew := cfg.Sessions, cfg.Nonces, cfg.MaxAge, cfg.ClockSkew
+
+	return func(c *gin.Context) {
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			if !cfg.AllowAPIKeys {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys are not accepted on this route"})
+				return
+			}
+
+			key, err := authenticateAPIKey(c, cfg.DB, rawKey)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid, expired or revoked API key"})
+				return
+			}
+
+			c.Set(ContextWalletAddress, key.WalletAddress)
+			c.Set(ContextAPIKey, key)
+			c.Next()
+			return
+		}
+
+		if token, ok := bearerToken(c); ok {
+			tokenHash := auth.HashToken(token)
+			session, err := sessions.Get(c.Request.Context(), tokenHash)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired session"})
+				return
+			}
+
+			// Record activity at most once a minute to keep store writes down
+			now := time.Now()
+			if ipHash := auth.CoarseIPHash(c.ClientIP()); now.Sub(session.LastSeenAt) > time.Minute || ipHash != session.IPHash {
+				if err := sessions.Touch(c.Request.Context(), tokenHash, now, ipHash); err != nil {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired session"})
+					return
+				}
+			}
+
+			c.Set(ContextWalletAddress, session.WalletAddress)
+			c.Set(ContextSessionTokenHash, tokenHash)
+			c.Set(ContextSessionID, session.ID)
+			c.Next()
+			return
+		}
+
+		if c.GetHeader("X-Timestamp") != "" {
+			signer, status, errMsg := verifySignedRequest(c, nonces, skew)
+			if errMsg != "" {
+				c.AbortWithStatusJSON(status, gin.H{"error": errMsg})
+				return
+			}
+
+			c.Set(ContextWalletAddress, signer)
+			c.Set(ContextRequestSigned, true)
+			c.Next()
+			return
+		}
+
+		wallet := c.GetHeader("X-Wallet-Address")
+		signature := c.GetHeader("X-Signature")
+		encoded := c.GetHeader("X-Auth-Message")
+
+		if wallet == "" || signature == "" || encoded == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Wallet authentication required"})
+			return
+		}
+
+		message, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid auth message encoding"})
+			return
+		}
+
+		if err := auth.CheckFreshness(message, maxAge, time.Now()); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Auth message expired or missing timestamp"})
+			return
+		}
+
+		signer, err := auth.VerifySignature(message, signature, wallet)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid wallet signature"})
+			return
+		}
+
+		if err := auth.CheckAuthMessage(message, cfg.Domain, signer); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not an auth message for this site and wallet"})
+			return
+		}
+
+		// Fingerprint the signed digest rather than the signature bytes, which are malleable
+		fingerprint := "sig:" + hex.EncodeToString(auth.HashPersonalMessage(message))
+		fresh, err := nonces.MarkSeen(c.Request.Context(), fingerprint, 2*maxAge)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Failed to check signature replay"})
+			return
+		}
+		if !fresh {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Signature already used"})
+			return
+		}
+
+		c.Set(ContextWalletAddress, signer)
+		c.Next()
+	}
+}
+
+// SignedRequest middleware requires mutating calls to carry a signature over
+// the canonical request (auth.CanonicalRequest), even when the caller is
+// already authenticated with a session. Headers:
+//   - X-Wallet-Address: the signing wallet (must be the authenticated wallet)
+//   - X-Signature: personal_sign signature over the canonical request
+//   - X-Timestamp: unix millis, must be within skew of the server clock
+//   - X-Nonce: client-chosen random string, accepted once per wallet
+//
+// Service accounts cannot sign; an API key passes only if it has write scope.
+//
+// Must run after WalletAuth.
+func SignedRequest(nonces services.NonceStore, skew time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// For now, just pass through
-		// TODO: Implement actual wallet signature verification
+		if c.GetBool(ContextRequestSigned) {
+			c.Next()
+			return
+		}
+
+		if key := APIKey(c); key != nil {
+			if !key.CanWrite() {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is read-only"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		signer, status, errMsg := verifySignedRequest(c, nonces, skew)
+		if errMsg != "" {
+			c.AbortWithStatusJSON(status, gin.H{"error": errMsg})
+			return
+		}
+
+		if !auth.SameAddress(signer, WalletAddress(c)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Request signed by a different wallet"})
+			return
+		}
+
+		c.Set(ContextRequestSigned, true)
 		c.Next()
 	}
 }
+
+// verifySignedRequest checks the canonical request signature headers and
+// returns the signer, or an HTTP status and error message
+func verifySignedRequest(c *gin.Context, nonces services.NonceStore, skew time.Duration) (string, int, string) {
+	wallet := c.GetHeader("X-Wallet-Address")
+	signature := c.GetHeader("X-Signature")
+	timestamp := c.GetHeader("X-Timestamp")
+	nonce := c.GetHeader("X-Nonce")
+
+	if wallet == "" || signature == "" || timestamp == "" || nonce == "" {
+		return "", http.StatusUnauthorized, "Signed request required"
+	}
+
+	if err := auth.CheckClockSkew(timestamp, skew, time.Now()); err != nil {
+		return "", http.StatusUnauthorized, "Request timestamp outside allowed window"
+	}
+
+	// Read the body for hashing and put it back for the handler
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", http.StatusBadRequest, "Failed to read request body"
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	canonical := auth.CanonicalRequest(c.Request.Method, c.Request.URL.EscapedPath(), c.Request.URL.RawQuery, body, timestamp, nonce)
+
+	signer, err := auth.VerifySignature([]byte(canonical), signature, wallet)
+	if err != nil {
+		return "", http.StatusUnauthorized, "Invalid request signature"
+	}
+
+	// The nonce is single-use per wallet for as long as the timestamp is acceptable
+	fresh, err := nonces.MarkSeen(c.Request.Context(), "req:"+strings.ToLower(signer)+":"+nonce, 2*skew)
+	if err != nil {
+		return "", http.StatusServiceUnavailable, "Failed to check signature replay"
+	}
+	if !fresh {
+		return "", http.StatusUnauthorized, "Request nonce already used"
+	}
+
+	return signer, 0, ""
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// WalletAddress returns the wallet verified by WalletAuth, or "" if none
+func WalletAddress(c *gin.Context) string {
+	return c.GetString(ContextWalletAddress)
+}
+
+// SessionTokenHash returns the session token hash set by WalletAuth, or "" for signature auth
+func SessionTokenHash(c *gin.Context) string {
+	return c.GetString(ContextSessionTokenHash)
+}
+
+// SessionID returns the public ID of the session set by WalletAuth, or ""
+func SessionID(c *gin.Context) string {
+	return c.GetString(ContextSessionID)
+}
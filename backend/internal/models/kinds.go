@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Credential kinds. EncryptedData always holds the main secret (password,
+// token, private key, TOTP seed or note body); Metadata holds the extras.
+const (
+	KindLogin    = "login"
+	KindAPIToken = "api_token"
+	KindSSHKey   = "ssh_key"
+	KindTOTP     = "totp"
+	KindNote     = "note"
+)
+
+// kindSpec lists the metadata keys a kind requires and allows
+type kindSpec struct {
+	username bool // Username is required
+	required []string
+	optional []string
+}
+
+var kindSpecs = map[string]kindSpec{
+	KindLogin:    {username: true, optional: []string{"notes", "totpSeed"}},
+	KindAPIToken: {optional: []string{"provider", "scopes", "expiresAt", "notes"}},
+	KindSSHKey:   {required: []string{"publicKey"}, optional: []string{"passphrase", "comment"}},
+	KindTOTP:     {required: []string{"issuer"}, optional: []string{"accountName", "algorithm", "digits", "period"}},
+	KindNote:     {},
+}
+
+const (
+	// minSealedSize is a 12-byte AES-GCM nonce plus the 16-byte tag
+	minSealedSize = 28
+	// maxSealedSize bounds one metadata value (an SSH key fits comfortably)
+	maxSealedSize = 16 << 10
+)
+
+// ValidKind reports whether kind is a known credential kind
+func ValidKind(kind string) bool {
+	_, ok := kindSpecs[kind]
+	return ok
+}
+
+// ValidateKind checks the username and metadata of a credential of the given
+// kind. Metadata values are client-encrypted with the credential key, each
+// as base64(nonce || ciphertext), so only their keys and shape are checked.
+func ValidateKind(kind, username string, metadata map[string]string) error {
+	spec, ok := kindSpecs[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+
+	if spec.username && username == "" {
+		return fmt.Errorf("username is required for %s credentials", kind)
+	}
+
+	for _, key := range spec.required {
+		if _, ok := metadata[key]; !ok {
+			return fmt.Errorf("metadata.%s is required for %s credentials", key, kind)
+		}
+	}
+
+	for key, value := range metadata {
+		if !containsKey(spec.required, key) && !containsKey(spec.optional, key) {
+			return fmt.Errorf("metadata.%s is not allowed for %s credentials", key, kind)
+		}
+		sealed, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(sealed) < minSealedSize || len(sealed) > maxSealedSize {
+			return fmt.Errorf("metadata.%s must be an encrypted value", key)
+		}
+	}
+
+	return nil
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
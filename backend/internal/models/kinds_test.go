@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestValidateKind(t *testing.T) {
+	sealed := base64.StdEncoding.EncodeToString(make([]byte, minSealedSize))
+	tooShort := base64.StdEncoding.EncodeToString(make([]byte, minSealedSize-1))
+	tooLong := base64.StdEncoding.EncodeToString(make([]byte, maxSealedSize+1))
+
+	tests := []struct {
+		name     string
+		kind     string
+		username string
+		metadata map[string]string
+		wantErr  string
+	}{
+		{"login", KindLogin, "alice", map[string]string{"notes": sealed, "totpSeed": sealed}, ""},
+		{"login without username", KindLogin, "", nil, "username is required"},
+		{"login with foreign key", KindLogin, "alice", map[string]string{"publicKey": sealed}, "metadata.publicKey is not allowed"},
+		{"api token", KindAPIToken, "", map[string]string{"provider": sealed, "scopes": sealed, "expiresAt": sealed}, ""},
+		{"ssh key", KindSSHKey, "", map[string]string{"publicKey": sealed, "passphrase": sealed}, ""},
+		{"ssh key without public key", KindSSHKey, "", map[string]string{"comment": sealed}, "metadata.publicKey is required"},
+		{"totp", KindTOTP, "", map[string]string{"issuer": sealed, "digits": sealed}, ""},
+		{"totp without issuer", KindTOTP, "", nil, "metadata.issuer is required"},
+		{"note", KindNote, "", nil, ""},
+		{"note with metadata", KindNote, "", map[string]string{"notes": sealed}, "metadata.notes is not allowed"},
+		{"plaintext value", KindSSHKey, "", map[string]string{"publicKey": "ssh-ed25519 AAAA"}, "must be an encrypted value"},
+		{"value too short", KindTOTP, "", map[string]string{"issuer": tooShort}, "must be an encrypted value"},
+		{"value too long", KindTOTP, "", map[string]string{"issuer": tooLong}, "must be an encrypted value"},
+		{"unknown kind", "password", "alice", nil, "unknown kind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKind(tt.kind, tt.username, tt.metadata)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidKind(t *testing.T) {
+	for _, kind := range []string{KindLogin, KindAPIToken, KindSSHKey, KindTOTP, KindNote} {
+		if !ValidKind(kind) {
+			t.Errorf("ValidKind(%q) = false", kind)
+		}
+	}
+	if ValidKind("") || ValidKind("Login") {
+		t.Error("ValidKind accepted an unknown kind")
+	}
+}
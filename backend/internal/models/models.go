@@ -3,38 +3,191 @@ package models
 import (
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"gorm.io/gorm"
 )
 
 // Credential represents an encrypted credential
 type Credential struct {
-	ID             string         `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
-	CredentialName string         `gorm:"column:credential_name;not null" json:"name"`
-	Username       string         `gorm:"not null" json:"username"`
-	URL            string         `json:"url,omitempty"`
-	EncryptedData  string         `gorm:"type:text;not null" json:"encryptedData"`
-	Nonce          string         `gorm:"not null" json:"nonce"`
-	WalletAddress  string         `gorm:"index;not null" json:"walletAddress"`
-	VaultPath      string         `gorm:"not null" json:"-"`                    // Path in Vault for Share1
-	BlockchainTxID string         `gorm:"column:blockchain_tx_id" json:"txId"`  // Fabric transaction ID (optional for now)
-	Share2         string         `gorm:"type:text" json:"-"`                   // Will be stored in blockchain
-	CreatedAt      time.Time      `json:"createdAt"`
-	UpdatedAt      time.Time      `json:"-"`
-	LastAccessed   *time.Time     `json:"lastAccessed,omitempty"`
-	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                string            `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	CredentialName    string            `gorm:"column:credential_name;not null" json:"name"`
+	Kind              string            `gorm:"not null;default:login;index" json:"kind"`
+	Username          string            `json:"username,omitempty"`
+	URL               string            `json:"url,omitempty"`
+	EncryptedData     string            `gorm:"type:text;not null" json:"encryptedData"`
+	Nonce             string            `gorm:"not null" json:"nonce"`
+	Metadata          map[string]string `gorm:"type:jsonb;serializer:json" json:"metadata,omitempty"` // Client-encrypted values, see ValidateKind
+	WalletAddress     string            `gorm:"index;not null" json:"walletAddress"`
+	VaultPath         string            `gorm:"not null" json:"-"`                   // Path in Vault for Share1
+	BlockchainTxID    string            `gorm:"column:blockchain_tx_id" json:"txId"` // Fabric transaction ID (optional for now)
+	Share2            string            `gorm:"type:text" json:"-"`                  // Will be stored in blockchain
+	Share3Hash        string            `json:"-"`                                   // Hex SHA-256 of the client's share3, see RecoverCredentialRequest
+	SplitScheme       string            `json:"splitScheme,omitempty"`               // How share3 was made, see SplitRecovery; empty when unknown
+	CreatedAt         time.Time         `json:"createdAt"`
+	UpdatedAt         time.Time         `json:"-"`
+	LastAccessed      *time.Time        `json:"lastAccessed,omitempty"`
+	RequirePasskey    bool              `gorm:"not null;default:false" json:"requirePasskey"` // Reveal and delete need a passkey assertion
+	Version           int               `gorm:"not null;default:1" json:"version"`            // Bumped by every update of the secret
+	SharesRefreshedAt *time.Time        `json:"sharesRefreshedAt,omitempty"`                  // Last re-sharing of share1 and share2, see worker.ShareRefresher
+	FolderID          *string           `gorm:"type:uuid;index" json:"folderId,omitempty"`
+	ExpiresAt         *time.Time        `gorm:"index" json:"expiresAt,omitempty"`                      // Removed by the expiry reaper after this
+	ExpiryNotifiedAt  *time.Time        `json:"-"`                                                     // When the owner was warned
+	PurgeAt           *time.Time        `gorm:"index" json:"purgeAt,omitempty"`                        // Set while in the trash: when the shares are destroyed
+	OrgID             *string           `gorm:"type:uuid;index" json:"orgId,omitempty"`                // Set for credentials in an organization vault; WalletAddress is then the creator
+	Approvers         []string          `gorm:"type:jsonb;serializer:json" json:"approvers,omitempty"` // Wallets that approve reveals, see ApprovalRequest
+	ApprovalThreshold int               `gorm:"not null;default:0" json:"approvalThreshold,omitempty"` // Approvals needed per reveal; 0 for none
+	Tags              []Tag             `gorm:"many2many:credential_tags" json:"tags,omitempty"`
+	SharedWithMe      bool              `gorm:"-" json:"sharedWithMe,omitempty"` // Set in listings for credentials of other wallets shared with the caller
+	DeletedAt         gorm.DeletedAt    `gorm:"index" json:"-"`
+}
+
+// Split schemes: how the client derived share3 from the key and the two
+// server shares. The server never sees share3, so it cannot tell them apart
+// and relies on the client saying which one it used.
+const (
+	SplitXOR      = "xor"      // share3 = key ^ share1 ^ share2 (32 bytes); needs all three
+	SplitRecovery = "recovery" // share3 = (key ^ share1) || (key ^ share2) (64 bytes); either server share will do
+)
+
+// ValidSplitScheme reports whether scheme is a known split scheme
+func ValidSplitScheme(scheme string) bool {
+	return scheme == SplitXOR || scheme == SplitRecovery
+}
+
+// RequiresApproval reports whether every reveal needs M-of-N approval
+func (c *Credential) RequiresApproval() bool {
+	return c.ApprovalThreshold > 0
+}
+
+// TagNames returns the names of the credential's (preloaded) tags
+func (c *Credential) TagNames() []string {
+	names := make([]string, 0, len(c.Tags))
+	for _, tag := range c.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
 }
 
 // CreateCredentialRequest for API
 type CreateCredentialRequest struct {
-	Name          string `json:"name" binding:"required"`
-	Username      string `json:"username" binding:"required"`
-	URL           string `json:"url"`
-	EncryptedData string `json:"encryptedData" binding:"required"`
-	Nonce         string `json:"nonce" binding:"required"`
-	Share1        string `json:"share1" binding:"required"` // Will be stored in Vault
-	Share2        string `json:"share2" binding:"required"` // Will be stored in Blockchain
-	WalletAddress string `json:"walletAddress" binding:"required"`
-	Signature     string `json:"signature" binding:"required"`
+	Kind           string            `json:"kind"` // Defaults to login
+	Name           string            `json:"name" binding:"required"`
+	Username       string            `json:"username"`
+	URL            string            `json:"url"`
+	EncryptedData  string            `json:"encryptedData" binding:"required"`
+	Nonce          string            `json:"nonce" binding:"required"`
+	Metadata       map[string]string `json:"metadata"`
+	Share1         string            `json:"share1" binding:"required"` // Will be stored in Vault
+	Share2         string            `json:"share2" binding:"required"` // Will be stored in Blockchain
+	Share3Hash     string            `json:"share3Hash"`                // Commitment to share3; enables recovery, required for SplitRecovery
+	SplitScheme    string            `json:"splitScheme"`               // SplitXOR or SplitRecovery; credentials without one are never refreshed automatically
+	WalletAddress  string            `json:"walletAddress" binding:"required"`
+	Signature      string            `json:"signature"` // Deprecated: the request itself is signed (X-Signature)
+	RequirePasskey bool              `json:"requirePasskey"`
+	ExpiresAt      *time.Time        `json:"expiresAt"` // Optional auto-expiry
+	OrgID          *string           `json:"orgId"`     // Create in this organization's vault instead
+
+	RefreshDeltas []string `json:"-"` // Pending RefreshDelta.WrappedDelta carried over by a backup restore
+}
+
+// ImportCredentialsRequest creates many credentials at once, all or none.
+// Items may omit walletAddress; it defaults to the request's.
+type ImportCredentialsRequest struct {
+	WalletAddress string                    `json:"walletAddress" binding:"required"`
+	Credentials   []CreateCredentialRequest `json:"credentials" binding:"required,min=1"`
+}
+
+// UpdateCredentialRequest for API. PUT replaces the secret and needs all of
+// encryptedData, nonce, share1 and share2; PATCH may omit them, but they
+// always travel together because the shares only decrypt their own ciphertext.
+type UpdateCredentialRequest struct {
+	Name          *string    `json:"name"`
+	Username      *string    `json:"username"`
+	URL           *string    `json:"url"`
+	EncryptedData string     `json:"encryptedData"`
+	Nonce         string     `json:"nonce"`
+	Share1        string     `json:"share1"`
+	Share2        string     `json:"share2"`
+	Share3Hash    string     `json:"share3Hash"`  // Commitment to the new share3; only with a new secret
+	SplitScheme   string     `json:"splitScheme"` // Of the new secret's split; defaults to the current one
+	Version       int        `json:"version"`     // Optional: reject the update unless this is still the current version
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	NeverExpires  bool       `json:"neverExpires"` // Clears expiresAt
+
+	// Metadata is encrypted under the same key as EncryptedData, so it can
+	// only be replaced together with the secret
+	Metadata map[string]string `json:"metadata"`
+}
+
+// HasSecret reports whether the request carries a new secret
+func (r *UpdateCredentialRequest) HasSecret() bool {
+	return r.EncryptedData != "" || r.Nonce != "" || r.Share1 != "" || r.Share2 != ""
+}
+
+// CompleteSecret reports whether every part of the new secret is present
+func (r *UpdateCredentialRequest) CompleteSecret() bool {
+	return r.EncryptedData != "" && r.Nonce != "" && r.Share1 != "" && r.Share2 != ""
+}
+
+// CredentialVersion is one saved version of a credential's secret. Share1
+// stays in Vault as KV v2 version VaultVersion of the credential's path.
+type CredentialVersion struct {
+	ID             string            `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	CredentialID   string            `gorm:"type:uuid;uniqueIndex:idx_credential_version;not null" json:"credentialId"`
+	Version        int               `gorm:"uniqueIndex:idx_credential_version;not null" json:"version"`
+	EncryptedData  string            `gorm:"type:text;not null" json:"-"`
+	Nonce          string            `gorm:"not null" json:"-"`
+	Share2         string            `gorm:"type:text" json:"-"`
+	Share3Hash     string            `json:"-"`
+	SplitScheme    string            `json:"-"`
+	Metadata       map[string]string `gorm:"type:jsonb;serializer:json" json:"-"`
+	VaultVersion   int               `json:"vaultVersion"` // 0 when unknown (created before versioning)
+	BlockchainTxID string            `gorm:"column:blockchain_tx_id" json:"txId"`
+	WalletAddress  string            `json:"walletAddress"`          // Wallet that wrote this version
+	RestoredFrom   int               `json:"restoredFrom,omitempty"` // Set when written by a rollback
+	CreatedAt      time.Time         `json:"createdAt"`
+}
+
+// RecoverCredentialRequest proves possession of share3 after Vault lost
+// share1 or Fabric lost share2. The server checks it against the commitment
+// recorded with the current version and returns the surviving share; the
+// client rebuilds the key from the two and re-splits it with a PUT.
+type RecoverCredentialRequest struct {
+	Share3 string `json:"share3" binding:"required"`
+}
+
+// RefreshSharesRequest re-shares a credential in place. Leave it empty to
+// have the server re-randomize the shares. That keeps share3 valid under
+// SplitXOR; credentials under SplitRecovery get a RefreshDelta their owner
+// applies to share3 instead, and ones with no recorded scheme cannot be
+// re-randomized. Or pass a fresh SplitRecovery split of the same key.
+type RefreshSharesRequest struct {
+	Share1     string `json:"share1"`
+	Share2     string `json:"share2"`
+	Share3Hash string `json:"share3Hash"`
+}
+
+// Fresh reports whether the client supplied its own shares
+func (r *RefreshSharesRequest) Fresh() bool {
+	return r.Share1 != "" || r.Share2 != "" || r.Share3Hash != ""
+}
+
+// Complete reports whether every part of a client-supplied split is present
+func (r *RefreshSharesRequest) Complete() bool {
+	return r.Share1 != "" && r.Share2 != "" && r.Share3Hash != ""
+}
+
+// ApplyRefreshRequest commits to the share3 the owner got by applying
+// pending RefreshDeltas. DeltaIDs must name every delta pending for the
+// current version, since the new share3 follows all of them.
+type ApplyRefreshRequest struct {
+	DeltaIDs   []string `json:"deltaIds" binding:"required,min=1"`
+	Share3Hash string   `json:"share3Hash" binding:"required"`
+}
+
+// RollbackCredentialRequest for API
+type RollbackCredentialRequest struct {
+	Version int `json:"version" binding:"required,min=1"`
 }
 
 // AuditLog for blockchain audit trail
@@ -42,8 +195,460 @@ type AuditLog struct {
 	ID            string    `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
 	CredentialID  string    `gorm:"type:uuid;index" json:"credentialId"`
 	WalletAddress string    `gorm:"index" json:"walletAddress"`
-	Action        string    `json:"action"` // "create", "read", "delete"
+	Action        string    `json:"action"`                                            // "create", "read", "update", "delete"
+	APIKeyID      string    `gorm:"column:api_key_id;index" json:"apiKeyId,omitempty"` // Set when a service account acted
+	ActorWallet   string    `gorm:"index" json:"actorWallet,omitempty"`                // Set when another wallet (e.g. a share recipient) acted
+	OrgID         *string   `gorm:"type:uuid;index" json:"orgId,omitempty"`            // Set for actions on organization credentials
 	IPAddress     string    `json:"ipAddress,omitempty"`
 	Timestamp     time.Time `json:"timestamp"`
 	TxHash        string    `json:"txHash,omitempty"` // Blockchain transaction hash
 }
+
+// API key scopes
+const (
+	APIKeyScopeRead      = "read"
+	APIKeyScopeReadWrite = "read-write"
+)
+
+// APIKey is a service-account key owned by a wallet, for machine access
+// (e.g. CI pipelines) to the credential routes without a browser wallet
+type APIKey struct {
+	ID            string     `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	WalletAddress string     `gorm:"index;not null" json:"walletAddress"`
+	Name          string     `gorm:"not null" json:"name"`
+	LookupID      string     `gorm:"uniqueIndex;not null" json:"lookupId"` // Public part of the key
+	KeyHash       string     `gorm:"not null" json:"-"`
+	Scope         string     `gorm:"not null" json:"scope"`
+	CredentialIDs []string   `gorm:"type:jsonb;serializer:json" json:"credentialIds,omitempty"` // Empty = no ID restriction
+	Tags          []string   `gorm:"type:jsonb;serializer:json" json:"tags,omitempty"`          // Empty = no tag restriction
+	ExpiresAt     time.Time  `gorm:"not null" json:"expiresAt"`
+	LastUsedAt    *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty"`
+}
+
+// Active reports whether the key is neither revoked nor expired
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && now.Before(k.ExpiresAt)
+}
+
+// CanWrite reports whether the key may create or delete credentials
+func (k *APIKey) CanWrite() bool {
+	return k.Scope == APIKeyScopeReadWrite
+}
+
+// Unrestricted reports whether the key covers every credential of its wallet
+func (k *APIKey) Unrestricted() bool {
+	return len(k.CredentialIDs) == 0 && len(k.Tags) == 0
+}
+
+// Allows reports whether the key may touch a credential with the given ID and tags
+func (k *APIKey) Allows(credentialID string, tags []string) bool {
+	if k.Unrestricted() {
+		return true
+	}
+	for _, id := range k.CredentialIDs {
+		if id == credentialID {
+			return true
+		}
+	}
+	for _, want := range k.Tags {
+		for _, tag := range tags {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyRequest for API
+type CreateAPIKeyRequest struct {
+	Name          string    `json:"name" binding:"required"`
+	Scope         string    `json:"scope" binding:"required,oneof=read read-write"`
+	CredentialIDs []string  `json:"credentialIds"`
+	Tags          []string  `json:"tags"`
+	ExpiresAt     time.Time `json:"expiresAt" binding:"required"`
+}
+
+// Account groups the wallets that belong to one user. Credentials stay keyed
+// by WalletAddress; every wallet linked to an account can reach them.
+type Account struct {
+	ID        string          `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Wallets   []AccountWallet `json:"wallets"`
+}
+
+// AccountWallet links a wallet (checksummed address) to an account
+type AccountWallet struct {
+	WalletAddress string    `gorm:"primarykey" json:"walletAddress"`
+	AccountID     string    `gorm:"type:uuid;index;not null" json:"accountId"`
+	LinkedAt      time.Time `json:"linkedAt"`
+}
+
+// LinkWalletRequest proves control of both wallets: the account wallet
+// (the caller) and the new wallet sign the same link challenge
+type LinkWalletRequest struct {
+	NewWallet         string `json:"newWallet" binding:"required"`
+	Nonce             string `json:"nonce" binding:"required"`
+	ExistingSignature string `json:"existingSignature" binding:"required"`
+	NewSignature      string `json:"newSignature" binding:"required"`
+}
+
+// MigrateWalletRequest moves everything owned by one linked wallet to another
+type MigrateWalletRequest struct {
+	FromWallet string `json:"fromWallet" binding:"required"`
+	ToWallet   string `json:"toWallet" binding:"required"`
+}
+
+// Passkey is a WebAuthn credential registered by a wallet as a second factor
+type Passkey struct {
+	ID            string              `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	WalletAddress string              `gorm:"index;not null" json:"walletAddress"`
+	Name          string              `gorm:"not null" json:"name"`
+	CredentialID  string              `gorm:"uniqueIndex;not null" json:"credentialId"` // base64url WebAuthn credential ID
+	Key           webauthn.Credential `gorm:"type:jsonb;serializer:json" json:"-"`      // Public key, flags and sign count
+	CreatedAt     time.Time           `json:"createdAt"`
+	LastUsedAt    *time.Time          `json:"lastUsedAt,omitempty"`
+}
+
+// SetRequirePasskeyRequest turns the passkey requirement of a credential on or off
+type SetRequirePasskeyRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// Folder organizes credentials in a tree; ParentID is nil for top-level folders
+type Folder struct {
+	ID            string    `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	WalletAddress string    `gorm:"index;not null" json:"walletAddress"`
+	Name          string    `gorm:"not null" json:"name"`
+	ParentID      *string   `gorm:"type:uuid;index" json:"parentId,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Tag labels credentials; names are unique per wallet
+type Tag struct {
+	ID            string    `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	WalletAddress string    `gorm:"uniqueIndex:idx_tag_wallet_name;not null" json:"-"`
+	Name          string    `gorm:"uniqueIndex:idx_tag_wallet_name;not null" json:"name"`
+	CreatedAt     time.Time `json:"-"`
+}
+
+// CreateFolderRequest for API
+type CreateFolderRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	ParentID *string `json:"parentId"`
+}
+
+// UpdateFolderRequest renames and/or moves a folder. A parentId of "" moves
+// it to the top level; an absent parentId leaves it where it is.
+type UpdateFolderRequest struct {
+	Name     *string `json:"name"`
+	ParentID *string `json:"parentId"`
+}
+
+// MoveCredentialRequest puts a credential in a folder ("" for none)
+type MoveCredentialRequest struct {
+	FolderID string `json:"folderId"`
+}
+
+// TagCredentialRequest attaches tags by name, creating unknown ones
+type TagCredentialRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1,dive,required"`
+}
+
+// Notification kinds
+const (
+	NotificationExpiring         = "credential_expiring"
+	NotificationExpired          = "credential_expired"
+	NotificationApprovalRequest  = "approval_requested"
+	NotificationApprovalGranted  = "approval_granted"
+	NotificationEmergencyRequest = "emergency_requested"
+	NotificationEmergencyDenied  = "emergency_denied"
+	NotificationEmergencyGranted = "emergency_granted"
+)
+
+// Notification is an in-app message to a wallet
+type Notification struct {
+	ID            string     `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	WalletAddress string     `gorm:"index;not null" json:"walletAddress"`
+	Kind          string     `gorm:"not null" json:"kind"`
+	CredentialID  *string    `gorm:"type:uuid;index" json:"credentialId,omitempty"` // Unset for account-level notifications
+	Message       string     `gorm:"not null" json:"message"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ReadAt        *time.Time `json:"readAt,omitempty"`
+}
+
+// CredentialShare gives another wallet read access to a credential. The owner
+// rebuilds the credential's data key on the client and wraps it to the
+// recipient's published encryption key; the server only stores the wrapped
+// copy, which opens the ciphertext of Version and no other.
+type CredentialShare struct {
+	ID              string     `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	CredentialID    string     `gorm:"type:uuid;index;not null" json:"credentialId"`
+	OwnerWallet     string     `gorm:"index;not null" json:"ownerWallet"`
+	RecipientWallet string     `gorm:"index;not null" json:"recipientWallet"`
+	WrappedKey      string     `gorm:"type:text;not null;default:''" json:"-"`
+	Version         int        `gorm:"not null" json:"version"` // Credential version the key decrypts
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	RevokedAt       *time.Time `json:"revokedAt,omitempty"`
+
+	// Set when the share was released from escrow for a granted emergency request
+	EmergencyRequestID *string `gorm:"type:uuid;index" json:"emergencyRequestId,omitempty"`
+}
+
+// WalletKey is the encryption public key a wallet publishes so others can
+// wrap credential keys to it (e.g. from MetaMask eth_getEncryptionPublicKey)
+type WalletKey struct {
+	WalletAddress string    `gorm:"primarykey" json:"walletAddress"`
+	PublicKey     string    `gorm:"not null" json:"publicKey"`
+	Algorithm     string    `gorm:"not null" json:"algorithm"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// PublishWalletKeyRequest for API
+type PublishWalletKeyRequest struct {
+	PublicKey string `json:"publicKey" binding:"required"`
+	Algorithm string `json:"algorithm"` // Defaults to x25519-xsalsa20-poly1305
+}
+
+// ShareCredentialRequest shares (or re-shares after an update) a credential.
+// Version must be the credential's current version.
+type ShareCredentialRequest struct {
+	RecipientWallet string `json:"recipientWallet" binding:"required"`
+	WrappedKey      string `json:"wrappedKey" binding:"required"` // EthEncryptedData JSON of the data key
+	Version         int    `json:"version" binding:"required"`
+}
+
+// ShareLink is a one-time, time-limited link to a credential for someone
+// without a wallet. The payload is encrypted on the client under a fresh key
+// of its own, split like a credential: share1 in Vault, share2 here, and the
+// rest in the link itself (never sent to the server).
+type ShareLink struct {
+	ID            string     `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	CredentialID  string     `gorm:"type:uuid;index;not null" json:"credentialId"`
+	OwnerWallet   string     `gorm:"index;not null" json:"ownerWallet"`
+	TokenHash     string     `gorm:"uniqueIndex;not null" json:"-"` // SHA-256 of the link token
+	EncryptedData string     `gorm:"type:text" json:"-"`
+	Nonce         string     `json:"-"`
+	VaultPath     string     `json:"-"` // Path in Vault for the payload's share1
+	Share2        string     `gorm:"type:text" json:"-"`
+	MaxViews      int        `gorm:"not null" json:"maxViews"`
+	Views         int        `gorm:"not null;default:0" json:"views"`
+	ExpiresAt     time.Time  `gorm:"index;not null" json:"expiresAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	DestroyedAt   *time.Time `gorm:"index" json:"destroyedAt,omitempty"` // Payload removed: used up, expired or revoked
+}
+
+// CreateShareLinkRequest for API. The payload shares are of the link's own
+// key, never of the credential's.
+type CreateShareLinkRequest struct {
+	EncryptedData string `json:"encryptedData" binding:"required"`
+	Nonce         string `json:"nonce" binding:"required"`
+	Share1        string `json:"share1" binding:"required"`
+	Share2        string `json:"share2" binding:"required"`
+	ExpiresIn     int    `json:"expiresIn"` // Seconds; defaults to a day
+	MaxViews      int    `json:"maxViews"`  // Defaults to 1
+}
+
+// Organization roles, most privileged first. On organization credentials,
+// read-only members may read, members may also create and update, and admins
+// may also delete and manage members. Only owners may manage admins and owners.
+const (
+	OrgRoleOwner    = "owner"
+	OrgRoleAdmin    = "admin"
+	OrgRoleMember   = "member"
+	OrgRoleReadOnly = "read-only"
+)
+
+var orgRoleRank = map[string]int{
+	OrgRoleReadOnly: 1,
+	OrgRoleMember:   2,
+	OrgRoleAdmin:    3,
+	OrgRoleOwner:    4,
+}
+
+// OrgRoleAtLeast reports whether role grants everything min does
+func OrgRoleAtLeast(role, min string) bool {
+	return orgRoleRank[role] > 0 && orgRoleRank[role] >= orgRoleRank[min]
+}
+
+// Organization is a team with a shared credential vault
+type Organization struct {
+	ID        string      `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	Name      string      `gorm:"not null" json:"name"`
+	CreatedBy string      `gorm:"not null" json:"createdBy"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Members   []OrgMember `gorm:"foreignKey:OrgID" json:"members,omitempty"`
+}
+
+// OrgMember gives a wallet a role in an organization. A member's linked
+// wallets act with the wallet's role.
+type OrgMember struct {
+	OrgID         string    `gorm:"type:uuid;primarykey" json:"orgId"`
+	WalletAddress string    `gorm:"primarykey;index" json:"walletAddress"`
+	Role          string    `gorm:"not null" json:"role"`
+	AddedBy       string    `json:"addedBy"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// CreateOrganizationRequest for API; the caller becomes its owner
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// AddOrgMemberRequest for API
+type AddOrgMemberRequest struct {
+	WalletAddress string `json:"walletAddress" binding:"required"`
+	Role          string `json:"role" binding:"required,oneof=owner admin member read-only"`
+}
+
+// UpdateOrgMemberRequest changes a member's role
+type UpdateOrgMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member read-only"`
+}
+
+// OrgMemberKey is an organization credential's data key wrapped to one
+// member's wallet. share3 of an organization credential stays with the
+// member who saved it, so the others decrypt with this instead.
+type OrgMemberKey struct {
+	ID           string    `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	OrgID        string    `gorm:"type:uuid;not null;index" json:"orgId"`
+	MemberWallet string    `gorm:"not null;uniqueIndex:idx_org_member_key" json:"memberWallet"`
+	CredentialID string    `gorm:"type:uuid;not null;uniqueIndex:idx_org_member_key;index" json:"credentialId"`
+	WrappedKey   string    `gorm:"type:text;not null" json:"-"`
+	Version      int       `gorm:"not null" json:"version"` // Credential version the key decrypts
+	WrappedBy    string    `json:"wrappedBy"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// OrgMemberKeysRequest wraps (or re-wraps) organization credential keys for
+// a member, in the same shape as EscrowKeysRequest
+type OrgMemberKeysRequest struct {
+	Keys []EscrowKey `json:"keys" binding:"required,min=1,dive"`
+}
+
+// SetApprovalPolicyRequest flags a credential for M-of-N approval: each reveal
+// needs Threshold of Approvers to sign off. A threshold of 0 clears it.
+type SetApprovalPolicyRequest struct {
+	Approvers []string `json:"approvers"`
+	Threshold int      `json:"threshold" binding:"min=0"`
+}
+
+// ApprovalRequest actions
+const (
+	ApprovalActionReveal = "reveal" // Reveal the credential's shares
+	ApprovalActionPolicy = "policy" // Weaken or remove its approval policy
+)
+
+// ApprovalRequest is one wallet's request to reveal a flagged credential, or
+// to weaken its approval policy to ProposedApprovers and ProposedThreshold.
+// The approvers and threshold are copied from the credential when it is made.
+// Once approved, it is released (the shares handed out or the policy applied)
+// exactly once before it expires.
+type ApprovalRequest struct {
+	ID                string     `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	CredentialID      string     `gorm:"type:uuid;index;not null" json:"credentialId"`
+	RequesterWallet   string     `gorm:"index;not null" json:"requesterWallet"`
+	Action            string     `gorm:"not null;default:reveal" json:"action"`
+	ProposedApprovers []string   `gorm:"type:jsonb;serializer:json" json:"proposedApprovers,omitempty"`
+	ProposedThreshold int        `gorm:"not null;default:0" json:"proposedThreshold"`
+	Approvers         []string   `gorm:"type:jsonb;serializer:json" json:"approvers"`
+	Threshold         int        `gorm:"not null" json:"threshold"`
+	ExpiresAt         time.Time  `gorm:"index;not null" json:"expiresAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	ApprovedAt        *time.Time `json:"approvedAt,omitempty"` // Threshold reached
+	ReleasedAt        *time.Time `json:"releasedAt,omitempty"` // Shares handed out or policy applied
+	Approvals         []Approval `gorm:"foreignKey:RequestID" json:"approvals"`
+}
+
+// Approval is one approver's signature on an ApprovalRequest
+type Approval struct {
+	RequestID      string    `gorm:"type:uuid;primarykey" json:"requestId"`
+	ApproverWallet string    `gorm:"primarykey" json:"approverWallet"`
+	Signature      string    `gorm:"not null" json:"signature"` // Over auth.ApprovalMessage or auth.PolicyChangeMessage
+	TxHash         string    `json:"txHash,omitempty"`          // Fabric transaction recording it
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// ApproveRequest for API
+type ApproveRequest struct {
+	Signature string `json:"signature" binding:"required"`
+}
+
+// EmergencyContact is a wallet the owner trusts to take over their
+// credentials. The contact can request access at any time; unless the owner
+// denies it within WaitPeriod, the credentials the owner escrowed a key for
+// are shared with the contact.
+type EmergencyContact struct {
+	ID            string     `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	OwnerWallet   string     `gorm:"index;not null" json:"ownerWallet"`
+	ContactWallet string     `gorm:"index;not null" json:"contactWallet"`
+	WaitPeriod    int        `gorm:"not null" json:"waitPeriod"` // Seconds the owner has to deny a request
+	CreatedAt     time.Time  `json:"createdAt"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty"`
+}
+
+// EmergencyRequest is a contact's request for access. A contact has at most
+// one pending request (neither denied nor granted).
+type EmergencyRequest struct {
+	ID            string     `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	ContactID     string     `gorm:"type:uuid;not null;uniqueIndex:idx_pending_emergency_request,where:denied_at IS NULL AND granted_at IS NULL" json:"contactId"`
+	OwnerWallet   string     `gorm:"index;not null" json:"ownerWallet"`
+	ContactWallet string     `gorm:"index;not null" json:"contactWallet"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	GrantAt       time.Time  `gorm:"index;not null" json:"grantAt"` // Granted from then on unless denied
+	DeniedAt      *time.Time `json:"deniedAt,omitempty"`
+	GrantedAt     *time.Time `json:"grantedAt,omitempty"`
+	Granted       int        `json:"granted"`                 // Credentials shared with the contact
+	Skipped       int        `json:"skipped"`                 // Credentials without a current escrowed key, or needing a passkey or approval
+	RequestTxHash string     `json:"requestTxHash,omitempty"` // Fabric transactions recording the request and its outcome
+	ResultTxHash  string     `json:"resultTxHash,omitempty"`
+}
+
+// EmergencyEscrow is a credential's data key wrapped by its owner to an
+// emergency contact's encryption key, held until a request of the contact is
+// granted. It only opens the ciphertext of Version; the owner escrows again
+// after updating the credential.
+type EmergencyEscrow struct {
+	ID           string    `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	ContactID    string    `gorm:"type:uuid;not null;uniqueIndex:idx_emergency_escrow" json:"contactId"`
+	CredentialID string    `gorm:"type:uuid;not null;uniqueIndex:idx_emergency_escrow;index" json:"credentialId"`
+	WrappedKey   string    `gorm:"type:text;not null" json:"-"`
+	Version      int       `gorm:"not null" json:"version"` // Credential version the key decrypts
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// RefreshDelta holds the pads a server-made refresh XORed into share1 and
+// share2 of a credential split for recovery; the owner's share3 of that
+// version follows as share3 ^ (pad1 || pad2). The pads are only kept wrapped
+// to the owner's wallet, until the owner applies them and commits to the new
+// share3 (ApplyRefreshRequest).
+type RefreshDelta struct {
+	ID           string    `gorm:"type:uuid;primarykey;default:gen_random_uuid()" json:"id"`
+	CredentialID string    `gorm:"type:uuid;not null;index:idx_refresh_delta" json:"credentialId"`
+	Version      int       `gorm:"not null;index:idx_refresh_delta" json:"version"` // Credential version whose shares were refreshed
+	WrappedDelta string    `gorm:"type:text;not null" json:"wrappedDelta"`          // pad1 || pad2, base64, as EthEncryptedData JSON
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// EscrowKey is one wrapped key in an EscrowKeysRequest or OrgMemberKeysRequest
+type EscrowKey struct {
+	CredentialID string `json:"credentialId" binding:"required"`
+	WrappedKey   string `json:"wrappedKey" binding:"required"` // EthEncryptedData JSON of the data key
+	Version      int    `json:"version" binding:"required"`
+}
+
+// EscrowKeysRequest escrows (or re-escrows) credential keys for a contact
+type EscrowKeysRequest struct {
+	Keys []EscrowKey `json:"keys" binding:"required,min=1,dive"`
+}
+
+// AddEmergencyContactRequest for API
+type AddEmergencyContactRequest struct {
+	ContactWallet string `json:"contactWallet" binding:"required"`
+	WaitPeriod    int    `json:"waitPeriod"` // Seconds; defaults to 7 days
+}
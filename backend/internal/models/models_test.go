@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAPIKeyActive(t *testing.T) {
+	now := time.Now()
+	revoked := now.Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		key  APIKey
+		want bool
+	}{
+		{"active", APIKey{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", APIKey{ExpiresAt: now.Add(-time.Second)}, false},
+		{"expires now", APIKey{ExpiresAt: now}, false},
+		{"revoked", APIKey{ExpiresAt: now.Add(time.Hour), RevokedAt: &revoked}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.key.Active(now); got != tt.want {
+			t.Errorf("%s: Active = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyCanWrite(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{APIKeyScopeRead, false},
+		{APIKeyScopeReadWrite, true},
+		{"write", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		key := APIKey{Scope: tt.scope}
+		if got := key.CanWrite(); got != tt.want {
+			t.Errorf("CanWrite(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyAllows(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          APIKey
+		credentialID string
+		tags         []string
+		want         bool
+	}{
+		{"unrestricted", APIKey{}, "c1", nil, true},
+		{"listed ID", APIKey{CredentialIDs: []string{"c1", "c2"}}, "c2", nil, true},
+		{"unlisted ID", APIKey{CredentialIDs: []string{"c1"}}, "c3", []string{"ci"}, false},
+		{"matching tag", APIKey{Tags: []string{"ci"}}, "c3", []string{"prod", "ci"}, true},
+		{"other tag", APIKey{Tags: []string{"ci"}}, "c3", []string{"prod"}, false},
+		{"untagged", APIKey{Tags: []string{"ci"}}, "c3", nil, false},
+		{"ID or tag", APIKey{CredentialIDs: []string{"c1"}, Tags: []string{"ci"}}, "c3", []string{"ci"}, true},
+		{"tag case", APIKey{Tags: []string{"ci"}}, "c3", []string{"CI"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.key.Allows(tt.credentialID, tt.tags); got != tt.want {
+			t.Errorf("%s: Allows = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
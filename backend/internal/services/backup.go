@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Backup archives are newline-delimited JSON: a BackupHeader line, one sealed
+// BackupEntry line per credential and a BackupTrailer line. The trailer holds
+// the SHA-256 of every byte before it and the signer's Ed25519 signature over
+// that digest, so an archive can be streamed out and verified on the way in.
+//
+// The header names the signer's public key, which a restoring deployment must
+// trust (its own key or one configured as trusted), and carries a random
+// archive key wrapped to the wallet's encryption key. Entries are sealed with
+// that key (NaCl secretbox), so the archive is only readable by the wallet:
+// to restore, the client unwraps the archive key (eth_decrypt) and sends it
+// along.
+const (
+	BackupFormat        = "passchain-backup"
+	BackupFormatVersion = 2
+)
+
+var (
+	ErrInvalidBackup   = errors.New("invalid backup archive")
+	ErrUntrustedBackup = errors.New("backup signed by an untrusted key")
+	ErrBackupKey       = errors.New("archive key does not open the backup")
+	ErrNoBackupKey     = errors.New("no backup signing key is configured")
+)
+
+// BackupHeader is the first line of an archive
+type BackupHeader struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	Wallet     string    `json:"wallet"`
+	CreatedAt  time.Time `json:"createdAt"`
+	KeyID      string    `json:"keyId"`      // BackupSigner.KeyID of the signing key
+	PublicKey  string    `json:"publicKey"`  // The signing key, base64
+	WrappedKey string    `json:"wrappedKey"` // The archive key (base64) wrapped to the wallet, EthEncryptedData JSON
+}
+
+// BackupEntry is one credential with everything needed to recreate it elsewhere
+type BackupEntry struct {
+	ID            string            `json:"id"` // On the exporting deployment; restore maps it to the new ID
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind"`
+	Username      string            `json:"username,omitempty"`
+	URL           string            `json:"url,omitempty"`
+	EncryptedData string            `json:"encryptedData"`
+	Nonce         string            `json:"nonce"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Share1        string            `json:"share1"`
+	Share2        string            `json:"share2"`
+	Share3Hash    string            `json:"share3Hash,omitempty"`    // The client keeps share3 itself
+	SplitScheme   string            `json:"splitScheme,omitempty"`   // models.SplitXOR or models.SplitRecovery; empty when unknown
+	RefreshDeltas []string          `json:"refreshDeltas,omitempty"` // Pending models.RefreshDelta.WrappedDelta for share3
+	ExpiresAt     *time.Time        `json:"expiresAt,omitempty"`
+}
+
+// sealedEntry is an entry line: a BackupEntry's JSON sealed with the archive key
+type sealedEntry struct {
+	Nonce string `json:"nonce"`
+	Box   string `json:"box"`
+}
+
+// BackupTrailer is the last line of an archive
+type BackupTrailer struct {
+	Count     int    `json:"count"`
+	Skipped   int    `json:"skipped"` // Credentials left out, see the export handler
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"` // Ed25519 over the raw digest, base64
+}
+
+// BackupSigner signs archives with the deployment's Ed25519 key and verifies
+// archives signed by it or by another trusted deployment
+type BackupSigner struct {
+	key     ed25519.PrivateKey           // nil when no signing key is configured
+	trusted map[string]ed25519.PublicKey // By KeyID, the own key included
+}
+
+// NewBackupSigner loads the signing key from a base64 32-byte seed and the
+// base64 public keys of other deployments whose archives may be restored.
+// Without a seed the signer can only verify; exports are refused.
+func NewBackupSigner(seed string, trustedKeys []string) (*BackupSigner, error) {
+	s := &BackupSigner{trusted: make(map[string]ed25519.PublicKey, len(trustedKeys)+1)}
+
+	for _, encoded := range trustedKeys {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("trusted backup key %q must be a base64 %d-byte Ed25519 public key", encoded, ed25519.PublicKeySize)
+		}
+		s.trusted[keyID(raw)] = ed25519.PublicKey(raw)
+	}
+
+	if seed == "" {
+		return s, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil || len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("backup signing key must be a base64 %d-byte seed", ed25519.SeedSize)
+	}
+	s.key = ed25519.NewKeyFromSeed(raw)
+	public := s.key.Public().(ed25519.PublicKey)
+	s.trusted[keyID(public)] = public
+	return s, nil
+}
+
+// CanSign reports whether a signing key is configured
+func (s *BackupSigner) CanSign() bool {
+	return s.key != nil
+}
+
+// PublicKey returns the base64 public key, for verifying archives offline
+func (s *BackupSigner) PublicKey() string {
+	if s.key == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// KeyID is a short fingerprint of the public key
+func (s *BackupSigner) KeyID() string {
+	if s.key == nil {
+		return ""
+	}
+	return keyID(s.key.Public().(ed25519.PublicKey))
+}
+
+func keyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// BackupWriter streams an archive to w
+type BackupWriter struct {
+	signer     *BackupSigner
+	archiveKey [32]byte
+	dst        io.Writer
+	out        io.Writer // dst, also feeding digest
+	digest     hash.Hash
+	count      int
+	skipped    int
+}
+
+// NewBackupWriter writes the header line for wallet, with a fresh archive key
+// wrapped to walletKey (the wallet's published encryption key)
+func (s *BackupSigner) NewBackupWriter(w io.Writer, wallet, walletKey string) (*BackupWriter, error) {
+	if s.key == nil {
+		return nil, ErrNoBackupKey
+	}
+
+	bw := &BackupWriter{signer: s, dst: w, digest: sha256.New()}
+	if _, err := rand.Read(bw.archiveKey[:]); err != nil {
+		return nil, err
+	}
+	wrapped, err := WrapForWallet(walletKey, base64.StdEncoding.EncodeToString(bw.archiveKey[:]))
+	if err != nil {
+		return nil, err
+	}
+	bw.out = io.MultiWriter(w, bw.digest)
+
+	err = bw.writeLine(BackupHeader{
+		Format:     BackupFormat,
+		Version:    BackupFormatVersion,
+		Wallet:     wallet,
+		CreatedAt:  time.Now().UTC(),
+		KeyID:      s.KeyID(),
+		PublicKey:  s.PublicKey(),
+		WrappedKey: wrapped,
+	})
+	return bw, err
+}
+
+// Write adds one credential
+func (bw *BackupWriter) Write(entry BackupEntry) error {
+	plain, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+
+	bw.count++
+	return bw.writeLine(sealedEntry{
+		Nonce: base64.StdEncoding.EncodeToString(nonce[:]),
+		Box:   base64.StdEncoding.EncodeToString(secretbox.Seal(nil, plain, &nonce, &bw.archiveKey)),
+	})
+}
+
+// Skip counts a credential that was left out of the archive
+func (bw *BackupWriter) Skip() {
+	bw.skipped++
+}
+
+// Close signs everything written so far and writes the trailer
+func (bw *BackupWriter) Close() error {
+	sum := bw.digest.Sum(nil)
+	trailer := BackupTrailer{
+		Count:     bw.count,
+		Skipped:   bw.skipped,
+		SHA256:    hex.EncodeToString(sum),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(bw.signer.key, sum)),
+	}
+	line, err := json.Marshal(trailer)
+	if err != nil {
+		return err
+	}
+	_, err = bw.dst.Write(append(line, '\n'))
+	return err
+}
+
+func (bw *BackupWriter) writeLine(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = bw.out.Write(append(line, '\n'))
+	return err
+}
+
+// ReadBackup parses and verifies a complete archive and opens its entries
+// with archiveKey (as unwrapped by the wallet). Nothing is returned unless
+// the signer is trusted, the digest and signature match, every entry opens
+// and the entry count is right.
+func (s *BackupSigner) ReadBackup(data, archiveKey []byte) (*BackupHeader, []BackupEntry, error) {
+	body := bytes.TrimRight(data, "\n")
+	cut := bytes.LastIndexByte(body, '\n')
+	if cut < 0 {
+		return nil, nil, fmt.Errorf("%w: missing trailer", ErrInvalidBackup)
+	}
+	signed, trailerLine := data[:cut+1], body[cut+1:]
+
+	var trailer BackupTrailer
+	if err := json.Unmarshal(trailerLine, &trailer); err != nil {
+		return nil, nil, fmt.Errorf("%w: unreadable trailer", ErrInvalidBackup)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(signed))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(signed))
+
+	var header BackupHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil {
+		return nil, nil, fmt.Errorf("%w: unreadable header", ErrInvalidBackup)
+	}
+	if header.Format != BackupFormat || header.Version != BackupFormatVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported format %s v%d", ErrInvalidBackup, header.Format, header.Version)
+	}
+
+	signer, err := base64.StdEncoding.DecodeString(header.PublicKey)
+	if err != nil || len(signer) != ed25519.PublicKeySize {
+		return nil, nil, fmt.Errorf("%w: unreadable signing key", ErrInvalidBackup)
+	}
+	trusted, ok := s.trusted[keyID(signer)]
+	if !ok || !trusted.Equal(ed25519.PublicKey(signer)) {
+		return nil, nil, fmt.Errorf("%w: key %s", ErrUntrustedBackup, keyID(signer))
+	}
+
+	sum := sha256.Sum256(signed)
+	signature, err := base64.StdEncoding.DecodeString(trailer.Signature)
+	if err != nil || hex.EncodeToString(sum[:]) != trailer.SHA256 || !ed25519.Verify(trusted, sum[:], signature) {
+		return nil, nil, fmt.Errorf("%w: signature does not match", ErrInvalidBackup)
+	}
+
+	if len(archiveKey) != 32 {
+		return nil, nil, fmt.Errorf("%w: archive key must be 32 bytes", ErrBackupKey)
+	}
+	var key [32]byte
+	copy(key[:], archiveKey)
+
+	entries := make([]BackupEntry, 0, trailer.Count)
+	for scanner.Scan() {
+		entry, err := openEntry(scanner.Bytes(), &key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w (entry %d)", err, len(entries))
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if len(entries) != trailer.Count {
+		return nil, nil, fmt.Errorf("%w: expected %d entries, found %d", ErrInvalidBackup, trailer.Count, len(entries))
+	}
+
+	return &header, entries, nil
+}
+
+// openEntry decodes and opens one sealed entry line
+func openEntry(line []byte, key *[32]byte) (BackupEntry, error) {
+	var entry BackupEntry
+	var sealed sealedEntry
+	if err := json.Unmarshal(line, &sealed); err != nil {
+		return entry, fmt.Errorf("%w: unreadable entry", ErrInvalidBackup)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(sealed.Nonce)
+	if err != nil || len(nonce) != 24 {
+		return entry, fmt.Errorf("%w: unreadable entry nonce", ErrInvalidBackup)
+	}
+	box, err := base64.StdEncoding.DecodeString(sealed.Box)
+	if err != nil {
+		return entry, fmt.Errorf("%w: unreadable entry", ErrInvalidBackup)
+	}
+
+	var n [24]byte
+	copy(n[:], nonce)
+	plain, ok := secretbox.Open(nil, box, &n, key)
+	if !ok {
+		return entry, ErrBackupKey
+	}
+	if err := json.Unmarshal(plain, &entry); err != nil {
+		return entry, fmt.Errorf("%w: unreadable entry", ErrInvalidBackup)
+	}
+	return entry, nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+const backupWallet = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+
+func newSeed(t *testing.T) string {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(seed)
+}
+
+// writeBackup exports entries for a fresh wallet key pair and returns the
+// archive with the archive key as the wallet unwraps it
+func writeBackup(t *testing.T, signer *BackupSigner, entries []BackupEntry, skipped int) ([]byte, []byte) {
+	t.Helper()
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	writer, err := signer.NewBackupWriter(&archive, backupWallet, base64.StdEncoding.EncodeToString(public[:]))
+	if err != nil {
+		t.Fatalf("NewBackupWriter: %v", err)
+	}
+	for _, entry := range entries {
+		if err := writer.Write(entry); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	for i := 0; i < skipped; i++ {
+		writer.Skip()
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var header BackupHeader
+	line, _, _ := bytes.Cut(archive.Bytes(), []byte("\n"))
+	if err := json.Unmarshal(line, &header); err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	unwrapped, ok := openAsWallet(t, private, header.WrappedKey)
+	if !ok {
+		t.Fatal("wallet could not unwrap the archive key")
+	}
+	archiveKey, err := base64.StdEncoding.DecodeString(unwrapped)
+	if err != nil {
+		t.Fatalf("archive key: %v", err)
+	}
+	return archive.Bytes(), archiveKey
+}
+
+var backupEntries = []BackupEntry{
+	{ID: "c1", Name: "github", Kind: "login", Username: "alice", EncryptedData: "ZW5j", Nonce: "bm9uY2U=", Share1: "czE=", Share2: "czI=", Share3Hash: "ab"},
+	{ID: "c2", Name: "deploy key", Kind: "ssh_key", Metadata: map[string]string{"publicKey": "cGs="}, EncryptedData: "ZW5j", Nonce: "bm9uY2U=", Share1: "czE=", Share2: "czI=", RefreshDeltas: []string{"{}"}},
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	signer, err := NewBackupSigner(newSeed(t), nil)
+	if err != nil {
+		t.Fatalf("NewBackupSigner: %v", err)
+	}
+	archive, archiveKey := writeBackup(t, signer, backupEntries, 1)
+
+	header, entries, err := signer.ReadBackup(archive, archiveKey)
+	if err != nil {
+		t.Fatalf("ReadBackup: %v", err)
+	}
+	if header.Wallet != backupWallet || header.KeyID != signer.KeyID() || header.PublicKey != signer.PublicKey() {
+		t.Errorf("header = %+v", header)
+	}
+	if !reflect.DeepEqual(entries, backupEntries) {
+		t.Errorf("entries = %+v, want %+v", entries, backupEntries)
+	}
+
+	// Nothing secret is readable without the archive key
+	if bytes.Contains(archive, []byte("github")) || bytes.Contains(archive, []byte("czE=")) {
+		t.Error("archive holds entries in the clear")
+	}
+}
+
+func TestReadBackupRejects(t *testing.T) {
+	seed := newSeed(t)
+	signer, _ := NewBackupSigner(seed, nil)
+	archive, archiveKey := writeBackup(t, signer, backupEntries, 0)
+	lines := strings.SplitAfter(string(archive), "\n")
+
+	other, _ := NewBackupSigner(newSeed(t), nil)
+	otherArchive, otherKey := writeBackup(t, other, backupEntries, 0)
+
+	tamperedEntry := append([]string(nil), lines...)
+	tamperedEntry[1] = strings.Replace(tamperedEntry[1], `"box":"`, `"box":"A`, 1)
+
+	var trailer BackupTrailer
+	json.Unmarshal([]byte(lines[len(lines)-2]), &trailer)
+	trailer.Count++
+	recount, _ := json.Marshal(trailer)
+	wrongCount := append(append([]string(nil), lines[:len(lines)-2]...), string(recount)+"\n")
+
+	wrongKey := make([]byte, 32)
+	rand.Read(wrongKey)
+
+	tests := []struct {
+		name       string
+		signer     *BackupSigner
+		archive    string
+		archiveKey []byte
+		wantErr    error
+	}{
+		{"untrusted signer", signer, string(otherArchive), otherKey, ErrUntrustedBackup},
+		{"tampered entry", signer, strings.Join(tamperedEntry, ""), archiveKey, ErrInvalidBackup},
+		{"dropped entry", signer, lines[0] + strings.Join(lines[2:], ""), archiveKey, ErrInvalidBackup},
+		{"wrong count", signer, strings.Join(wrongCount, ""), archiveKey, ErrInvalidBackup},
+		{"no trailer", signer, strings.Join(lines[:len(lines)-2], ""), archiveKey, ErrInvalidBackup},
+		{"wrong archive key", signer, string(archive), wrongKey, ErrBackupKey},
+		{"short archive key", signer, string(archive), archiveKey[:16], ErrBackupKey},
+		{"empty", signer, "", archiveKey, ErrInvalidBackup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.signer.ReadBackup([]byte(tt.archive), tt.archiveKey); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackupTrustedKeys(t *testing.T) {
+	exporter, _ := NewBackupSigner(newSeed(t), nil)
+	archive, archiveKey := writeBackup(t, exporter, backupEntries[:1], 0)
+
+	// A deployment without a signing key of its own restores what it trusts
+	restorer, err := NewBackupSigner("", []string{exporter.PublicKey()})
+	if err != nil {
+		t.Fatalf("NewBackupSigner: %v", err)
+	}
+	if _, _, err := restorer.ReadBackup(archive, archiveKey); err != nil {
+		t.Errorf("ReadBackup with the exporter trusted: %v", err)
+	}
+
+	if restorer.CanSign() || restorer.PublicKey() != "" || restorer.KeyID() != "" {
+		t.Error("signer without a seed claims a key")
+	}
+	if _, err := restorer.NewBackupWriter(&bytes.Buffer{}, backupWallet, ""); !errors.Is(err, ErrNoBackupKey) {
+		t.Errorf("NewBackupWriter without a key: err = %v, want %v", err, ErrNoBackupKey)
+	}
+}
+
+func TestNewBackupSigner(t *testing.T) {
+	exporter, _ := NewBackupSigner(newSeed(t), nil)
+
+	tests := []struct {
+		name    string
+		seed    string
+		trusted []string
+		wantErr bool
+	}{
+		{"seed", newSeed(t), nil, false},
+		{"no seed", "", nil, false},
+		{"trusted keys", "", []string{exporter.PublicKey()}, false},
+		{"short seed", base64.StdEncoding.EncodeToString(make([]byte, 16)), nil, true},
+		{"seed not base64", "not a seed!", nil, true},
+		{"short trusted key", "", []string{base64.StdEncoding.EncodeToString(make([]byte, 16))}, true},
+		{"trusted key not base64", "", []string{"not a key!"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewBackupSigner(tt.seed, tt.trusted); (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The same seed is the same key across restarts and replicas
+	seed := newSeed(t)
+	a, _ := NewBackupSigner(seed, nil)
+	b, _ := NewBackupSigner(seed, nil)
+	if a.PublicKey() != b.PublicKey() || a.KeyID() != b.KeyID() {
+		t.Error("one seed gave two keys")
+	}
+}
+
+func TestBackupWriterNeedsWalletKey(t *testing.T) {
+	signer, _ := NewBackupSigner(newSeed(t), nil)
+	if _, err := signer.NewBackupWriter(&bytes.Buffer{}, backupWallet, "not a key"); err == nil {
+		t.Error("NewBackupWriter accepted an invalid wallet key")
+	}
+}
@@ -1,7 +1,8 @@
-package fabric
+package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"pass-chain/backend/pkg/logger"
@@ -20,6 +21,10 @@ type FabricClient struct {
 	logger        *logger.Logger
 }
 
+// ErrShardNotFound means the chaincode answered that no share2 is stored
+// under the key. A failed query is a different error.
+var ErrShardNotFound = errors.New("shard not found")
+
 // FabricConfig holds Fabric connection config
 type FabricConfig struct {
 	ConfigPath    string
@@ -52,7 +57,7 @@ func NewFabricClient(cfg *FabricConfig, log *logger.Logger) (*FabricClient, erro
 		sdk:           sdk,
 		channelClient: channelClient,
 		channelID:     cfg.ChannelID,
-		chaincode     cfg.ChaincodeID,
+		chaincode:     cfg.ChaincodeID,
 		logger:        log,
 	}, nil
 }
@@ -64,6 +69,12 @@ func (fc *FabricClient) Close() {
 	}
 }
 
+// ShardKey is the Fabric key of share2 for version 2 onwards of a credential.
+// Versions never overwrite each other on the ledger.
+func ShardKey(credentialID string, version int) string {
+	return fmt.Sprintf("%s:v%d", credentialID, version)
+}
+
 // StoreShard stores Share2 in Fabric blockchain (Private Data Collection)
 func (fc *FabricClient) StoreShard(wallet, credentialID, share2 string) (string, error) {
 	req := channel.Request{
@@ -96,6 +107,11 @@ func (fc *FabricClient) ReadShard(wallet, credentialID string) (string, error) {
 		return "", fmt.Errorf("failed to read shard: %w", err)
 	}
 
+	// Missing private data reads back empty
+	if len(resp.Payload) == 0 || string(resp.Payload) == "null" {
+		return "", ErrShardNotFound
+	}
+
 	var result map[string]interface{}
 	if err := json.Unmarshal(resp.Payload, &result); err != nil {
 		return "", fmt.Errorf("failed to parse shard response: %w", err)
@@ -110,6 +126,25 @@ func (fc *FabricClient) ReadShard(wallet, credentialID string) (string, error) {
 	return share2, nil
 }
 
+// DeleteShard removes a stored Share2 from the private data collection.
+// Past transactions stay on the ledger; only the shard itself is purged.
+func (fc *FabricClient) DeleteShard(wallet, credentialID string) (string, error) {
+	req := channel.Request{
+		ChaincodeID: fc.chaincode,
+		Fcn:         "DeleteShard",
+		Args:        [][]byte{[]byte(wallet), []byte(credentialID)},
+	}
+
+	resp, err := fc.channelClient.Execute(req)
+	if err != nil {
+		fc.logger.Error("Failed to delete shard from Fabric", "error", err)
+		return "", fmt.Errorf("failed to delete shard: %w", err)
+	}
+
+	fc.logger.Info("Shard deleted from Fabric", "txID", resp.TransactionID, "credentialID", credentialID)
+	return string(resp.TransactionID), nil
+}
+
 // LogAccess logs credential access to Fabric blockchain
 func (fc *FabricClient) LogAccess(wallet, credentialID, action, ipHash string) (string, error) {
 	req := channel.Request{
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NonceStore holds short-lived, single-use values such as login nonces,
+// and remembers fingerprints of signatures that were already accepted
+type NonceStore interface {
+	// Put stores value under key until ttl elapses
+	Put(ctx context.Context, key, value string, ttl time.Duration) error
+	// Take returns the value under key and removes it, so it can only be used once
+	Take(ctx context.Context, key string) (string, bool, error)
+	// MarkSeen records key for ttl and reports whether it was new.
+	// A false result means the key was already seen (a replay).
+	MarkSeen(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// MemoryNonceStore is an in-process NonceStore for development.
+// It is not shared between replicas.
+type MemoryNonceStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// Put stores value under key until ttl elapses
+func (s *MemoryNonceStore) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+	s.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Take returns the value under key and removes it
+func (s *MemoryNonceStore) Take(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return "", false, nil
+	}
+	delete(s.entries, key)
+
+	if time.Now().After(entry.expiresAt) {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+// MarkSeen records key for ttl and reports whether it was new
+func (s *MemoryNonceStore) MarkSeen(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		return false, nil
+	}
+
+	s.pruneLocked(now)
+	s.entries[key] = memoryEntry{expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryNonceStore) pruneLocked(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryNonceStoreTake(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		wait   time.Duration
+		takes  int
+		wantOK []bool
+	}{
+		{"single use", time.Minute, 0, 2, []bool{true, false}},
+		{"expired", 10 * time.Millisecond, 20 * time.Millisecond, 1, []bool{false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryNonceStore()
+			if err := store.Put(ctx, "key", "value", tt.ttl); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			time.Sleep(tt.wait)
+
+			for i := 0; i < tt.takes; i++ {
+				value, ok, err := store.Take(ctx, "key")
+				if err != nil {
+					t.Fatalf("Take: %v", err)
+				}
+				if ok != tt.wantOK[i] {
+					t.Fatalf("take %d: ok = %v, want %v", i+1, ok, tt.wantOK[i])
+				}
+				if ok && value != "value" {
+					t.Errorf("take %d: value = %q", i+1, value)
+				}
+			}
+		})
+	}
+
+	store := NewMemoryNonceStore()
+	if _, ok, _ := store.Take(ctx, "missing"); ok {
+		t.Error("Take found a key that was never put")
+	}
+}
+
+func TestMemoryNonceStoreMarkSeen(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryNonceStore()
+
+	steps := []struct {
+		name    string
+		key     string
+		ttl     time.Duration
+		wait    time.Duration
+		wantNew bool
+	}{
+		{"first", "sig", 10 * time.Millisecond, 0, true},
+		{"replay", "sig", 10 * time.Millisecond, 0, false},
+		{"other key", "other", time.Minute, 0, true},
+		{"after ttl", "sig", time.Minute, 20 * time.Millisecond, true},
+		{"replay again", "sig", time.Minute, 0, false},
+	}
+
+	for _, step := range steps {
+		time.Sleep(step.wait)
+		fresh, err := store.MarkSeen(ctx, step.key, step.ttl)
+		if err != nil {
+			t.Fatalf("%s: MarkSeen: %v", step.name, err)
+		}
+		if fresh != step.wantNew {
+			t.Errorf("%s: new = %v, want %v", step.name, fresh, step.wantNew)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "passchain:"
+
+// NewRedisClient connects to Redis and checks the connection
+func NewRedisClient(address, password string) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: password,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// RedisNonceStore is a NonceStore shared by every API replica
+type RedisNonceStore struct {
+	client *redis.Client
+}
+
+func NewRedisNonceStore(client *redis.Client) *RedisNonceStore {
+	return &RedisNonceStore{
+		client: client,
+	}
+}
+
+func (s *RedisNonceStore) key(key string) string {
+	return redisKeyPrefix + "nonce:" + key
+}
+
+// Put stores value under key until ttl elapses
+func (s *RedisNonceStore) Put(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.key(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store nonce: %w", err)
+	}
+	return nil
+}
+
+// Take returns the value under key and removes it atomically (GETDEL)
+func (s *RedisNonceStore) Take(ctx context.Context, key string) (string, bool, error) {
+	value, err := s.client.GetDel(ctx, s.key(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to take nonce: %w", err)
+	}
+	return value, true, nil
+}
+
+// MarkSeen records key for ttl (SET NX) and reports whether it was new
+func (s *RedisNonceStore) MarkSeen(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	fresh, err := s.client.SetNX(ctx, s.key(key), "1", ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record fingerprint: %w", err)
+	}
+	return fresh, nil
+}
+
+// RedisSessionStore is a SessionStore shared by every API replica, so a
+// revoked session stops working everywhere on the next request.
+//
+// Layout:
+//
+//	passchain:session:<token hash>        JSON Session, expires with the session
+//	passchain:wallet-sessions:<wallet>    hash of session ID -> token hash
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
+	return &RedisSessionStore{
+		client: client,
+	}
+}
+
+func (s *RedisSessionStore) sessionKey(tokenHash string) string {
+	return redisKeyPrefix + "session:" + tokenHash
+}
+
+func (s *RedisSessionStore) walletKey(wallet string) string {
+	return redisKeyPrefix + "wallet-sessions:" + walletKey(wallet)
+}
+
+// Create stores a session until its ExpiresAt
+func (s *RedisSessionStore) Create(ctx context.Context, tokenHash string, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, s.sessionKey(tokenHash), data, ttl)
+	pipe.HSet(ctx, s.walletKey(session.WalletAddress), session.ID, tokenHash)
+	pipe.Expire(ctx, s.walletKey(session.WalletAddress), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
+
+// Get returns the live session for a token hash
+func (s *RedisSessionStore) Get(ctx context.Context, tokenHash string) (*Session, error) {
+	data, err := s.client.Get(ctx, s.sessionKey(tokenHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &session, nil
+}
+
+// Delete removes a session; deleting an unknown session is not an error
+func (s *RedisSessionStore) Delete(ctx context.Context, tokenHash string) error {
+	session, err := s.Get(ctx, tokenHash)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, s.sessionKey(tokenHash))
+	pipe.HDel(ctx, s.walletKey(session.WalletAddress), session.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// Touch records activity on a session, keeping its expiry
+func (s *RedisSessionStore) Touch(ctx context.Context, tokenHash string, seenAt time.Time, ipHash string) error {
+	session, err := s.Get(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	session.LastSeenAt = seenAt
+	session.IPHash = ipHash
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	// XX: do not resurrect a session revoked since the read above
+	if err := s.client.SetArgs(ctx, s.sessionKey(tokenHash), data, redis.SetArgs{KeepTTL: true, Mode: "XX"}).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// List returns the live sessions of a wallet, most recently seen first
+func (s *RedisSessionStore) List(ctx context.Context, wallet string) ([]Session, error) {
+	index, err := s.client.HGetAll(ctx, s.walletKey(wallet)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := []Session{}
+	var stale []string
+	for id, tokenHash := range index {
+		session, err := s.Get(ctx, tokenHash)
+		if errors.Is(err, ErrSessionNotFound) {
+			stale = append(stale, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	// Expired sessions leave their index entry behind; tidy up lazily
+	if len(stale) > 0 {
+		s.client.HDel(ctx, s.walletKey(wallet), stale...)
+	}
+
+	sortByLastSeen(sessions)
+	return sessions, nil
+}
+
+// Revoke deletes one session of a wallet by its public ID
+func (s *RedisSessionStore) Revoke(ctx context.Context, wallet, sessionID string) error {
+	tokenHash, err := s.client.HGet(ctx, s.walletKey(wallet), sessionID).Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, s.sessionKey(tokenHash))
+	pipe.HDel(ctx, s.walletKey(wallet), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll deletes every session of a wallet except keepID
+func (s *RedisSessionStore) RevokeAll(ctx context.Context, wallet, keepID string) (int, error) {
+	index, err := s.client.HGetAll(ctx, s.walletKey(wallet)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	revoked := 0
+	for id, tokenHash := range index {
+		if id == keepID {
+			continue
+		}
+		pipe.Del(ctx, s.sessionKey(tokenHash))
+		pipe.HDel(ctx, s.walletKey(wallet), id)
+		revoked++
+	}
+	if revoked == 0 {
+		return 0, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return revoked, nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a wallet login issued after a verified SIWE message
+type Session struct {
+	ID            string    `json:"id"`
+	WalletAddress string    `json:"walletAddress"`
+	ChainID       int64     `json:"chainId"`
+	DeviceID      string    `json:"deviceId"`  // Stable hash of the user agent
+	UserAgent     string    `json:"userAgent"` // As sent at login
+	IPHash        string    `json:"ipHash"`    // Coarse (network prefix) hash of the last seen IP
+	CreatedAt     time.Time `json:"createdAt"`
+	LastSeenAt    time.Time `json:"lastSeenAt"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+// SessionStore keeps sessions keyed by the hash of their bearer token,
+// indexed by wallet so a user can list and revoke their own sessions
+type SessionStore interface {
+	Create(ctx context.Context, tokenHash string, session *Session) error
+	Get(ctx context.Context, tokenHash string) (*Session, error)
+	Delete(ctx context.Context, tokenHash string) error
+
+	// Touch records activity on a session
+	Touch(ctx context.Context, tokenHash string, seenAt time.Time, ipHash string) error
+	// List returns the live sessions of a wallet, most recently seen first
+	List(ctx context.Context, wallet string) ([]Session, error)
+	// Revoke deletes one session of a wallet by its public ID
+	Revoke(ctx context.Context, wallet, sessionID string) error
+	// RevokeAll deletes every session of a wallet except keepID (may be empty)
+	// and returns how many were revoked
+	RevokeAll(ctx context.Context, wallet, keepID string) (int, error)
+}
+
+// walletKey normalises wallet addresses for indexing (checksum casing varies)
+func walletKey(wallet string) string {
+	return strings.ToLower(wallet)
+}
+
+func sortByLastSeen(sessions []Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+}
+
+// MemorySessionStore is an in-process SessionStore for development.
+// It is not shared between replicas.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]Session),
+	}
+}
+
+// Create stores a session until its ExpiresAt
+func (s *MemorySessionStore) Create(ctx context.Context, tokenHash string, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+	s.sessions[tokenHash] = *session
+	return nil
+}
+
+// Get returns the live session for a token hash
+func (s *MemorySessionStore) Get(ctx context.Context, tokenHash string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[tokenHash]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	if time.Now().After(session.ExpiresAt) {
+		delete(s.sessions, tokenHash)
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// Delete removes a session; deleting an unknown session is not an error
+func (s *MemorySessionStore) Delete(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, tokenHash)
+	return nil
+}
+
+// Touch records activity on a session
+func (s *MemorySessionStore) Touch(ctx context.Context, tokenHash string, seenAt time.Time, ipHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[tokenHash]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	session.LastSeenAt = seenAt
+	session.IPHash = ipHash
+	s.sessions[tokenHash] = session
+	return nil
+}
+
+// List returns the live sessions of a wallet, most recently seen first
+func (s *MemorySessionStore) List(ctx context.Context, wallet string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(time.Now())
+
+	sessions := []Session{}
+	for _, session := range s.sessions {
+		if walletKey(session.WalletAddress) == walletKey(wallet) {
+			sessions = append(sessions, session)
+		}
+	}
+	sortByLastSeen(sessions)
+	return sessions, nil
+}
+
+// Revoke deletes one session of a wallet by its public ID
+func (s *MemorySessionStore) Revoke(ctx context.Context, wallet, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, session := range s.sessions {
+		if session.ID == sessionID && walletKey(session.WalletAddress) == walletKey(wallet) {
+			delete(s.sessions, hash)
+			return nil
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// RevokeAll deletes every session of a wallet except keepID
+func (s *MemorySessionStore) RevokeAll(ctx context.Context, wallet, keepID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revoked := 0
+	for hash, session := range s.sessions {
+		if walletKey(session.WalletAddress) == walletKey(wallet) && session.ID != keepID {
+			delete(s.sessions, hash)
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (s *MemorySessionStore) pruneLocked(now time.Time) {
+	for hash, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, hash)
+		}
+	}
+}
@@ -1,11 +1,19 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	vault "github.com/hashicorp/vault/api"
 )
 
+// ErrSecretNotFound means Vault answered that the secret, or the version
+// asked for, does not exist or was deleted or destroyed. Other read errors
+// (timeouts, 5xx) say nothing about whether the secret is still there.
+var ErrSecretNotFound = errors.New("secret not found")
+
 type VaultService struct {
 	client *vault.Client
 }
@@ -41,6 +49,32 @@ func (v *VaultService) WriteSecret(path string, data map[string]interface{}) err
 	return nil
 }
 
+// WriteSecretVersion writes data to Vault KV v2 and returns the version it
+// created. Earlier versions of the path stay readable.
+func (v *VaultService) WriteSecretVersion(path string, data map[string]interface{}) (int, error) {
+	secret, err := v.client.Logical().Write(path, map[string]interface{}{
+		"data": data,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write secret: %w", err)
+	}
+
+	if secret == nil {
+		return 0, fmt.Errorf("no version metadata returned")
+	}
+
+	return secretVersion(secret.Data["version"])
+}
+
+// secretVersion converts a KV v2 version number as decoded by the client
+func secretVersion(raw interface{}) (int, error) {
+	version, err := strconv.Atoi(fmt.Sprint(raw))
+	if err != nil {
+		return 0, fmt.Errorf("invalid secret version: %v", raw)
+	}
+	return version, nil
+}
+
 // ReadSecret reads data from Vault KV v2
 func (v *VaultService) ReadSecret(path string) (map[string]interface{}, error) {
 	secret, err := v.client.Logical().Read(path)
@@ -49,10 +83,39 @@ func (v *VaultService) ReadSecret(path string) (map[string]interface{}, error) {
 	}
 
 	if secret == nil {
-		return nil, fmt.Errorf("secret not found")
+		return nil, ErrSecretNotFound
+	}
+
+	// Vault KV v2 wraps data in "data" key; it is null when the latest
+	// version is deleted or destroyed
+	if secret.Data["data"] == nil {
+		return nil, fmt.Errorf("latest version is deleted or destroyed: %w", ErrSecretNotFound)
+	}
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid secret format")
+	}
+
+	return data, nil
+}
+
+// ReadSecretVersion reads one version of a Vault KV v2 secret
+func (v *VaultService) ReadSecretVersion(path string, version int) (map[string]interface{}, error) {
+	secret, err := v.client.Logical().ReadWithData(path, map[string][]string{
+		"version": {strconv.Itoa(version)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret: %w", err)
+	}
+
+	if secret == nil {
+		return nil, ErrSecretNotFound
 	}
 
-	// Vault KV v2 wraps data in "data" key
+	// Deleted and destroyed versions come back with null data
+	if secret.Data["data"] == nil {
+		return nil, fmt.Errorf("secret version %d is deleted or destroyed: %w", version, ErrSecretNotFound)
+	}
 	data, ok := secret.Data["data"].(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid secret format")
@@ -61,6 +124,57 @@ func (v *VaultService) ReadSecret(path string) (map[string]interface{}, error) {
 	return data, nil
 }
 
+// SecretVersion is the KV v2 metadata of one version of a secret
+type SecretVersion struct {
+	Version      int    `json:"version"`
+	CreatedTime  string `json:"createdTime"`
+	DeletionTime string `json:"deletionTime,omitempty"`
+	Destroyed    bool   `json:"destroyed"`
+}
+
+// ReadSecretVersions returns the version metadata of a KV v2 secret, keyed by
+// version. path is the data path (secret/data/...).
+func (v *VaultService) ReadSecretVersions(path string) (map[int]SecretVersion, error) {
+	secret, err := v.client.Logical().Read(metadataPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret metadata: %w", err)
+	}
+
+	if secret == nil {
+		return nil, fmt.Errorf("secret not found")
+	}
+
+	raw, ok := secret.Data["versions"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid secret metadata format")
+	}
+
+	versions := make(map[int]SecretVersion, len(raw))
+	for key, value := range raw {
+		number, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		fields, _ := value.(map[string]interface{})
+		destroyed, _ := fields["destroyed"].(bool)
+		created, _ := fields["created_time"].(string)
+		deleted, _ := fields["deletion_time"].(string)
+		versions[number] = SecretVersion{
+			Version:      number,
+			CreatedTime:  created,
+			DeletionTime: deleted,
+			Destroyed:    destroyed,
+		}
+	}
+
+	return versions, nil
+}
+
+// metadataPath turns a KV v2 data path (secret/data/x) into its metadata path (secret/metadata/x)
+func metadataPath(path string) string {
+	return strings.Replace(path, "/data/", "/metadata/", 1)
+}
+
 // DeleteSecret deletes a secret from Vault
 func (v *VaultService) DeleteSecret(path string) error {
 	_, err := v.client.Logical().Delete(path)
@@ -71,6 +185,31 @@ func (v *VaultService) DeleteSecret(path string) error {
 	return nil
 }
 
+// PurgeSecret permanently removes every version of a KV v2 secret and its
+// metadata. path is the data path (secret/data/...).
+func (v *VaultService) PurgeSecret(path string) error {
+	_, err := v.client.Logical().Delete(metadataPath(path))
+	if err != nil {
+		return fmt.Errorf("failed to purge secret: %w", err)
+	}
+
+	return nil
+}
+
+// DestroySecretVersions permanently removes the data of some versions of a
+// KV v2 secret; the metadata and other versions stay. path is the data path
+// (secret/data/...).
+func (v *VaultService) DestroySecretVersions(path string, versions ...int) error {
+	_, err := v.client.Logical().Write(strings.Replace(path, "/data/", "/destroy/", 1), map[string]interface{}{
+		"versions": versions,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to destroy secret versions: %w", err)
+	}
+
+	return nil
+}
+
 // ListSecrets lists secrets at a path
 func (v *VaultService) ListSecrets(path string) ([]string, error) {
 	secret, err := v.client.Logical().List(path)
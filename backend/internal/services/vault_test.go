@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadSecretNotFound(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantNotFound bool
+	}{
+		{"no secret", http.StatusNotFound, "", true},
+		{"destroyed version", http.StatusNotFound, `{"data":{"data":null,"metadata":{"destroyed":true}}}`, true},
+		{"deleted version", http.StatusOK, `{"data":{"data":null,"metadata":{"deletion_time":"2024-05-01T10:00:00Z"}}}`, true},
+		{"server error", http.StatusInternalServerError, `{"errors":["internal error"]}`, false},
+		{"sealed", http.StatusServiceUnavailable, `{"errors":["Vault is sealed"]}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			t.Setenv("VAULT_MAX_RETRIES", "0")
+			vault, err := NewVaultService(server.URL, "test-token")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = vault.ReadSecret("secret/data/passchain/w/c")
+			if err == nil || errors.Is(err, ErrSecretNotFound) != tt.wantNotFound {
+				t.Errorf("ReadSecret: err = %v, want not found: %v", err, tt.wantNotFound)
+			}
+			_, err = vault.ReadSecretVersion("secret/data/passchain/w/c", 2)
+			if err == nil || errors.Is(err, ErrSecretNotFound) != tt.wantNotFound {
+				t.Errorf("ReadSecretVersion: err = %v, want not found: %v", err, tt.wantNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// WrapAlgorithm is the only wallet key algorithm keys are wrapped with
+const WrapAlgorithm = "x25519-xsalsa20-poly1305"
+
+// wrappedData is MetaMask's EthEncryptedData
+type wrappedData struct {
+	Version        string `json:"version"`
+	Nonce          string `json:"nonce"`
+	EphemPublicKey string `json:"ephemPublicKey"`
+	Ciphertext     string `json:"ciphertext"`
+}
+
+// ValidWrapped reports whether s is EthEncryptedData JSON of WrapAlgorithm,
+// as the client's wrapping of a key to a wallet produces. Only the shape is
+// checked; the server cannot open it.
+func ValidWrapped(s string) bool {
+	var wrapped wrappedData
+	if err := json.Unmarshal([]byte(s), &wrapped); err != nil || wrapped.Version != WrapAlgorithm {
+		return false
+	}
+	nonce, err := base64.StdEncoding.DecodeString(wrapped.Nonce)
+	if err != nil || len(nonce) != 24 {
+		return false
+	}
+	ephemPublic, err := base64.StdEncoding.DecodeString(wrapped.EphemPublicKey)
+	if err != nil || len(ephemPublic) != 32 {
+		return false
+	}
+	sealed, err := base64.StdEncoding.DecodeString(wrapped.Ciphertext)
+	return err == nil && len(sealed) > box.Overhead
+}
+
+// WrapForWallet encrypts plaintext to a wallet's published encryption key
+// (base64, from eth_getEncryptionPublicKey) under a throwaway sender key. It
+// returns the EthEncryptedData JSON; hex-encoded, it is what eth_decrypt takes.
+func WrapForWallet(publicKey, plaintext string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(raw) != 32 {
+		return "", errors.New("wallet encryption key must be a base64 32-byte x25519 key")
+	}
+	var recipient [32]byte
+	copy(recipient[:], raw)
+
+	ephemPublic, ephemPrivate, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+
+	sealed := box.Seal(nil, []byte(plaintext), &nonce, &recipient, ephemPrivate)
+	wrapped, err := json.Marshal(wrappedData{
+		Version:        WrapAlgorithm,
+		Nonce:          base64.StdEncoding.EncodeToString(nonce[:]),
+		EphemPublicKey: base64.StdEncoding.EncodeToString(ephemPublic[:]),
+		Ciphertext:     base64.StdEncoding.EncodeToString(sealed),
+	})
+	return string(wrapped), err
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// sealForWallet wraps plaintext to recipient as the client's wrapKey does
+func sealForWallet(t *testing.T, recipient *[32]byte, plaintext []byte) wrappedData {
+	t.Helper()
+	ephemPublic, ephemPrivate, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		t.Fatal(err)
+	}
+	return wrappedData{
+		Version:        WrapAlgorithm,
+		Nonce:          base64.StdEncoding.EncodeToString(nonce[:]),
+		EphemPublicKey: base64.StdEncoding.EncodeToString(ephemPublic[:]),
+		Ciphertext:     base64.StdEncoding.EncodeToString(box.Seal(nil, plaintext, &nonce, recipient, ephemPrivate)),
+	}
+}
+
+// openAsWallet opens EthEncryptedData JSON as eth_decrypt does
+func openAsWallet(t *testing.T, private *[32]byte, s string) (string, bool) {
+	t.Helper()
+	var wrapped wrappedData
+	if err := json.Unmarshal([]byte(s), &wrapped); err != nil {
+		t.Fatalf("wrapped data is not JSON: %v", err)
+	}
+	nonce, _ := base64.StdEncoding.DecodeString(wrapped.Nonce)
+	ephemPublic, _ := base64.StdEncoding.DecodeString(wrapped.EphemPublicKey)
+	sealed, _ := base64.StdEncoding.DecodeString(wrapped.Ciphertext)
+	if len(nonce) != 24 || len(ephemPublic) != 32 {
+		return "", false
+	}
+
+	var n [24]byte
+	var sender [32]byte
+	copy(n[:], nonce)
+	copy(sender[:], ephemPublic)
+	plain, ok := box.Open(nil, sealed, &n, &sender, private)
+	return string(plain), ok
+}
+
+func marshal(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestValidWrapped(t *testing.T) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKey := make([]byte, 32)
+	rand.Read(dataKey)
+	plaintext := []byte(base64.StdEncoding.EncodeToString(dataKey))
+
+	valid := sealForWallet(t, public, plaintext)
+	change := func(f func(*wrappedData)) string {
+		w := valid
+		f(&w)
+		return marshal(t, w)
+	}
+
+	tests := []struct {
+		name    string
+		wrapped string
+		want    bool
+	}{
+		{"sealed key", marshal(t, valid), true},
+		{"other algorithm", change(func(w *wrappedData) { w.Version = "x25519-chacha20-poly1305" }), false},
+		{"short nonce", change(func(w *wrappedData) { w.Nonce = base64.StdEncoding.EncodeToString(make([]byte, 12)) }), false},
+		{"short ephemeral key", change(func(w *wrappedData) { w.EphemPublicKey = base64.StdEncoding.EncodeToString(make([]byte, 31)) }), false},
+		{"bare tag", change(func(w *wrappedData) { w.Ciphertext = base64.StdEncoding.EncodeToString(make([]byte, box.Overhead)) }), false},
+		{"ciphertext not base64", change(func(w *wrappedData) { w.Ciphertext = "%%%" }), false},
+		{"not JSON", "wrapped", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidWrapped(tt.wrapped); got != tt.want {
+				t.Errorf("ValidWrapped = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// What passes is what the recipient's wallet opens
+	opened, ok := openAsWallet(t, private, marshal(t, valid))
+	if !ok || opened != string(plaintext) {
+		t.Errorf("recipient could not open the wrapped key")
+	}
+}
+
+func TestWrapForWallet(t *testing.T) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPrivate, _ := box.GenerateKey(rand.Reader)
+	publicKey := base64.StdEncoding.EncodeToString(public[:])
+
+	wrapped, err := WrapForWallet(publicKey, "archive key")
+	if err != nil {
+		t.Fatalf("WrapForWallet: %v", err)
+	}
+	if !ValidWrapped(wrapped) {
+		t.Error("WrapForWallet output fails ValidWrapped")
+	}
+	if opened, ok := openAsWallet(t, private, wrapped); !ok || opened != "archive key" {
+		t.Errorf("wallet opened %q, %v", opened, ok)
+	}
+	if _, ok := openAsWallet(t, otherPrivate, wrapped); ok {
+		t.Error("another wallet opened the wrapped data")
+	}
+
+	again, _ := WrapForWallet(publicKey, "archive key")
+	if again == wrapped {
+		t.Error("wrapping twice gave the same output")
+	}
+
+	for _, bad := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := WrapForWallet(bad, "x"); err == nil || !strings.Contains(err.Error(), "x25519") {
+			t.Errorf("WrapForWallet(%q): err = %v", bad, err)
+		}
+	}
+}
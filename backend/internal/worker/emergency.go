@@ -0,0 +1,198 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// EmergencyGranter is the dead-man switch of emergency access: a request the
+// owner has not denied by its grant time is granted by releasing the keys the
+// owner escrowed for the contact (models.EmergencyEscrow) as shares. The
+// contact then reads them like any credential shared with them. The server
+// never holds a key it could open.
+type EmergencyGranter struct {
+	db     *database.Database
+	fabric *services.FabricClient // nil when Fabric is unavailable
+	logger *logger.Logger
+}
+
+func NewEmergencyGranter(db *database.Database, fabric *services.FabricClient, log *logger.Logger) *EmergencyGranter {
+	return &EmergencyGranter{
+		db:     db,
+		fabric: fabric,
+		logger: log,
+	}
+}
+
+// Run grants one batch of requests past their grant time
+func (g *EmergencyGranter) Run(ctx context.Context) error {
+	var requests []models.EmergencyRequest
+	err := g.db.WithContext(ctx).
+		Where("denied_at IS NULL AND granted_at IS NULL AND grant_at <= ?", time.Now()).
+		Order("grant_at").
+		Limit(batchSize).
+		Find(&requests).Error
+	if err != nil {
+		return err
+	}
+
+	for _, request := range requests {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := g.grant(ctx, request); err != nil {
+			g.logger.Error("Failed to grant emergency request", "id", request.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// grant claims the request, so a deny arriving now fails, then shares the
+// owner's credentials that have a key escrowed for the current version.
+// Others, and any that fail, are counted as skipped.
+func (g *EmergencyGranter) grant(ctx context.Context, request models.EmergencyRequest) error {
+	claim := g.db.WithContext(ctx).Model(&models.EmergencyRequest{}).
+		Where("id = ? AND denied_at IS NULL AND granted_at IS NULL", request.ID).
+		Update("granted_at", time.Now())
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil // Denied meanwhile, or another replica got there first
+	}
+
+	var granted, skipped int
+	var batch []models.Credential
+	err := g.db.WithContext(ctx).
+		Where("wallet_address IN ? AND org_id IS NULL AND (expires_at IS NULL OR expires_at > now())", accountWallets(g.db, request.OwnerWallet)).
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			for i := range batch {
+				credential := &batch[i]
+				// Neither could the contact satisfy
+				if credential.RequirePasskey || credential.RequiresApproval() {
+					skipped++
+					continue
+				}
+				if err := g.share(ctx, request, credential); err != nil {
+					if !errors.Is(err, gorm.ErrRecordNotFound) {
+						g.logger.Error("Failed to share credential with emergency contact", "request", request.ID, "id", credential.ID, "error", err)
+					}
+					skipped++
+					continue
+				}
+				granted++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	resultTx := g.logOnChain(request.OwnerWallet, request.ID, "emergency_grant")
+	if err := g.db.WithContext(ctx).Model(&models.EmergencyRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
+		"granted":        granted,
+		"skipped":        skipped,
+		"result_tx_hash": resultTx,
+	}).Error; err != nil {
+		g.logger.Error("Failed to record emergency grant", "id", request.ID, "error", err)
+	}
+
+	g.notify(request.ContactWallet, models.NotificationEmergencyGranted,
+		"Emergency access to "+request.OwnerWallet+" was granted; their credentials are now shared with you")
+	g.notify(request.OwnerWallet, models.NotificationEmergencyGranted,
+		"Your credentials were shared with emergency contact "+request.ContactWallet)
+
+	g.logger.Info("Emergency access granted", "id", request.ID, "owner", request.OwnerWallet, "contact", request.ContactWallet, "granted", granted, "skipped", skipped)
+	return nil
+}
+
+// share releases the key escrowed for the contact for credential's current
+// version as a share. gorm.ErrRecordNotFound means there is none.
+func (g *EmergencyGranter) share(ctx context.Context, request models.EmergencyRequest, credential *models.Credential) error {
+	var escrow models.EmergencyEscrow
+	if err := g.db.WithContext(ctx).
+		Where("contact_id = ? AND credential_id = ? AND version = ?", request.ContactID, credential.ID, credential.Version).
+		First(&escrow).Error; err != nil {
+		return err
+	}
+
+	// Replace any share the owner made by hand
+	var share models.CredentialShare
+	err := g.db.WithContext(ctx).
+		Where("credential_id = ? AND recipient_wallet = ? AND revoked_at IS NULL", credential.ID, request.ContactWallet).
+		First(&share).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		share = models.CredentialShare{
+			CredentialID:    credential.ID,
+			OwnerWallet:     credential.WalletAddress,
+			RecipientWallet: request.ContactWallet,
+		}
+	} else if err != nil {
+		return err
+	}
+	share.WrappedKey = escrow.WrappedKey
+	share.Version = escrow.Version
+	share.EmergencyRequestID = &request.ID
+
+	if err := g.db.WithContext(ctx).Save(&share).Error; err != nil {
+		return err
+	}
+
+	entry := &models.AuditLog{
+		CredentialID:  credential.ID,
+		WalletAddress: credential.WalletAddress,
+		Action:        "emergency_grant",
+		ActorWallet:   request.ContactWallet,
+		Timestamp:     time.Now(),
+		TxHash:        g.logOnChain(credential.WalletAddress, credential.ID, "emergency_grant"),
+	}
+	if err := g.db.Create(entry).Error; err != nil {
+		g.logger.Error("Failed to write audit log", "error", err)
+	}
+	return nil
+}
+
+func (g *EmergencyGranter) logOnChain(walletAddress, subjectID, action string) string {
+	if g.fabric == nil {
+		return ""
+	}
+	txID, err := g.fabric.LogAccess(walletAddress, subjectID, action, "")
+	if err != nil {
+		g.logger.Error("Failed to log to Fabric", "id", subjectID, "action", action, "error", err)
+		return ""
+	}
+	return txID
+}
+
+func (g *EmergencyGranter) notify(wallet, kind, message string) {
+	notification := &models.Notification{
+		WalletAddress: wallet,
+		Kind:          kind,
+		Message:       message,
+	}
+	if err := g.db.Create(notification).Error; err != nil {
+		g.logger.Error("Failed to create notification", "wallet", wallet, "error", err)
+	}
+}
+
+// accountWallets lists the wallets linked to wallet's account (just wallet
+// if it has none), like the handlers' linkedWallets
+func accountWallets(db *database.Database, wallet string) []string {
+	var wallets []string
+	db.Model(&models.AccountWallet{}).
+		Where("account_id = (?)", db.Model(&models.AccountWallet{}).Select("account_id").Where("wallet_address = ?", wallet)).
+		Pluck("wallet_address", &wallets)
+
+	if len(wallets) == 0 {
+		return []string{wallet}
+	}
+	return wallets
+}
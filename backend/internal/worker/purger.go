@@ -0,0 +1,190 @@
+package worker
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// Purger empties the trash: once a deleted credential's purge_at has passed,
+// its shares are destroyed in Vault and Fabric and its rows are removed.
+// It also destroys the payloads of share links that expired or were used up.
+type Purger struct {
+	db     *database.Database
+	vault  *services.VaultService
+	fabric *services.FabricClient // nil when Fabric is unavailable
+	logger *logger.Logger
+}
+
+func NewPurger(db *database.Database, vault *services.VaultService, fabric *services.FabricClient, log *logger.Logger) *Purger {
+	return &Purger{
+		db:     db,
+		vault:  vault,
+		fabric: fabric,
+		logger: log,
+	}
+}
+
+// Run purges one batch of credentials whose retention has ended and one of
+// spent share links
+func (p *Purger) Run(ctx context.Context) error {
+	if err := p.purgeLinks(ctx); err != nil {
+		return err
+	}
+
+	var credentials []models.Credential
+	err := p.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND purge_at <= ?", time.Now()).
+		Order("purge_at").
+		Limit(batchSize).
+		Find(&credentials).Error
+	if err != nil {
+		return err
+	}
+
+	for _, credential := range credentials {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		p.purge(ctx, credential)
+	}
+
+	return nil
+}
+
+// purge destroys the shares before the rows, so a failure leaves the
+// credential in the trash to be retried on the next run
+func (p *Purger) purge(ctx context.Context, credential models.Credential) {
+	if !p.sharesVaultPath(credential) {
+		if err := p.vault.PurgeSecret(credential.VaultPath); err != nil {
+			p.logger.Error("Failed to purge credential from Vault", "id", credential.ID, "error", err)
+			return
+		}
+	}
+
+	var versions []int
+	p.db.Model(&models.CredentialVersion{}).
+		Where("credential_id = ?", credential.ID).
+		Pluck("version", &versions)
+
+	if p.fabric != nil {
+		for _, key := range shardKeys(credential, versions) {
+			if _, err := p.fabric.DeleteShard(credential.WalletAddress, key); err != nil {
+				// Not every version reached the ledger; nothing to retry
+				p.logger.Warn("Failed to delete shard from Fabric", "id", credential.ID, "key", key, "error", err)
+			}
+		}
+	}
+
+	var purged bool
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM credential_tags WHERE credential_id = ?", credential.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("credential_id = ?", credential.ID).Delete(&models.CredentialVersion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("credential_id = ?", credential.ID).Delete(&models.CredentialShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("credential_id = ?", credential.ID).Delete(&models.EmergencyEscrow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("credential_id = ?", credential.ID).Delete(&models.OrgMemberKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("credential_id = ?", credential.ID).Delete(&models.RefreshDelta{}).Error; err != nil {
+			return err
+		}
+		requests := tx.Model(&models.ApprovalRequest{}).Select("id").Where("credential_id = ?", credential.ID)
+		if err := tx.Where("request_id IN (?)", requests).Delete(&models.Approval{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("credential_id = ?", credential.ID).Delete(&models.ApprovalRequest{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id = ? AND purge_at <= ?", credential.ID, time.Now()).Delete(&models.Credential{})
+		purged = result.RowsAffected > 0
+		return result.Error
+	})
+	if err != nil {
+		p.logger.Error("Failed to purge credential", "id", credential.ID, "error", err)
+		return
+	}
+	if !purged {
+		return // Another replica finished first
+	}
+
+	entry := &models.AuditLog{
+		CredentialID:  credential.ID,
+		WalletAddress: credential.WalletAddress,
+		Action:        "purge",
+		Timestamp:     time.Now(),
+	}
+	if err := p.db.Create(entry).Error; err != nil {
+		p.logger.Error("Failed to write audit log", "error", err)
+	}
+
+	p.logger.Info("Credential purged", "id", credential.ID, "wallet", credential.WalletAddress)
+}
+
+// purgeLinks removes the payload of share links past their expiry or out of
+// views. A used-up link is normally destroyed by its last redemption; this
+// retries it when that failed.
+func (p *Purger) purgeLinks(ctx context.Context) error {
+	var links []models.ShareLink
+	err := p.db.WithContext(ctx).
+		Where("destroyed_at IS NULL AND (expires_at <= ? OR views >= max_views)", time.Now()).
+		Limit(batchSize).
+		Find(&links).Error
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if err := p.vault.PurgeSecret(link.VaultPath); err != nil {
+			p.logger.Error("Failed to purge share link from Vault", "id", link.ID, "error", err)
+			continue
+		}
+		err := p.db.WithContext(ctx).Model(&link).Updates(map[string]interface{}{
+			"encrypted_data": "",
+			"nonce":          "",
+			"share2":         "",
+			"destroyed_at":   time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sharesVaultPath reports whether another live or trashed credential uses
+// the same Vault path, whose secret must then survive this purge
+func (p *Purger) sharesVaultPath(credential models.Credential) bool {
+	var count int64
+	p.db.Unscoped().Model(&models.Credential{}).
+		Where("vault_path = ? AND id <> ? AND (deleted_at IS NULL OR purge_at IS NOT NULL)", credential.VaultPath, credential.ID).
+		Count(&count)
+	return count > 0
+}
+
+// shardKeys lists the Fabric keys share2 of the credential may be stored
+// under: the first version uses the last segment of the Vault path (the
+// original name, or the ID after a wallet migration), later ones ShardKey
+func shardKeys(credential models.Credential, versions []int) []string {
+	keys := []string{path.Base(credential.VaultPath)}
+	for _, version := range versions {
+		if version > 1 {
+			keys = append(keys, services.ShardKey(credential.ID, version))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+// Reaper warns owners about credentials that are about to expire and removes
+// the expired ones. Every credential is claimed with a conditional update
+// first, so several replicas can run it side by side.
+type Reaper struct {
+	db         *database.Database
+	vault      *services.VaultService
+	fabric     *services.FabricClient // nil when Fabric is unavailable
+	warnBefore time.Duration
+	logger     *logger.Logger
+}
+
+func NewReaper(db *database.Database, vault *services.VaultService, fabric *services.FabricClient, warnBefore time.Duration, log *logger.Logger) *Reaper {
+	return &Reaper{
+		db:         db,
+		vault:      vault,
+		fabric:     fabric,
+		warnBefore: warnBefore,
+		logger:     log,
+	}
+}
+
+// Run is one sweep: warn first, then expire
+func (r *Reaper) Run(ctx context.Context) error {
+	if err := r.warnExpiring(ctx); err != nil {
+		return err
+	}
+	return r.expire(ctx)
+}
+
+// warnExpiring notifies owners once about credentials expiring within warnBefore
+func (r *Reaper) warnExpiring(ctx context.Context) error {
+	now := time.Now()
+
+	var credentials []models.Credential
+	err := r.db.WithContext(ctx).
+		Where("expires_at > ? AND expires_at <= ? AND expiry_notified_at IS NULL", now, now.Add(r.warnBefore)).
+		Order("expires_at").
+		Limit(batchSize).
+		Find(&credentials).Error
+	if err != nil {
+		return err
+	}
+
+	for _, credential := range credentials {
+		claim := r.db.WithContext(ctx).Model(&models.Credential{}).
+			Where("id = ? AND expiry_notified_at IS NULL", credential.ID).
+			Update("expiry_notified_at", now)
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue // Another replica got there first
+		}
+
+		r.notify(credential, models.NotificationExpiring,
+			fmt.Sprintf("%q expires on %s", credential.CredentialName, credential.ExpiresAt.UTC().Format(time.RFC1123)))
+	}
+
+	return nil
+}
+
+// expire removes share1 from Vault, soft-deletes the credential and records
+// an "expire" event on Fabric for every credential past its expiry. The
+// credential is due for purging at once, so it skips the trash and the
+// purger destroys the rest of its shares and rows on its next run.
+func (r *Reaper) expire(ctx context.Context) error {
+	var credentials []models.Credential
+	err := r.db.WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Order("expires_at").
+		Limit(batchSize).
+		Find(&credentials).Error
+	if err != nil {
+		return err
+	}
+
+	for _, credential := range credentials {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// The soft delete is the claim: only one replica sees a row affected
+		now := time.Now()
+		claim := r.db.WithContext(ctx).Model(&models.Credential{}).
+			Where("id = ?", credential.ID).
+			Updates(map[string]interface{}{
+				"deleted_at": now,
+				"purge_at":   now,
+			})
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		if err := r.vault.DeleteSecret(credential.VaultPath); err != nil {
+			r.logger.Error("Failed to delete expired credential from Vault", "id", credential.ID, "error", err)
+			// Continue anyway; the credential is already gone from the DB
+		}
+
+		var txHash string
+		if r.fabric != nil {
+			txID, err := r.fabric.LogAccess(credential.WalletAddress, credential.ID, "expire", "")
+			if err != nil {
+				r.logger.Error("Failed to log expiry to Fabric", "id", credential.ID, "error", err)
+			} else {
+				txHash = txID
+			}
+		}
+
+		entry := &models.AuditLog{
+			CredentialID:  credential.ID,
+			WalletAddress: credential.WalletAddress,
+			Action:        "expire",
+			Timestamp:     time.Now(),
+			TxHash:        txHash,
+		}
+		if err := r.db.Create(entry).Error; err != nil {
+			r.logger.Error("Failed to write audit log", "error", err)
+		}
+
+		r.notify(credential, models.NotificationExpired,
+			fmt.Sprintf("%q expired and was removed", credential.CredentialName))
+
+		r.logger.Info("Credential expired", "id", credential.ID, "wallet", credential.WalletAddress)
+	}
+
+	return nil
+}
+
+func (r *Reaper) notify(credential models.Credential, kind, message string) {
+	notification := &models.Notification{
+		WalletAddress: credential.WalletAddress,
+		Kind:          kind,
+		CredentialID:  &credential.ID,
+		Message:       message,
+	}
+	if err := r.db.Create(notification).Error; err != nil {
+		r.logger.Error("Failed to create notification", "id", credential.ID, "error", err)
+	}
+}
@@ -0,0 +1,394 @@
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"gorm.io/gorm"
+	"pass-chain/backend/internal/database"
+	"pass-chain/backend/internal/models"
+	"pass-chain/backend/internal/services"
+	"pass-chain/backend/pkg/logger"
+)
+
+var (
+	// ErrRefreshInProgress means another refresh of the credential holds it
+	ErrRefreshInProgress = errors.New("share refresh already in progress")
+	// ErrRefreshConflict means the credential was updated during the refresh,
+	// which was undone
+	ErrRefreshConflict = errors.New("credential changed during share refresh")
+	// ErrNoWalletKey means a credential split for recovery cannot get a
+	// RefreshDelta, as its owner has not published an encryption key
+	ErrNoWalletKey = errors.New("owner has not published an encryption key")
+	// ErrUnknownSplit means the server cannot pad a credential whose split
+	// scheme was never recorded; only a fresh split refreshes it
+	ErrUnknownSplit = errors.New("credential split scheme is unknown")
+)
+
+// FreshShares is a client-made split of a credential's unchanged key
+type FreshShares struct {
+	Share1      string
+	Share2      string
+	Share3Hash  string
+	SplitScheme string
+}
+
+// ShareRefresher re-shares credentials in place so that a slow leak of Vault
+// or Fabric does not add up over time. The key and encryptedData stay the
+// same; share1 gets a new Vault version, share2 is overwritten on Fabric, and
+// the replaced Vault version is destroyed.
+//
+// Only the current version's shares are replaced. The shares of earlier
+// credential versions (their Vault versions and Fabric keys, and share2 in
+// credential_versions) are what a rollback restores, so they stay until the
+// credential is purged; destroying them would end rollback to those
+// versions. Fabric also keeps the overwritten share2 in the key's history,
+// which a refresh cannot remove.
+//
+// Without FreshShares the pads depend on the credential's split scheme.
+// Under models.SplitXOR both shares are XORed with the same random pad,
+// which keeps share1 ^ share2 and so the client's share3 valid. Under
+// models.SplitRecovery share3 binds each share on its own, so share1 and
+// share2 get pads of their own, which are handed to the owner wrapped to
+// their wallet (models.RefreshDelta); the scheduled sweep skips them until
+// the owner has published an encryption key. Credentials whose scheme was
+// never recorded are never padded, since the wrong pads would break share3
+// for good once the replaced share1 is destroyed; only a fresh split, which
+// records SplitRecovery, refreshes them.
+//
+// Shares and emergency escrows wrap the data key, which a refresh leaves as
+// it is, so they stay valid and the version is not bumped.
+//
+// A reveal racing a refresh may get shares from both sides of it and fail to
+// decrypt; reading again fixes it.
+type ShareRefresher struct {
+	db     *database.Database
+	vault  *services.VaultService
+	fabric *services.FabricClient // nil when Fabric is unavailable
+	maxAge time.Duration
+	logger *logger.Logger
+}
+
+func NewShareRefresher(db *database.Database, vault *services.VaultService, fabric *services.FabricClient, maxAge time.Duration, log *logger.Logger) *ShareRefresher {
+	return &ShareRefresher{
+		db:     db,
+		vault:  vault,
+		fabric: fabric,
+		maxAge: maxAge,
+		logger: log,
+	}
+}
+
+// Run refreshes one batch of credentials whose shares are older than maxAge
+func (r *ShareRefresher) Run(ctx context.Context) error {
+	var credentials []models.Credential
+	err := r.db.WithContext(ctx).
+		Where("COALESCE(shares_refreshed_at, created_at) <= ?", time.Now().Add(-r.maxAge)).
+		Where("split_scheme = ? OR (split_scheme = ? AND wallet_address IN (?))", models.SplitXOR, models.SplitRecovery,
+			r.db.Model(&models.WalletKey{}).Select("wallet_address").Where("algorithm = ?", services.WrapAlgorithm)).
+		Order("COALESCE(shares_refreshed_at, created_at)").
+		Limit(batchSize).
+		Find(&credentials).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range credentials {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		credential := &credentials[i]
+		txID, err := r.Refresh(ctx, credential, nil)
+		if err != nil {
+			r.logger.Error("Failed to refresh shares", "id", credential.ID, "error", err)
+			continue
+		}
+
+		entry := &models.AuditLog{
+			CredentialID:  credential.ID,
+			WalletAddress: credential.WalletAddress,
+			Action:        "refresh",
+			OrgID:         credential.OrgID,
+			Timestamp:     time.Now(),
+			TxHash:        txID,
+		}
+		if err := r.db.Create(entry).Error; err != nil {
+			r.logger.Error("Failed to write audit log", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Refresh re-shares credential, with fresh (a client's split) or random pads
+// if fresh is nil, and returns the Fabric transaction of the new share2.
+// On failure the credential keeps its current shares.
+func (r *ShareRefresher) Refresh(ctx context.Context, credential *models.Credential, fresh *FreshShares) (string, error) {
+	// The claim keeps concurrent refreshes of the same credential apart
+	claimedAt := time.Now().Truncate(time.Microsecond) // As stored, so the reset below matches
+	claim := r.db.WithContext(ctx).Model(&models.Credential{}).
+		Where("id = ? AND version = ? AND shares_refreshed_at IS NOT DISTINCT FROM ?", credential.ID, credential.Version, credential.SharesRefreshedAt).
+		Update("shares_refreshed_at", claimedAt)
+	if claim.Error != nil {
+		return "", claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return "", ErrRefreshInProgress
+	}
+
+	txID, err := r.refresh(ctx, credential, fresh)
+	if err != nil {
+		// Let the next sweep retry
+		r.db.Model(&models.Credential{}).
+			Where("id = ? AND shares_refreshed_at = ?", credential.ID, claimedAt).
+			Update("shares_refreshed_at", credential.SharesRefreshedAt)
+		return "", err
+	}
+
+	credential.SharesRefreshedAt = &claimedAt
+	r.logger.Info("Shares refreshed", "id", credential.ID, "version", credential.Version, "txID", txID)
+	return txID, nil
+}
+
+func (r *ShareRefresher) refresh(ctx context.Context, credential *models.Credential, fresh *FreshShares) (string, error) {
+	// Reveals read the Vault version pinned in the history row; credentials
+	// from before version history read the latest
+	var pinned models.CredentialVersion
+	err := r.db.WithContext(ctx).Select("vault_version").
+		Where("credential_id = ? AND version = ?", credential.ID, credential.Version).
+		First(&pinned).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	current := pinned.VaultVersion
+	if current == 0 {
+		versions, err := r.vault.ReadSecretVersions(credential.VaultPath)
+		if err != nil {
+			return "", err
+		}
+		current = latestVersion(versions)
+	}
+	vaultData, err := r.vault.ReadSecretVersion(credential.VaultPath, current)
+	if err != nil {
+		return "", err
+	}
+	share1, ok := vaultData["share1"].(string)
+	if !ok {
+		return "", errors.New("invalid share1 in Vault")
+	}
+
+	next := fresh
+	var delta *models.RefreshDelta
+	if next == nil {
+		if next, delta, err = r.pad(ctx, credential, share1); err != nil {
+			return "", err
+		}
+	}
+
+	written, err := r.vault.WriteSecretVersion(credential.VaultPath, map[string]interface{}{
+		"share1":     next.Share1,
+		"created_at": "now",
+	})
+	if err != nil {
+		return "", err
+	}
+	unpinned := pinned.VaultVersion == 0
+
+	key := currentShardKey(credential)
+	txID := credential.BlockchainTxID
+	if r.fabric != nil {
+		if txID, err = r.fabric.StoreShard(credential.WalletAddress, key, next.Share2); err != nil {
+			r.undoVault(credential.VaultPath, written, current, unpinned)
+			return "", err
+		}
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Credential{}).
+			Where("id = ? AND version = ? AND share2 = ?", credential.ID, credential.Version, credential.Share2).
+			Updates(map[string]interface{}{
+				"share2":           next.Share2,
+				"share3_hash":      next.Share3Hash,
+				"split_scheme":     next.SplitScheme,
+				"blockchain_tx_id": txID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshConflict
+		}
+
+		// The owner's share3 follows the pads; a fresh split replaces it
+		if delta != nil {
+			if err := tx.Create(delta).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Where("credential_id = ? AND version = ?", credential.ID, credential.Version).Delete(&models.RefreshDelta{}).Error; err != nil {
+			return err
+		}
+
+		// Pin the new share1, recording the version first if it predates history
+		result = tx.Model(&models.CredentialVersion{}).
+			Where("credential_id = ? AND version = ?", credential.ID, credential.Version).
+			Updates(map[string]interface{}{
+				"share2":           next.Share2,
+				"share3_hash":      next.Share3Hash,
+				"split_scheme":     next.SplitScheme,
+				"vault_version":    written,
+				"blockchain_tx_id": txID,
+			})
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+		return tx.Create(&models.CredentialVersion{
+			CredentialID:   credential.ID,
+			Version:        credential.Version,
+			EncryptedData:  credential.EncryptedData,
+			Nonce:          credential.Nonce,
+			Metadata:       credential.Metadata,
+			Share2:         next.Share2,
+			Share3Hash:     next.Share3Hash,
+			SplitScheme:    next.SplitScheme,
+			VaultVersion:   written,
+			BlockchainTxID: txID,
+			WalletAddress:  credential.WalletAddress,
+		}).Error
+	})
+	if err != nil {
+		r.undoVault(credential.VaultPath, written, current, unpinned)
+		if r.fabric != nil {
+			if _, restoreErr := r.fabric.StoreShard(credential.WalletAddress, key, credential.Share2); restoreErr != nil {
+				r.logger.Error("Failed to restore share2 on Fabric", "id", credential.ID, "key", key, "error", restoreErr)
+			}
+		}
+		return "", err
+	}
+
+	if err := r.vault.DestroySecretVersions(credential.VaultPath, current); err != nil {
+		r.logger.Error("Failed to destroy replaced share1 in Vault", "id", credential.ID, "vaultVersion", current, "error", err)
+	}
+
+	credential.Share2 = next.Share2
+	credential.Share3Hash = next.Share3Hash
+	credential.SplitScheme = next.SplitScheme
+	credential.BlockchainTxID = txID
+	return txID, nil
+}
+
+// pad re-randomizes share1 and credential.Share2. Under the XOR split both
+// take the same pad; a split for recovery takes a pad per share, which the
+// returned delta hands to the owner.
+func (r *ShareRefresher) pad(ctx context.Context, credential *models.Credential, share1 string) (*FreshShares, *models.RefreshDelta, error) {
+	recovery := credential.SplitScheme == models.SplitRecovery
+	if !recovery && credential.SplitScheme != models.SplitXOR {
+		return nil, nil, ErrUnknownSplit
+	}
+
+	var key models.WalletKey
+	if recovery {
+		err := r.db.WithContext(ctx).Where("wallet_address = ?", credential.WalletAddress).First(&key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && key.Algorithm != services.WrapAlgorithm) {
+			return nil, nil, ErrNoWalletKey
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pads := make([]byte, 64)
+	if _, err := rand.Read(pads); err != nil {
+		return nil, nil, err
+	}
+	pad1, pad2 := pads[:32], pads[32:]
+	if !recovery {
+		pad2 = pad1
+	}
+
+	var err error
+	next := &FreshShares{Share3Hash: credential.Share3Hash, SplitScheme: credential.SplitScheme}
+	if next.Share1, err = xorShare(share1, pad1); err != nil {
+		return nil, nil, fmt.Errorf("share1: %w", err)
+	}
+	if next.Share2, err = xorShare(credential.Share2, pad2); err != nil {
+		return nil, nil, fmt.Errorf("share2: %w", err)
+	}
+	if !recovery {
+		return next, nil, nil
+	}
+
+	wrapped, err := services.WrapForWallet(key.PublicKey, base64.StdEncoding.EncodeToString(pads))
+	if err != nil {
+		return nil, nil, err
+	}
+	return next, &models.RefreshDelta{
+		CredentialID: credential.ID,
+		Version:      credential.Version,
+		WrappedDelta: wrapped,
+	}, nil
+}
+
+// undoVault destroys a share1 version the refresh wrote. An unpinned
+// credential reads the latest version, so if that is the one written, the
+// version it replaced is first written back on top.
+func (r *ShareRefresher) undoVault(vaultPath string, written, current int, unpinned bool) {
+	if unpinned {
+		versions, err := r.vault.ReadSecretVersions(vaultPath)
+		if err == nil && latestVersion(versions) == written {
+			previous, readErr := r.vault.ReadSecretVersion(vaultPath, current)
+			if readErr == nil {
+				_, err = r.vault.WriteSecretVersion(vaultPath, previous)
+			} else {
+				err = readErr
+			}
+		}
+		if err != nil {
+			r.logger.Error("Failed to restore share1 in Vault", "path", vaultPath, "error", err)
+		}
+	}
+
+	if err := r.vault.DestroySecretVersions(vaultPath, written); err != nil {
+		r.logger.Error("Failed to destroy refreshed share1 in Vault", "path", vaultPath, "vaultVersion", written, "error", err)
+	}
+}
+
+// latestVersion is the highest version number of a KV v2 secret
+func latestVersion(versions map[int]services.SecretVersion) int {
+	latest := 0
+	for number := range versions {
+		if number > latest {
+			latest = number
+		}
+	}
+	return latest
+}
+
+// currentShardKey is the Fabric key of share2 of the credential's current
+// version: the last segment of the Vault path for the first version, ShardKey
+// after (like the handlers' versionShardKey)
+func currentShardKey(credential *models.Credential) string {
+	if credential.Version == 1 {
+		return path.Base(credential.VaultPath)
+	}
+	return services.ShardKey(credential.ID, credential.Version)
+}
+
+// xorShare XORs a base64 share with pad
+func xorShare(share string, pad []byte) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(share)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) != len(pad) {
+		return "", fmt.Errorf("share is %d bytes, want %d", len(raw), len(pad))
+	}
+	for i := range raw {
+		raw[i] ^= pad[i]
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
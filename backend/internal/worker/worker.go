@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"pass-chain/backend/pkg/logger"
+)
+
+// batchSize caps the rows a worker handles per run so one sweep cannot hold
+// the database for long; the rest are picked up on the next tick
+const batchSize = 100
+
+// Every runs job immediately and then once per interval until ctx is
+// cancelled. A failed run is logged and retried on the next tick.
+func Every(ctx context.Context, interval time.Duration, name string, log *logger.Logger, job func(context.Context) error) {
+	if interval <= 0 {
+		log.Warn("Worker disabled", "worker", name)
+		return
+	}
+
+	log.Info("Worker started", "worker", name, "interval", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil && ctx.Err() == nil {
+			log.Error("Worker run failed", "worker", name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("Worker stopped", "worker", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
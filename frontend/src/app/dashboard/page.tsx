@@ -13,6 +13,7 @@ import {
   decryptData,
   splitSecret,
   reconstructSecret,
+  commitShare,
 } from '@/lib/crypto';
 import {
   createCredential,
@@ -30,7 +31,7 @@ interface CredentialWithPassword extends Credential {
 export default function DashboardPage() {
   const { address, isConnected } = useAccount();
   const router = useRouter();
-  const { signCredentialOperation } = useWalletSigner();
+  const { signCredentialOperation, signMessage } = useWalletSigner();
   const [credentials, setCredentials] = useState<CredentialWithPassword[]>([]);
   const [loading, setLoading] = useState(false);
   const [showAddModal, setShowAddModal] = useState(false);
@@ -48,10 +49,10 @@ export default function DashboardPage() {
     
     try {
       setLoading(true);
-      const { signature } = await signCredentialOperation('read');
-      const creds = await getCredentials(address, signature);
-      setCredentials(creds);
-      toast.success(`Loaded ${creds.length} credentials`);
+      const signed = await signCredentialOperation('read');
+      const page = await getCredentials(address, signed, { limit: 200 });
+      setCredentials(page.items);
+      toast.success(`Loaded ${page.items.length} credentials`);
     } catch (error) {
       console.error('Failed to load credentials:', error);
       toast.error('Failed to load credentials');
@@ -78,8 +79,12 @@ export default function DashboardPage() {
         if (!address) return;
         
         toast.info('Retrieving encryption keys...');
-        const { signature } = await signCredentialOperation('read', id);
-        const credentialData = await getCredentialById(id, address, signature);
+        const credentialData = await getCredentialById(
+          id,
+          address,
+          () => signCredentialOperation('read', id),
+          signMessage
+        );
         
         // Get user's backup share from localStorage
         const backupShares = JSON.parse(localStorage.getItem('pass-chain-backups') || '{}');
@@ -121,8 +126,7 @@ export default function DashboardPage() {
     if (!address || !confirm(`Delete credential "${name}"?`)) return;
     
     try {
-      const { signature } = await signCredentialOperation('delete', id, name);
-      await deleteCredential(id, address, signature);
+      await deleteCredential(id, address, signMessage);
       setCredentials(prev => prev.filter(c => c.id !== id));
       
       // Remove backup from localStorage
@@ -327,7 +331,7 @@ function CredentialCard({
           <div className="flex items-center gap-2">
             <span className="text-sm text-white font-mono">{credential.username}</span>
             <button
-              onClick={() => copyToClipboard(credential.username)}
+              onClick={() => copyToClipboard(credential.username ?? '')}
               className="text-gray-400 hover:text-white transition-colors"
             >
               <Copy className="h-4 w-4" />
@@ -385,7 +389,7 @@ function AddCredentialModal({
   onClose: () => void;
   onSuccess: () => void;
 }) {
-  const { signCredentialOperation } = useWalletSigner();
+  const { signMessage } = useWalletSigner();
   const [formData, setFormData] = useState({
     name: '',
     username: '',
@@ -409,13 +413,10 @@ function AddCredentialModal({
       
       // 3. Split the key into 3 shares (2-of-3)
       const { share1, share2, share3 } = splitSecret(encryptionKey);
+      const share3Hash = await commitShare(share3);
       
-      // 4. Sign the transaction with wallet
-      toast.info('Sign the transaction with your wallet...');
-      const { signature } = await signCredentialOperation('create', undefined, formData.name);
-      
-      // 5. Send to backend (share1 → Vault, share2 → Blockchain)
-      toast.info('Storing encrypted credential...');
+      // 4. Sign the request with wallet and send to backend (share1 → Vault, share2 → Blockchain)
+      toast.info('Sign the request with your wallet...');
       const result = await createCredential({
         name: formData.name,
         username: formData.username,
@@ -424,11 +425,12 @@ function AddCredentialModal({
         nonce,
         share1,
         share2,
+        share3Hash,
+        splitScheme: 'recovery',
         walletAddress,
-        signature,
-      });
+      }, signMessage);
       
-      // 6. Store share3 locally as backup
+      // 5. Store share3 locally as backup
       const backupShares = JSON.parse(localStorage.getItem('pass-chain-backups') || '{}');
       backupShares[result.id] = share3;
       localStorage.setItem('pass-chain-backups', JSON.stringify(backupShares));
@@ -18,6 +18,8 @@ import {
   Lock
 } from 'lucide-react';
 import { toast } from 'sonner';
+import { walletAuthHeaders } from '@/lib/api';
+import { useWalletSigner } from '@/lib/wallet';
 
 interface AuditLog {
   id: string;
@@ -40,6 +42,7 @@ interface BlockchainStats {
 
 export default function BlockchainExplorerPage() {
   const { address, isConnected } = useAccount();
+  const { signAuth } = useWalletSigner();
   const [auditLogs, setAuditLogs] = useState<AuditLog[]>([]);
   const [stats, setStats] = useState<BlockchainStats | null>(null);
   const [loading, setLoading] = useState(false);
@@ -58,12 +61,11 @@ export default function BlockchainExplorerPage() {
     setLoading(true);
     try {
       // Fetch from backend
+      const signed = await signAuth('view-audit-logs');
       const response = await fetch(
-        `${process.env.NEXT_PUBLIC_API_URL}/api/v1/audit-logs?wallet=${address}`,
+        `${process.env.NEXT_PUBLIC_API_URL}/api/v1/audit-logs`,
         {
-          headers: {
-            'X-Wallet-Address': address,
-          },
+          headers: walletAuthHeaders(address, signed),
         }
       );
 
@@ -85,12 +87,11 @@ export default function BlockchainExplorerPage() {
     if (!address) return;
 
     try {
+      const signed = await signAuth('view-stats');
       const response = await fetch(
-        `${process.env.NEXT_PUBLIC_API_URL}/api/v1/stats?wallet=${address}`,
+        `${process.env.NEXT_PUBLIC_API_URL}/api/v1/stats`,
         {
-          headers: {
-            'X-Wallet-Address': address,
-          },
+          headers: walletAuthHeaders(address, signed),
         }
       );
 
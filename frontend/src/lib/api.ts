@@ -1,52 +1,157 @@
 // API client for Pass Chain backend
 
+import { hashData } from './crypto';
+
 const API_BASE_URL = typeof window !== 'undefined' 
   ? (process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080')
   : 'http://passchain-backend:8080'; // Internal cluster URL for SSR
 
+/**
+ * A personal_sign message and its signature, used to authenticate a request
+ */
+export interface SignedMessage {
+  message: string;
+  signature: string;
+}
+
+/**
+ * Build wallet auth headers. The message is base64 encoded because it spans
+ * several lines and headers cannot carry newlines.
+ */
+export function walletAuthHeaders(
+  walletAddress: string,
+  signed: SignedMessage
+): Record<string, string> {
+  return {
+    'X-Wallet-Address': walletAddress,
+    'X-Signature': signed.signature,
+    'X-Auth-Message': btoa(signed.message),
+  };
+}
+
+/**
+ * Signs a message with the connected wallet (personal_sign)
+ */
+export type MessageSigner = (message: string) => Promise<string>;
+
+/**
+ * Sign the canonical form of a mutating request. Must match
+ * auth.CanonicalRequest in the backend byte for byte, so query parameters
+ * must be passed sorted by key.
+ */
+export async function signedRequestHeaders(
+  method: string,
+  path: string,
+  body: string,
+  walletAddress: string,
+  sign: MessageSigner,
+  query = ''
+): Promise<Record<string, string>> {
+  const timestamp = Date.now().toString();
+  const nonce = crypto.randomUUID();
+  const bodyHash = await hashData(body);
+
+  const message = [
+    'PassChain Signed Request v1',
+    `Method: ${method}`,
+    `Path: ${path}`,
+    `Query: ${query}`,
+    `Body-SHA256: ${bodyHash}`,
+    `Timestamp: ${timestamp}`,
+    `Nonce: ${nonce}`,
+  ].join('\n');
+
+  return {
+    'X-Wallet-Address': walletAddress,
+    'X-Signature': await sign(message),
+    'X-Timestamp': timestamp,
+    'X-Nonce': nonce,
+  };
+}
+
+export type CredentialKind = 'login' | 'api_token' | 'ssh_key' | 'totp' | 'note';
+
 export interface CreateCredentialRequest {
+  kind?: CredentialKind; // Defaults to 'login'
   name: string;
-  username: string;
+  username?: string; // Required for logins
   url?: string;
   encryptedData: string;
   nonce: string;
+  // Kind-specific extras, each value base64(nonce || ciphertext) under the credential key
+  metadata?: Record<string, string>;
+  expiresAt?: string; // ISO timestamp; removed automatically afterwards
+  orgId?: string; // Create in an organization vault (member role or above)
   share1: string; // Will be stored in Vault
   share2: string; // Will be stored in Blockchain
+  share3Hash?: string; // commitShare(share3); lets share3 recover a lost share later
+  splitScheme?: SplitScheme; // How share3 was made; without it the server never refreshes the shares on its own
   walletAddress: string;
-  signature: string;
 }
 
+/**
+ * How share3 was derived: 'recovery' is splitSecret, whose share3 works with
+ * either server share; 'xor' is the older split needing all three shares
+ */
+export type SplitScheme = 'xor' | 'recovery';
+
 export interface Credential {
   id: string;
   name: string;
-  username: string;
+  kind: CredentialKind;
+  username?: string;
   url?: string;
   encryptedData: string;
   nonce: string;
+  metadata?: Record<string, string>;
   walletAddress: string;
   createdAt: string;
   lastAccessed?: string;
+  folderId?: string;
+  tags?: { id: string; name: string }[];
+  expiresAt?: string;
+  purgeAt?: string; // Only set for credentials in the trash
+  sharedWithMe?: boolean; // Owned by another wallet (walletAddress)
+  orgId?: string; // In an organization vault; walletAddress is the creator
+  approvers?: string[]; // Wallets that approve reveals
+  approvalThreshold?: number; // Approvals needed per reveal (M of approvers)
+  sharesRefreshedAt?: string; // Last re-sharing of share1 and share2
+  splitScheme?: SplitScheme; // Unset for credentials saved before it was recorded
 }
 
 export interface RetrieveCredentialResponse extends Credential {
   share1: string; // Retrieved from Vault
   share2: string; // Retrieved from Blockchain
+  wrappedKey?: string; // Instead of the shares when sharedWithMe or for organization members; see unwrapKey
+  wrappedFor?: string; // Organization credentials: the member wallet to eth_decrypt wrappedKey with
+  refreshDeltas?: RefreshDelta[]; // share3 must take these first; see applyRefreshDeltas
+}
+
+/**
+ * Pads a server-made share refresh XORed into share1 and share2, wrapped to
+ * the owner's wallet (eth_decrypt wrappedDelta)
+ */
+export interface RefreshDelta {
+  id: string;
+  wrappedDelta: string;
 }
 
 /**
  * Create a new credential
  */
 export async function createCredential(
-  data: CreateCredentialRequest
+  data: CreateCredentialRequest,
+  sign: MessageSigner
 ): Promise<{ id: string; share3: string }> {
-  const response = await fetch(`${API_BASE_URL}/api/v1/credentials`, {
+  const path = '/api/v1/credentials';
+  const body = JSON.stringify(data);
+  const response = await fetch(`${API_BASE_URL}${path}`, {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
-      'X-Wallet-Address': data.walletAddress,
-      'X-Signature': data.signature,
+      ...(await signedRequestHeaders('POST', path, body, data.walletAddress, sign)),
     },
-    body: JSON.stringify(data),
+    body,
   });
 
   if (!response.ok) {
@@ -57,20 +162,155 @@ export async function createCredential(
   return response.json();
 }
 
+export interface ImportResult {
+  index: number;
+  name: string;
+  id?: string;
+  txId?: string;
+  error?: string;
+}
+
+/**
+ * Create many credentials at once (e.g. from a password manager export).
+ * Either every item is saved or none is; per-item errors are in the results.
+ */
+export async function importCredentials(
+  walletAddress: string,
+  credentials: Omit<CreateCredentialRequest, 'walletAddress'>[],
+  sign: MessageSigner
+): Promise<{ imported: number; results: ImportResult[] }> {
+  const path = '/api/v1/credentials/import';
+  const body = JSON.stringify({ walletAddress, credentials });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  const result = await response.json();
+  if (!response.ok) {
+    const error = new Error(result.error || 'Failed to import credentials') as Error & {
+      results?: ImportResult[];
+    };
+    error.results = result.results;
+    throw error;
+  }
+
+  return result;
+}
+
+/**
+ * Download a signed backup archive (NDJSON) of the wallet's credentials,
+ * encrypted to the wallet's published encryption key
+ */
+export async function exportBackup(
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<Blob> {
+  const path = '/api/v1/backups/export';
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: await signedRequestHeaders('POST', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    const result = await response.json().catch(() => ({}));
+    throw new Error(result.error || 'Failed to export backup');
+  }
+
+  return response.blob();
+}
+
+/**
+ * The archive key of a backup, wrapped to the wallet (EthEncryptedData JSON).
+ * eth_decrypt it to get the archiveKey restoreBackup takes.
+ */
+export function backupWrappedKey(archive: string): string {
+  const header = JSON.parse(archive.slice(0, archive.indexOf('\n')));
+  return header.wrappedKey;
+}
+
+/**
+ * Restore a backup archive produced by exportBackup. archiveKey is the
+ * unwrapped backupWrappedKey (base64). With skipExisting, credentials whose
+ * name already exists are left alone instead of failing. ids maps archived
+ * credential IDs to the restored ones; re-key stored share3 entries with it.
+ */
+export async function restoreBackup(
+  walletAddress: string,
+  archive: string,
+  archiveKey: string,
+  sign: MessageSigner,
+  skipExisting = false
+): Promise<{
+  imported: number;
+  results: ImportResult[];
+  skipped: string[];
+  ids: Record<string, string>;
+}> {
+  const path = '/api/v1/backups/restore';
+  const query = skipExisting ? 'skipExisting=true' : '';
+  const response = await fetch(`${API_BASE_URL}${path}${query ? `?${query}` : ''}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/x-ndjson',
+      'X-Backup-Key': archiveKey,
+      ...(await signedRequestHeaders('POST', path, archive, walletAddress, sign, query)),
+    },
+    body: archive,
+  });
+
+  const result = await response.json();
+  if (!response.ok) {
+    throw new Error(result.error || 'Failed to restore backup');
+  }
+
+  return result;
+}
+
+export interface CredentialListParams {
+  limit?: number;
+  cursor?: string;
+  sort?: 'name' | 'createdAt' | 'lastAccessed';
+  order?: 'asc' | 'desc';
+  kind?: CredentialKind;
+  q?: string; // Name prefix
+  domain?: string; // URL host, subdomains included
+  folder?: string; // Folder ID (subfolders included) or 'none'
+  tag?: string;
+  shared?: boolean; // true: only shared with me, false: only my own
+  org?: string; // Organization ID: list its vault instead
+}
+
+export interface CredentialPage {
+  items: Credential[];
+  nextCursor?: string;
+  hasMore: boolean;
+}
+
 /**
- * Get all credentials for a wallet
+ * Get one page of the credentials for a wallet. Pass the returned
+ * nextCursor back as params.cursor to fetch the following page.
  */
 export async function getCredentials(
   walletAddress: string,
-  signature: string
-): Promise<Credential[]> {
+  signed: SignedMessage,
+  params: CredentialListParams = {}
+): Promise<CredentialPage> {
+  const query = new URLSearchParams();
+  for (const [key, value] of Object.entries(params)) {
+    if (value !== undefined && value !== '') {
+      query.set(key, String(value));
+    }
+  }
+
   const response = await fetch(
-    `${API_BASE_URL}/api/v1/credentials?wallet=${walletAddress}`,
+    `${API_BASE_URL}/api/v1/credentials?${query.toString()}`,
     {
-      headers: {
-        'X-Wallet-Address': walletAddress,
-        'X-Signature': signature,
-      },
+      headers: walletAuthHeaders(walletAddress, signed),
     }
   );
 
@@ -81,21 +321,78 @@ export async function getCredentials(
   return response.json();
 }
 
+export interface ApprovalRequest {
+  id: string;
+  credentialId: string;
+  requesterWallet: string;
+  action: 'reveal' | 'policy';
+  proposedApprovers?: string[]; // Policy changes: the policy asked for
+  proposedThreshold: number;
+  approvers: string[];
+  threshold: number;
+  expiresAt: string;
+  createdAt: string;
+  approvedAt?: string;
+  releasedAt?: string;
+  approvals: { approverWallet: string; txHash?: string; createdAt: string }[];
+}
+
 /**
- * Get a specific credential with decryption keys
+ * Thrown by getCredentialById for credentials that need M-of-N approval, and
+ * by setApprovalPolicy for changes that weaken the policy in force.
+ * Once request.approvedAt is set, repeat the call with its ID.
+ */
+export class ApprovalRequiredError extends Error {
+  constructor(public request: ApprovalRequest) {
+    super(
+      request.action === 'policy'
+        ? 'Changing this approval policy needs approval'
+        : 'Revealing this credential needs approval'
+    );
+  }
+}
+
+/**
+ * Get a specific credential with decryption keys.
+ * Revealing shares is a step-up operation: the server issues a short-lived
+ * challenge bound to this credential, which the wallet must sign.
  */
 export async function getCredentialById(
   id: string,
   walletAddress: string,
-  signature: string
+  authenticate: () => Promise<SignedMessage>,
+  sign: MessageSigner,
+  approvalRequestId?: string // An approved request, for flagged credentials
 ): Promise<RetrieveCredentialResponse> {
+  const challengeResponse = await fetch(
+    `${API_BASE_URL}/api/v1/credentials/${id}/reveal-challenge`,
+    {
+      method: 'POST',
+      headers: walletAuthHeaders(walletAddress, await authenticate()),
+    }
+  );
+
+  if (!challengeResponse.ok) {
+    throw new Error('Failed to request reveal challenge');
+  }
+
+  const challenge: { nonce: string; message: string } = await challengeResponse.json();
+  const revealSignature = await sign(challenge.message);
+
   const response = await fetch(`${API_BASE_URL}/api/v1/credentials/${id}`, {
     headers: {
-      'X-Wallet-Address': walletAddress,
-      'X-Signature': signature,
+      ...walletAuthHeaders(walletAddress, await authenticate()),
+      'X-Reveal-Nonce': challenge.nonce,
+      'X-Reveal-Signature': revealSignature,
+      ...(approvalRequestId ? { 'X-Approval-Request': approvalRequestId } : {}),
     },
   });
 
+  if (response.status === 202) {
+    const pending: { request: ApprovalRequest } = await response.json();
+    throw new ApprovalRequiredError(pending.request);
+  }
+
   if (!response.ok) {
     throw new Error('Failed to retrieve credential');
   }
@@ -109,14 +406,12 @@ export async function getCredentialById(
 export async function deleteCredential(
   id: string,
   walletAddress: string,
-  signature: string
+  sign: MessageSigner
 ): Promise<void> {
-  const response = await fetch(`${API_BASE_URL}/api/v1/credentials/${id}`, {
+  const path = `/api/v1/credentials/${id}`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
     method: 'DELETE',
-    headers: {
-      'X-Wallet-Address': walletAddress,
-      'X-Signature': signature,
-    },
+    headers: await signedRequestHeaders('DELETE', path, '', walletAddress, sign),
   });
 
   if (!response.ok) {
@@ -124,6 +419,45 @@ export async function deleteCredential(
   }
 }
 
+/**
+ * Get the deleted credentials that can still be restored
+ */
+export async function getTrash(
+  walletAddress: string,
+  signed: SignedMessage,
+  orgId?: string // An organization's trash instead (admins only)
+): Promise<Credential[]> {
+  const query = orgId ? `?org=${encodeURIComponent(orgId)}` : '';
+  const response = await fetch(`${API_BASE_URL}/api/v1/credentials/trash${query}`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch trash');
+  }
+
+  return response.json();
+}
+
+/**
+ * Restore a credential from the trash
+ */
+export async function restoreCredential(
+  id: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<void> {
+  const path = `/api/v1/credentials/${id}/restore`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: await signedRequestHeaders('POST', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to restore credential');
+  }
+}
+
 /**
  * Health check
  */
@@ -132,3 +466,947 @@ export async function healthCheck(): Promise<{ status: string; service: string }
   return response.json();
 }
 
+
+export interface WalletKey {
+  walletAddress: string;
+  publicKey: string;
+  algorithm: string;
+}
+
+export interface CredentialShare {
+  id: string;
+  credentialId: string;
+  ownerWallet: string;
+  recipientWallet: string;
+  version: number;
+  createdAt: string;
+  stale?: boolean; // Wrapped for an older version; share again to refresh
+  emergencyRequestId?: string; // Shared by a granted emergency access request
+}
+
+/**
+ * Publish this wallet's encryption public key so others can share with it
+ * (e.g. from eth_getEncryptionPublicKey)
+ */
+export async function publishEncryptionKey(
+  walletAddress: string,
+  publicKey: string,
+  sign: MessageSigner
+): Promise<WalletKey> {
+  const path = '/api/v1/sharing/key';
+  const body = JSON.stringify({ publicKey });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'PUT',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('PUT', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to publish encryption key');
+  }
+
+  return response.json();
+}
+
+/**
+ * Look up the encryption key a recipient published
+ */
+export async function getWalletKey(
+  address: string,
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<WalletKey> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/sharing/keys/${address}`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Recipient has not published an encryption key');
+  }
+
+  return response.json();
+}
+
+/**
+ * Share a credential. wrappedKey is its data key, rebuilt from the three
+ * shares and wrapped to the recipient's key (wrapKey); version is the
+ * credential version it decrypts. Share again after every update.
+ */
+export async function shareCredential(
+  id: string,
+  walletAddress: string,
+  share: { recipientWallet: string; wrappedKey: string; version: number },
+  sign: MessageSigner
+): Promise<CredentialShare> {
+  const path = `/api/v1/credentials/${id}/shares`;
+  const body = JSON.stringify(share);
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to share credential');
+  }
+
+  return response.json();
+}
+
+/**
+ * List who a credential is shared with
+ */
+export async function getCredentialShares(
+  id: string,
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<CredentialShare[]> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/credentials/${id}/shares`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch shares');
+  }
+
+  return response.json();
+}
+
+/**
+ * Revoke a recipient's access to a credential
+ */
+export async function revokeShare(
+  id: string,
+  shareId: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<void> {
+  const path = `/api/v1/credentials/${id}/shares/${shareId}`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'DELETE',
+    headers: await signedRequestHeaders('DELETE', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to revoke share');
+  }
+}
+
+export interface ShareLink {
+  id: string;
+  credentialId: string;
+  maxViews: number;
+  views: number;
+  expiresAt: string;
+  createdAt: string;
+  destroyedAt?: string;
+}
+
+/**
+ * Create a one-time link. The payload is the credential re-encrypted under a
+ * fresh link key; share1/share2 are shares of that key. Put the remaining
+ * share in the URL fragment so it never reaches the server.
+ */
+export async function createShareLink(
+  id: string,
+  walletAddress: string,
+  link: {
+    encryptedData: string;
+    nonce: string;
+    share1: string;
+    share2: string;
+    expiresIn?: number; // Seconds, default one day
+    maxViews?: number; // Default 1
+  },
+  sign: MessageSigner
+): Promise<{ id: string; token: string; expiresAt: string; maxViews: number }> {
+  const path = `/api/v1/credentials/${id}/links`;
+  const body = JSON.stringify(link);
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to create link');
+  }
+
+  return response.json();
+}
+
+/**
+ * List a credential's share links
+ */
+export async function getShareLinks(
+  id: string,
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<ShareLink[]> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/credentials/${id}/links`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch links');
+  }
+
+  return response.json();
+}
+
+/**
+ * Revoke a share link before it is used up
+ */
+export async function revokeShareLink(
+  id: string,
+  linkId: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<void> {
+  const path = `/api/v1/credentials/${id}/links/${linkId}`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'DELETE',
+    headers: await signedRequestHeaders('DELETE', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to revoke link');
+  }
+}
+
+/**
+ * Redeem a share link (no wallet needed). Uses up one view.
+ */
+export async function redeemShareLink(token: string): Promise<{
+  name: string;
+  kind: CredentialKind;
+  encryptedData: string;
+  nonce: string;
+  share1: string;
+  share2: string;
+  viewsLeft: number;
+  expiresAt: string;
+}> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/links/${token}/redeem`, {
+    method: 'POST',
+  });
+
+  if (!response.ok) {
+    throw new Error('Link is invalid or has expired');
+  }
+
+  return response.json();
+}
+
+export type OrgRole = 'owner' | 'admin' | 'member' | 'read-only';
+
+export interface OrgMember {
+  orgId: string;
+  walletAddress: string;
+  role: OrgRole;
+  addedBy: string;
+  createdAt: string;
+}
+
+export interface Organization {
+  id: string;
+  name: string;
+  createdBy: string;
+  createdAt: string;
+  role: OrgRole; // The caller's role
+  members?: OrgMember[];
+}
+
+/**
+ * Create an organization; the caller becomes its owner
+ */
+export async function createOrganization(
+  walletAddress: string,
+  name: string,
+  sign: MessageSigner
+): Promise<Organization> {
+  const path = '/api/v1/orgs';
+  const body = JSON.stringify({ name });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to create organization');
+  }
+
+  return response.json();
+}
+
+/**
+ * List the organizations this wallet's account belongs to
+ */
+export async function getOrganizations(
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<Organization[]> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/orgs`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch organizations');
+  }
+
+  return response.json();
+}
+
+/**
+ * Get an organization with its members
+ */
+export async function getOrganization(
+  id: string,
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<Organization> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/orgs/${id}`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch organization');
+  }
+
+  return response.json();
+}
+
+/**
+ * Add a member (admins may add members and read-only members; owners any role)
+ */
+export async function addOrgMember(
+  id: string,
+  walletAddress: string,
+  member: { walletAddress: string; role: OrgRole },
+  sign: MessageSigner
+): Promise<OrgMember> {
+  const path = `/api/v1/orgs/${id}/members`;
+  const body = JSON.stringify(member);
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to add member');
+  }
+
+  return response.json();
+}
+
+/**
+ * Change a member's role
+ */
+export async function updateOrgMember(
+  id: string,
+  member: string,
+  role: OrgRole,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<OrgMember> {
+  const path = `/api/v1/orgs/${id}/members/${member}`;
+  const body = JSON.stringify({ role });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'PATCH',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('PATCH', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to update member');
+  }
+
+  return response.json();
+}
+
+/**
+ * Remove a member, or leave the organization by removing your own wallet
+ */
+export async function removeOrgMember(
+  id: string,
+  member: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<void> {
+  const path = `/api/v1/orgs/${id}/members/${member}`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'DELETE',
+    headers: await signedRequestHeaders('DELETE', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to remove member');
+  }
+}
+
+export interface OrgMemberKey {
+  id: string;
+  orgId: string;
+  memberWallet: string;
+  credentialId: string;
+  version: number; // Credential version the key decrypts
+  wrappedBy: string;
+  updatedAt: string;
+  stale: boolean; // Wrapped for an older version; wrap it again
+}
+
+/**
+ * Wrap organization credential keys to a member (admins only). Only the
+ * member who saved a credential holds its share3; everyone else reveals it
+ * through a key wrapped to their published encryption key (wrapKey).
+ * Re-wrap after updating a credential.
+ */
+export async function setOrgMemberKeys(
+  id: string,
+  member: string,
+  keys: { credentialId: string; wrappedKey: string; version: number }[],
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<{ wrapped: number }> {
+  const path = `/api/v1/orgs/${id}/members/${member}/keys`;
+  const body = JSON.stringify({ keys });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'PUT',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('PUT', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to wrap member keys');
+  }
+
+  return response.json();
+}
+
+/**
+ * Keys wrapped to an organization's members (admins only)
+ */
+export async function getOrgMemberKeys(
+  id: string,
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<OrgMemberKey[]> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/orgs/${id}/keys`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch member keys');
+  }
+
+  return response.json();
+}
+
+export interface OrgAuditLog {
+  id: string;
+  credentialId: string;
+  credentialName: string;
+  walletAddress: string; // The member who acted
+  action: string;
+  timestamp: string;
+  txHash?: string;
+  apiKeyId?: string;
+}
+
+/**
+ * Every member's access to an organization's credentials (admins only)
+ */
+export async function getOrgAuditLogs(
+  id: string,
+  walletAddress: string,
+  signed: SignedMessage,
+  member?: string
+): Promise<{ logs: OrgAuditLog[]; count: number }> {
+  const query = member ? `?wallet=${encodeURIComponent(member)}` : '';
+  const response = await fetch(`${API_BASE_URL}/api/v1/orgs/${id}/audit-logs${query}`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch audit logs');
+  }
+
+  return response.json();
+}
+
+/**
+ * Require M of the given approver wallets to approve every reveal
+ * (threshold 0 removes the requirement). Lowering, removing or adding
+ * approvers to a policy in force needs the current approvers' approval.
+ */
+export async function setApprovalPolicy(
+  id: string,
+  walletAddress: string,
+  policy: { approvers: string[]; threshold: number },
+  sign: MessageSigner,
+  approvalRequestId?: string // An approved request for this change
+): Promise<void> {
+  const path = `/api/v1/credentials/${id}/approval-policy`;
+  const body = JSON.stringify(policy);
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'PUT',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('PUT', path, body, walletAddress, sign)),
+      ...(approvalRequestId ? { 'X-Approval-Request': approvalRequestId } : {}),
+    },
+    body,
+  });
+
+  if (response.status === 202) {
+    const pending: { request: ApprovalRequest } = await response.json();
+    throw new ApprovalRequiredError(pending.request);
+  }
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to set approval policy');
+  }
+}
+
+/**
+ * Open requests this wallet's account is an approver of
+ */
+export async function getApprovalRequests(
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<ApprovalRequest[]> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/approvals`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch approval requests');
+  }
+
+  return response.json();
+}
+
+/**
+ * Approve a reveal or policy change request. The approver wallet signs the request's message
+ * (from GET /approvals/:id); the approval is recorded on the blockchain.
+ */
+export async function approveRequest(
+  requestId: string,
+  walletAddress: string,
+  authenticate: () => Promise<SignedMessage>,
+  sign: MessageSigner
+): Promise<{ approvals: number; threshold: number; approved: boolean; txHash?: string }> {
+  const detail = await fetch(`${API_BASE_URL}/api/v1/approvals/${requestId}`, {
+    headers: walletAuthHeaders(walletAddress, await authenticate()),
+  });
+
+  if (!detail.ok) {
+    throw new Error('Failed to fetch approval request');
+  }
+
+  const { message }: { message: string } = await detail.json();
+  const signature = await sign(message);
+
+  const path = `/api/v1/approvals/${requestId}/approve`;
+  const body = JSON.stringify({ signature });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to approve');
+  }
+
+  return response.json();
+}
+
+export interface EmergencyContact {
+  id: string;
+  ownerWallet: string;
+  contactWallet: string;
+  waitPeriod: number; // Seconds the owner has to deny a request
+  createdAt: string;
+}
+
+export interface EmergencyRequest {
+  id: string;
+  contactId: string;
+  ownerWallet: string;
+  contactWallet: string;
+  createdAt: string;
+  grantAt: string; // Granted automatically from then on unless denied
+  deniedAt?: string;
+  grantedAt?: string;
+  granted: number;
+  skipped: number;
+  requestTxHash?: string;
+  resultTxHash?: string;
+}
+
+/**
+ * Name an emergency contact. The contact must have published an encryption
+ * key (publishEncryptionKey); escrow credential keys wrapped to it with
+ * escrowEmergencyKeys, which are released to the contact when access is
+ * granted.
+ */
+export async function addEmergencyContact(
+  walletAddress: string,
+  contact: { contactWallet: string; waitPeriod?: number }, // waitPeriod in seconds, default 7 days
+  sign: MessageSigner
+): Promise<{ contact: EmergencyContact; txId?: string }> {
+  const path = '/api/v1/emergency/contacts';
+  const body = JSON.stringify(contact);
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to add emergency contact');
+  }
+
+  return response.json();
+}
+
+/**
+ * Get this account's emergency contacts and the owners who trust it
+ */
+export async function getEmergencyContacts(
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<{ contacts: EmergencyContact[]; trustedBy: EmergencyContact[] }> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/emergency/contacts`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch emergency contacts');
+  }
+
+  return response.json();
+}
+
+export interface EmergencyEscrow {
+  id: string;
+  contactId: string;
+  credentialId: string;
+  version: number;
+  updatedAt: string;
+  stale: boolean; // Wrapped for an older version; escrow it again
+}
+
+/**
+ * Escrow credential keys for an emergency contact. Each wrappedKey is the
+ * credential's data key wrapped to the contact's key (wrapKey); version is
+ * the credential version it decrypts. Escrow again after every update.
+ */
+export async function escrowEmergencyKeys(
+  contactId: string,
+  walletAddress: string,
+  keys: { credentialId: string; wrappedKey: string; version: number }[],
+  sign: MessageSigner
+): Promise<{ escrowed: number }> {
+  const path = `/api/v1/emergency/contacts/${contactId}/keys`;
+  const body = JSON.stringify({ keys });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'PUT',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('PUT', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to escrow keys');
+  }
+
+  return response.json();
+}
+
+/**
+ * List the credential keys escrowed for an emergency contact
+ */
+export async function getEscrowedKeys(
+  contactId: string,
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<EmergencyEscrow[]> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/emergency/contacts/${contactId}/keys`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch escrowed keys');
+  }
+
+  return response.json();
+}
+
+/**
+ * Remove an emergency contact, revoking anything already shared with them
+ */
+export async function revokeEmergencyContact(
+  id: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<void> {
+  const path = `/api/v1/emergency/contacts/${id}`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'DELETE',
+    headers: await signedRequestHeaders('DELETE', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to revoke emergency contact');
+  }
+}
+
+/**
+ * As an emergency contact, request access to the owner's credentials
+ */
+export async function requestEmergencyAccess(
+  contactId: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<EmergencyRequest> {
+  const path = `/api/v1/emergency/contacts/${contactId}/request`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: await signedRequestHeaders('POST', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to request emergency access');
+  }
+
+  return response.json();
+}
+
+/**
+ * Emergency requests made of or by this account
+ */
+export async function getEmergencyRequests(
+  walletAddress: string,
+  signed: SignedMessage
+): Promise<EmergencyRequest[]> {
+  const response = await fetch(`${API_BASE_URL}/api/v1/emergency/requests`, {
+    headers: walletAuthHeaders(walletAddress, signed),
+  });
+
+  if (!response.ok) {
+    throw new Error('Failed to fetch emergency requests');
+  }
+
+  return response.json();
+}
+
+/**
+ * Deny a pending emergency request before its grant time
+ */
+export async function denyEmergencyRequest(
+  id: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<void> {
+  const path = `/api/v1/emergency/requests/${id}/deny`;
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: await signedRequestHeaders('POST', path, '', walletAddress, sign),
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to deny request');
+  }
+}
+
+export interface RecoveryResponse {
+  id: string;
+  version: number;
+  lost: 'share1' | 'share2';
+  share1?: string; // The surviving share: share1 if share2 was lost
+  share2?: string; // ...or share2 if share1 was lost
+  refreshDeltas?: RefreshDelta[]; // Apply to share3 before recoverSecret
+  encryptedData: string;
+  nonce: string;
+  metadata?: Record<string, string>;
+}
+
+/**
+ * Prove possession of share3 after Vault lost share1 or the ledger lost
+ * share2, and get the surviving share back. Rebuild the key with
+ * recoverSecret, then resplitCredential.
+ */
+export async function recoverCredential(
+  id: string,
+  share3: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<RecoveryResponse> {
+  const path = `/api/v1/credentials/${id}/recover`;
+  const body = JSON.stringify({ share3 });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to recover credential');
+  }
+
+  return response.json();
+}
+
+/**
+ * Store fresh shares of the same key as a new version, after recoverCredential.
+ * The ciphertext is unchanged; keep the new share3 as the backup.
+ */
+export async function resplitCredential(
+  recovery: RecoveryResponse,
+  shares: { share1: string; share2: string; share3Hash: string },
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<{ id: string; version: number; txId: string }> {
+  const path = `/api/v1/credentials/${recovery.id}`;
+  const body = JSON.stringify({
+    encryptedData: recovery.encryptedData,
+    nonce: recovery.nonce,
+    metadata: recovery.metadata,
+    version: recovery.version,
+    splitScheme: 'recovery',
+    ...shares,
+  });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'PUT',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('PUT', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to re-split credential');
+  }
+
+  return response.json();
+}
+
+/**
+ * Give Vault and the ledger new shares of the same key; encryptedData is
+ * untouched and the replaced shares are destroyed. Without fresh shares the
+ * server re-randomizes them; for 'recovery' splits that hands out
+ * refreshDeltas (the wallet must have published an encryption key).
+ * Credentials without a splitScheme need a new splitSecret of the key: pass
+ * it as fresh and keep its share3.
+ */
+export async function refreshCredentialShares(
+  id: string,
+  walletAddress: string,
+  sign: MessageSigner,
+  fresh?: { share1: string; share2: string; share3Hash: string }
+): Promise<{
+  id: string;
+  version: number;
+  sharesRefreshedAt: string;
+  txId: string;
+  refreshDeltas?: RefreshDelta[];
+}> {
+  const path = `/api/v1/credentials/${id}/refresh`;
+  const body = fresh ? JSON.stringify(fresh) : '';
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      ...(fresh ? { 'Content-Type': 'application/json' } : {}),
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body: body || undefined,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to refresh shares');
+  }
+
+  return response.json();
+}
+
+/**
+ * Commit to share3 after applying the refresh deltas it was handed
+ * (applyRefreshDeltas). deltaIds must name every pending delta.
+ */
+export async function applyRefresh(
+  id: string,
+  deltaIds: string[],
+  share3Hash: string,
+  walletAddress: string,
+  sign: MessageSigner
+): Promise<{ id: string; version: number; share3Hash: string }> {
+  const path = `/api/v1/credentials/${id}/refresh/apply`;
+  const body = JSON.stringify({ deltaIds, share3Hash });
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(await signedRequestHeaders('POST', path, body, walletAddress, sign)),
+    },
+    body,
+  });
+
+  if (!response.ok) {
+    const error = await response.json();
+    throw new Error(error.error || 'Failed to apply refresh deltas');
+  }
+
+  return response.json();
+}
@@ -4,6 +4,7 @@
 import { xchacha20poly1305 } from '@noble/ciphers/chacha';
 import { randomBytes } from '@noble/ciphers/webcrypto';
 import { utf8ToBytes, bytesToUtf8 } from '@noble/ciphers/utils';
+import nacl from 'tweetnacl';
 
 /**
  * Generate a random 256-bit key for XChaCha20-Poly1305
@@ -65,25 +66,26 @@ export function decryptData(
 }
 
 /**
- * Split a secret into shares using simple XOR-based secret sharing
- * For production, use a proper Shamir Secret Sharing library
- * This is a simplified 2-of-3 implementation
+ * Split a secret into shares for the 2-of-3 custody model: share1 (Vault) and
+ * share2 (Blockchain) are random pads, and share3 (client backup) is the secret
+ * under each pad. Either server share plus share3 recovers the secret, while
+ * share1 and share2 together reveal nothing.
  */
 export function splitSecret(secret: Uint8Array): {
   share1: string;
   share2: string;
   share3: string;
 } {
-  // Generate two random shares
   const share1 = randomBytes(32);
   const share2 = randomBytes(32);
-  
-  // Third share is XOR of secret with first two shares
-  const share3 = new Uint8Array(32);
+
+  // share3 = (secret ^ share1) || (secret ^ share2)
+  const share3 = new Uint8Array(64);
   for (let i = 0; i < 32; i++) {
-    share3[i] = secret[i] ^ share1[i] ^ share2[i];
+    share3[i] = secret[i] ^ share1[i];
+    share3[32 + i] = secret[i] ^ share2[i];
   }
-  
+
   return {
     share1: bufferToBase64(share1),
     share2: bufferToBase64(share2),
@@ -92,7 +94,8 @@ export function splitSecret(secret: Uint8Array): {
 }
 
 /**
- * Reconstruct secret from any 2 of 3 shares
+ * Reconstruct the secret from its shares. A 32-byte share3 is from the older
+ * XOR split, which needs all three shares.
  */
 export function reconstructSecret(
   share1: string,
@@ -102,16 +105,114 @@ export function reconstructSecret(
   const s1 = base64ToBuffer(share1);
   const s2 = base64ToBuffer(share2);
   const s3 = base64ToBuffer(share3);
-  
-  // Reconstruct using XOR
+
   const secret = new Uint8Array(32);
+  if (s3.length === 32) {
+    for (let i = 0; i < 32; i++) {
+      secret[i] = s1[i] ^ s2[i] ^ s3[i];
+    }
+    return secret;
+  }
+
   for (let i = 0; i < 32; i++) {
-    secret[i] = s1[i] ^ s2[i] ^ s3[i];
+    secret[i] = s3[i] ^ s1[i];
+    if ((s3[32 + i] ^ s2[i]) !== secret[i]) {
+      throw new Error('Shares do not belong to the same secret');
+    }
+  }
+  return secret;
+}
+
+/**
+ * Recover the secret from share3 and whichever server share survived
+ * (see recoverCredential)
+ */
+export function recoverSecret(
+  share3: string,
+  surviving: { share1?: string; share2?: string }
+): Uint8Array {
+  const s3 = base64ToBuffer(share3);
+  if (s3.length !== 64) {
+    throw new Error('This backup share predates recovery support');
+  }
+
+  const [pad, offset] = surviving.share1
+    ? [base64ToBuffer(surviving.share1), 0]
+    : [base64ToBuffer(surviving.share2 ?? ''), 32];
+  if (pad.length !== 32) {
+    throw new Error('Missing surviving share');
+  }
+
+  const secret = new Uint8Array(32);
+  for (let i = 0; i < 32; i++) {
+    secret[i] = s3[offset + i] ^ pad[i];
   }
-  
   return secret;
 }
 
+/**
+ * Apply the refresh deltas (each the eth_decrypt result of a wrappedDelta) a
+ * server-made share refresh handed out: share3 ^ (pad1 || pad2) for each.
+ * Commit to the result with applyRefresh (api) and keep it as the backup.
+ */
+export function applyRefreshDeltas(share3: string, decrypted: string[]): string {
+  const s3 = base64ToBuffer(share3);
+  if (s3.length !== 64) {
+    throw new Error('This backup share predates recovery support');
+  }
+  for (const delta of decrypted) {
+    const pads = base64ToBuffer(delta);
+    if (pads.length !== 64) {
+      throw new Error('Refresh delta is not a pair of pads');
+    }
+    for (let i = 0; i < 64; i++) {
+      s3[i] ^= pads[i];
+    }
+  }
+  return bufferToBase64(s3);
+}
+
+/**
+ * Commitment to share3 the server keeps to verify a later recovery
+ */
+export function commitShare(share3: string): Promise<string> {
+  return hashData(share3);
+}
+
+/**
+ * Wrap a credential's data key to another wallet's published encryption key
+ * (eth_getEncryptionPublicKey). Returns MetaMask's EthEncryptedData as JSON;
+ * the recipient opens it with eth_decrypt and passes the result to
+ * unwrapKey.
+ */
+export function wrapKey(key: Uint8Array, publicKey: string): string {
+  const ephemeral = nacl.box.keyPair();
+  const nonce = randomBytes(nacl.box.nonceLength);
+  const sealed = nacl.box(
+    utf8ToBytes(bufferToBase64(key)),
+    nonce,
+    base64ToBuffer(publicKey),
+    ephemeral.secretKey
+  );
+  return JSON.stringify({
+    version: 'x25519-xsalsa20-poly1305',
+    nonce: bufferToBase64(nonce),
+    ephemPublicKey: bufferToBase64(ephemeral.publicKey),
+    ciphertext: bufferToBase64(sealed),
+  });
+}
+
+/**
+ * Data key from the eth_decrypt result of a wrapped key
+ */
+export function unwrapKey(decrypted: string): Uint8Array {
+  const key = base64ToBuffer(decrypted);
+  if (key.length !== 32) {
+    throw new Error('Wrapped key is not a credential key');
+  }
+  return key;
+}
+
 /**
  * Hash data using SHA-256
  */
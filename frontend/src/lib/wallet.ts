@@ -1,14 +1,22 @@
 // Wallet signing utilities
 
-import { useSignMessage } from 'wagmi';
+import { useAccount, useSignMessage } from 'wagmi';
 import { hashData } from './crypto';
 
+/**
+ * First lines of every message signed for wallet authentication. The server
+ * only accepts messages made for its domain and the signing wallet.
+ */
+export function authMessageHeader(walletAddress: string): string {
+  return `pass-chain auth\nDomain: ${window.location.host}\nWallet: ${walletAddress}\n`;
+}
+
 /**
  * Generate a message to sign for authentication
  */
-export function generateAuthMessage(action: string, timestamp?: number): string {
+export function generateAuthMessage(action: string, walletAddress: string, timestamp?: number): string {
   const ts = timestamp || Date.now();
-  return `Pass Chain Authentication\n\nAction: ${action}\nTimestamp: ${ts}\n\nThis signature proves you own this wallet.`;
+  return `${authMessageHeader(walletAddress)}\nAction: ${action}\nTimestamp: ${ts}\n\nThis signature proves you own this wallet.`;
 }
 
 /**
@@ -16,11 +24,12 @@ export function generateAuthMessage(action: string, timestamp?: number): string
  */
 export function generateCredentialMessage(
   action: 'create' | 'read' | 'delete',
+  walletAddress: string,
   credentialId?: string,
   credentialName?: string
 ): string {
   const timestamp = Date.now();
-  let message = `Pass Chain - ${action.toUpperCase()} Credential\n\n`;
+  let message = `${authMessageHeader(walletAddress)}\nPass Chain - ${action.toUpperCase()} Credential\n\n`;
   
   if (credentialName) {
     message += `Credential: ${credentialName}\n`;
@@ -40,9 +49,10 @@ export function generateCredentialMessage(
  */
 export function useWalletSigner() {
   const { signMessageAsync } = useSignMessage();
+  const { address } = useAccount();
   
   const signAuth = async (action: string) => {
-    const message = generateAuthMessage(action);
+    const message = generateAuthMessage(action, address ?? '');
     const signature = await signMessageAsync({ message });
     return { message, signature };
   };
@@ -52,14 +62,17 @@ export function useWalletSigner() {
     credentialId?: string,
     credentialName?: string
   ) => {
-    const message = generateCredentialMessage(action, credentialId, credentialName);
+    const message = generateCredentialMessage(action, address ?? '', credentialId, credentialName);
     const signature = await signMessageAsync({ message });
     return { message, signature };
   };
   
+  const signMessage = (message: string) => signMessageAsync({ message });
+  
   return {
     signAuth,
     signCredentialOperation,
+    signMessage,
   };
 }
 